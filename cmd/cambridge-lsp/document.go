@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+	"github.com/andrinoff/cambridge-lang/pkg/lexer"
+	"github.com/andrinoff/cambridge-lang/pkg/parser"
+	"github.com/andrinoff/cambridge-lang/pkg/token"
+)
+
+// document is the cached state of an open text document. It is rebuilt once
+// per change so that diagnostics, highlighting, symbols and completion all
+// see the same parse, even when the document has errors.
+type document struct {
+	Text    string
+	Tokens  []token.Token // every token up to EOF
+	Program *ast.Program  // partial if Errors is not empty
+	Errors  []parser.ParseError
+}
+
+// parseDocument lexes and parses text once
+func parseDocument(text string) *document {
+	doc := &document{Text: text}
+
+	tokens := lexer.New(text)
+	for tok := tokens.NextToken(); tok.Type != token.EOF; tok = tokens.NextToken() {
+		doc.Tokens = append(doc.Tokens, tok)
+	}
+
+	l := lexer.New(text)
+	p := parser.New(l)
+	doc.Program = p.ParseProgram()
+	doc.Errors = p.StructuredErrors()
+
+	return doc
+}
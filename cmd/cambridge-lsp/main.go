@@ -11,28 +11,32 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/andrinoff/cambridge-lang/pkg/lexer"
-	"github.com/andrinoff/cambridge-lang/pkg/parser"
+	"github.com/andrinoff/cambridge-lang/pkg/analyzer"
+	"github.com/andrinoff/cambridge-lang/pkg/builtins"
+	"github.com/andrinoff/cambridge-lang/pkg/formatter"
+	"github.com/andrinoff/cambridge-lang/pkg/interpreter"
 	"github.com/andrinoff/cambridge-lang/pkg/token"
 )
 
 // LSP Types
 const (
-	TokenKeyword  = 0
-	TokenString   = 1
-	TokenNumber   = 2
-	TokenOperator = 3
-	TokenVariable = 4
-	TokenComment  = 5
+	TokenKeyword   = 0
+	TokenString    = 1
+	TokenNumber    = 2
+	TokenOperator  = 3
+	TokenVariable  = 4
+	TokenComment   = 5
+	TokenFunction  = 6
+	TokenParameter = 7
 )
 
 var tokenTypes = []string{
-	"keyword", "string", "number", "operator", "variable", "comment",
+	"keyword", "string", "number", "operator", "variable", "comment", "function", "parameter",
 }
 
 func main() {
 	reader := bufio.NewReader(os.Stdin)
-	documents := make(map[string]string) // Cache document content
+	documents := make(map[string]*document) // Cached text and parse
 
 	for {
 		// Read Header
@@ -73,6 +77,10 @@ func main() {
 						"range": true,
 						"full":  true,
 					},
+					"inlayHintProvider":          true,
+					"hoverProvider":              true,
+					"definitionProvider":         true,
+					"documentFormattingProvider": true,
 				},
 			})
 		}
@@ -82,9 +90,8 @@ func main() {
 			params := request["params"].(map[string]interface{})
 			doc := params["textDocument"].(map[string]interface{})
 			uri := doc["uri"].(string)
-			text := doc["text"].(string)
-			documents[uri] = text
-			publishDiagnostics(uri, text)
+			documents[uri] = parseDocument(doc["text"].(string))
+			publishDiagnostics(uri, documents[uri])
 		} else if method == "textDocument/didChange" {
 			params := request["params"].(map[string]interface{})
 			doc := params["textDocument"].(map[string]interface{})
@@ -92,31 +99,80 @@ func main() {
 			changes := params["contentChanges"].([]interface{})
 			if len(changes) > 0 {
 				lastChange := changes[len(changes)-1].(map[string]interface{})
-				text := lastChange["text"].(string)
-				documents[uri] = text
-				publishDiagnostics(uri, text)
+				documents[uri] = parseDocument(lastChange["text"].(string))
+				publishDiagnostics(uri, documents[uri])
 			}
 		}
 
 		// --- COMPLETION ---
 		if method == "textDocument/completion" {
-			items := []map[string]interface{}{}
-
-			// Add Keywords
-			for k := range token.Keywords {
-				items = append(items, map[string]interface{}{
-					"label":  k,
-					"kind":   14, // Keyword
-					"detail": "keyword",
-				})
+			params := request["params"].(map[string]interface{})
+			docParams := params["textDocument"].(map[string]interface{})
+			uri := docParams["uri"].(string)
+
+			if doc, ok := documents[uri]; ok {
+				sendResponse(request["id"], computeCompletion(doc))
+			} else {
+				sendResponse(request["id"], computeCompletion(parseDocument("")))
 			}
+		}
 
-			// Sort for consistency
-			sort.Slice(items, func(i, j int) bool {
-				return items[i]["label"].(string) < items[j]["label"].(string)
-			})
+		// --- HOVER ---
+		if method == "textDocument/hover" {
+			params := request["params"].(map[string]interface{})
+			docParams := params["textDocument"].(map[string]interface{})
+			uri := docParams["uri"].(string)
+			position := params["position"].(map[string]interface{})
+			line := int(position["line"].(float64))
+			character := int(position["character"].(float64))
+
+			if doc, ok := documents[uri]; ok {
+				sendResponse(request["id"], computeHover(doc, line, character))
+			} else {
+				sendResponse(request["id"], nil)
+			}
+		}
+
+		// --- GO TO DEFINITION ---
+		if method == "textDocument/definition" {
+			params := request["params"].(map[string]interface{})
+			docParams := params["textDocument"].(map[string]interface{})
+			uri := docParams["uri"].(string)
+			position := params["position"].(map[string]interface{})
+			line := int(position["line"].(float64))
+			character := int(position["character"].(float64))
+
+			if doc, ok := documents[uri]; ok {
+				sendResponse(request["id"], computeDefinition(uri, doc, line, character))
+			} else {
+				sendResponse(request["id"], nil)
+			}
+		}
+
+		// --- FORMATTING ---
+		if method == "textDocument/formatting" {
+			params := request["params"].(map[string]interface{})
+			docParams := params["textDocument"].(map[string]interface{})
+			uri := docParams["uri"].(string)
+
+			if doc, ok := documents[uri]; ok {
+				sendResponse(request["id"], computeFormatting(doc))
+			} else {
+				sendResponse(request["id"], nil)
+			}
+		}
 
-			sendResponse(request["id"], items)
+		// --- INLAY HINTS ---
+		if method == "textDocument/inlayHint" {
+			params := request["params"].(map[string]interface{})
+			docParams := params["textDocument"].(map[string]interface{})
+			uri := docParams["uri"].(string)
+
+			if doc, ok := documents[uri]; ok {
+				sendResponse(request["id"], computeInlayHints(doc))
+			} else {
+				sendResponse(request["id"], nil)
+			}
 		}
 
 		// --- SEMANTIC TOKENS (HIGHLIGHTING) ---
@@ -125,8 +181,8 @@ func main() {
 			docParams := params["textDocument"].(map[string]interface{})
 			uri := docParams["uri"].(string)
 
-			if text, ok := documents[uri]; ok {
-				data := computeSemanticTokens(text)
+			if doc, ok := documents[uri]; ok {
+				data := computeSemanticTokens(doc)
 				sendResponse(request["id"], map[string]interface{}{
 					"data": data,
 				})
@@ -137,14 +193,15 @@ func main() {
 	}
 }
 
-func computeSemanticTokens(text string) []int {
-	l := lexer.New(text)
+func computeSemanticTokens(doc *document) []int {
 	var data []int
 
 	lastLine := 0
 	lastStart := 0
+	symbols := collectSymbols(doc.Program)
+	builtinFns := builtins.GetBuiltins()
 
-	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	for idx, tok := range doc.Tokens {
 		tokenType := -1
 
 		// Map Token Type to LSP Token Type
@@ -157,7 +214,7 @@ func computeSemanticTokens(text string) []int {
 			case token.STRING_LIT, token.CHAR_LIT:
 				tokenType = TokenString
 			case token.IDENT:
-				tokenType = TokenVariable
+				tokenType = identifierTokenType(doc, symbols, builtinFns, idx)
 			case token.ASSIGN, token.PLUS, token.MINUS, token.ASTERISK, token.SLASH,
 				token.EQ, token.NOT_EQ, token.LT, token.GT, token.LT_EQ, token.GT_EQ:
 				tokenType = TokenOperator
@@ -179,7 +236,7 @@ func computeSemanticTokens(text string) []int {
 			deltaStart = col - lastStart
 		}
 
-		length := len(tok.Literal)
+		length := utf16Len(tok.Literal)
 
 		data = append(data, deltaLine, deltaStart, length, tokenType, 0)
 
@@ -190,41 +247,228 @@ func computeSemanticTokens(text string) []int {
 	return data
 }
 
-func publishDiagnostics(uri, text string) {
-	l := lexer.New(text)
-	p := parser.New(l)
-	p.ParseProgram()
-	diagnostics := []map[string]interface{}{}
+// identifierTokenType classifies the identifier at doc.Tokens[idx] by what
+// its name resolves to in the parsed program: procedure and function names
+// at their definitions and call sites, parameters, or variables. Builtins
+// count as functions where they are called.
+func identifierTokenType(doc *document, symbols []symbol, builtinFns map[string]*interpreter.Builtin, idx int) int {
+	tok := doc.Tokens[idx]
+
+	if sym, ok := resolveSymbol(doc.Program, symbols, tok.Literal, tok.Line); ok {
+		switch sym.Kind {
+		case "procedure", "function":
+			return TokenFunction
+		case "parameter":
+			return TokenParameter
+		}
+		return TokenVariable
+	}
 
-	for _, errStr := range p.Errors() {
-		parts := strings.SplitN(errStr, ": ", 2)
-		if len(parts) < 2 {
-			continue
+	if _, ok := builtinFns[tok.Literal]; ok && idx+1 < len(doc.Tokens) && doc.Tokens[idx+1].Type == token.LPAREN {
+		return TokenFunction
+	}
+	return TokenVariable
+}
+
+// completionKinds maps symbol kinds to LSP CompletionItemKind values
+var completionKinds = map[string]int{
+	"variable":  6,
+	"parameter": 6,
+	"constant":  21,
+	"procedure": 3,
+	"function":  3,
+}
+
+// computeCompletion offers keywords, builtins and the names defined in the
+// document, sorted by label with duplicates removed
+func computeCompletion(doc *document) []map[string]interface{} {
+	items := []map[string]interface{}{}
+	seen := make(map[string]bool)
+
+	add := func(item map[string]interface{}) {
+		label := item["label"].(string)
+		if seen[label] {
+			return
 		}
+		seen[label] = true
+		items = append(items, item)
+	}
 
-		locParts := strings.Split(parts[0], ",")
-		lineStr := strings.TrimPrefix(strings.TrimSpace(locParts[0]), "line ")
-		colStr := strings.TrimPrefix(strings.TrimSpace(locParts[1]), "column ")
+	// Add Keywords
+	for k := range token.Keywords {
+		add(map[string]interface{}{
+			"label":  k,
+			"kind":   14, // Keyword
+			"detail": "keyword",
+		})
+	}
 
-		line, _ := strconv.Atoi(lineStr)
-		col, _ := strconv.Atoi(colStr)
+	// Add Builtins
+	for _, b := range builtins.GetBuiltins() {
+		add(map[string]interface{}{
+			"label":         b.Name,
+			"kind":          3, // Function
+			"detail":        b.Signature,
+			"documentation": b.Description,
+		})
+	}
+
+	// Add document symbols
+	for _, sym := range collectSymbols(doc.Program) {
+		add(map[string]interface{}{
+			"label":  sym.Name,
+			"kind":   completionKinds[sym.Kind],
+			"detail": sym.Detail,
+		})
+	}
+
+	// Sort for consistency
+	sort.Slice(items, func(i, j int) bool {
+		return items[i]["label"].(string) < items[j]["label"].(string)
+	})
+
+	return items
+}
+
+// computeHover describes the builtin or declared symbol at the given 0-based
+// position, or returns nil if there is nothing to show
+func computeHover(doc *document, line, character int) interface{} {
+	word := wordAt(doc.Text, line, character)
+	if word == "" {
+		return nil
+	}
 
+	var value string
+	if b, ok := builtins.GetBuiltins()[word]; ok {
+		value = "```\n" + b.Signature + "\n```\n" + b.Description
+	} else {
+		sym, ok := lookupSymbol(doc.Program, word, line+1)
+		if !ok {
+			return nil
+		}
+		value = "```\n" + sym.Detail + "\n```"
+	}
+
+	return map[string]interface{}{
+		"contents": map[string]interface{}{
+			"kind":  "markdown",
+			"value": value,
+		},
+	}
+}
+
+// computeDefinition returns the location where the symbol at the given
+// 0-based position is defined, or nil for builtins and unknown names
+func computeDefinition(uri string, doc *document, line, character int) interface{} {
+	word := wordAt(doc.Text, line, character)
+	if word == "" {
+		return nil
+	}
+
+	sym, ok := lookupSymbol(doc.Program, word, line+1)
+	if !ok {
+		return nil
+	}
+
+	start := sym.Token.Column - 1
+	return map[string]interface{}{
+		"uri": uri,
+		"range": map[string]interface{}{
+			"start": map[string]int{"line": sym.Token.Line - 1, "character": start},
+			"end":   map[string]int{"line": sym.Token.Line - 1, "character": start + utf16Len(sym.Token.Literal)},
+		},
+	}
+}
+
+// computeFormatting reprints the document in canonical form as a single
+// edit replacing the whole text. Documents the formatter rejects, such as
+// those that fail to parse, are left untouched.
+func computeFormatting(doc *document) []map[string]interface{} {
+	edits := []map[string]interface{}{}
+
+	if len(doc.Errors) > 0 {
+		return edits
+	}
+
+	formatted, err := formatter.Format(doc.Text)
+	if err != nil || formatted == doc.Text {
+		return edits
+	}
+
+	return append(edits, map[string]interface{}{
+		"range": map[string]interface{}{
+			"start": map[string]int{"line": 0, "character": 0},
+			"end":   map[string]int{"line": strings.Count(doc.Text, "\n") + 1, "character": 0},
+		},
+		"newText": formatted,
+	})
+}
+
+func computeInlayHints(doc *document) []map[string]interface{} {
+	hints := []map[string]interface{}{}
+
+	if len(doc.Errors) > 0 {
+		return hints
+	}
+
+	for _, h := range analyzer.TypeHints(doc.Program) {
+		hints = append(hints, map[string]interface{}{
+			"position": map[string]int{"line": h.Line - 1, "character": h.Column - 1},
+			"label":    ": " + h.Type,
+			"kind":     1, // Type
+		})
+	}
+
+	return hints
+}
+
+// computeDiagnostics reports the document's parse errors, or the analyzer's
+// warnings when it parsed cleanly
+func computeDiagnostics(doc *document) []map[string]interface{} {
+	diagnostics := []map[string]interface{}{}
+
+	for _, err := range doc.Errors {
 		diagnostics = append(diagnostics, map[string]interface{}{
-			"range": map[string]interface{}{
-				"start": map[string]int{"line": line - 1, "character": col - 1},
-				"end":   map[string]int{"line": line - 1, "character": col + 10},
-			},
+			"range":    diagnosticRange(err.Line, err.Column, err.Length),
 			"severity": 1, // Error
-			"message":  parts[1],
+			"message":  err.Message,
 		})
 	}
 
+	// Static analysis only runs on programs that parsed cleanly
+	if len(doc.Errors) == 0 {
+		for _, w := range analyzer.Analyze(doc.Program) {
+			diagnostics = append(diagnostics, map[string]interface{}{
+				"range":    diagnosticRange(w.Line, w.Column, w.Length),
+				"severity": 2, // Warning
+				"message":  w.Message,
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// diagnosticRange returns the LSP range covering length characters from a
+// 1-based line and column. An empty span is widened to one character so that
+// the editor still has something to underline.
+func diagnosticRange(line, column, length int) map[string]interface{} {
+	if length < 1 {
+		length = 1
+	}
+	return map[string]interface{}{
+		"start": map[string]int{"line": line - 1, "character": column - 1},
+		"end":   map[string]int{"line": line - 1, "character": column - 1 + length},
+	}
+}
+
+func publishDiagnostics(uri string, doc *document) {
 	notification := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"method":  "textDocument/publishDiagnostics",
 		"params": map[string]interface{}{
 			"uri":         uri,
-			"diagnostics": diagnostics,
+			"diagnostics": computeDiagnostics(doc),
 		},
 	}
 	msg, _ := json.Marshal(notification)
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andrinoff/cambridge-lang/pkg/token"
+)
+
+func hoverValue(t *testing.T, text string, line, character int) string {
+	t.Helper()
+	result := computeHover(parseDocument(text), line, character)
+	if result == nil {
+		return ""
+	}
+	contents := result.(map[string]interface{})["contents"].(map[string]interface{})
+	return contents["value"].(string)
+}
+
+func TestHoverBuiltin(t *testing.T) {
+	text := `OUTPUT LENGTH("abc")`
+
+	value := hoverValue(t, text, 0, 9)
+	if !strings.Contains(value, "LENGTH(s: STRING) RETURNS INTEGER") {
+		t.Errorf("expected LENGTH signature, got %q", value)
+	}
+}
+
+func TestHoverDeclaredVariable(t *testing.T) {
+	text := `DECLARE Total : INTEGER
+PROCEDURE Show()
+    DECLARE Total : STRING
+    OUTPUT Total
+ENDPROCEDURE
+Total <- 5`
+
+	if value := hoverValue(t, text, 5, 1); !strings.Contains(value, "DECLARE Total : INTEGER") {
+		t.Errorf("expected global declaration, got %q", value)
+	}
+	if value := hoverValue(t, text, 3, 12); !strings.Contains(value, "DECLARE Total : STRING") {
+		t.Errorf("expected nearest declaration, got %q", value)
+	}
+}
+
+func TestHoverParameter(t *testing.T) {
+	text := `FUNCTION Square(N : INTEGER) RETURNS INTEGER
+    RETURN N * N
+ENDFUNCTION`
+
+	if value := hoverValue(t, text, 1, 11); !strings.Contains(value, "N : INTEGER") {
+		t.Errorf("expected parameter type, got %q", value)
+	}
+}
+
+func TestHoverNonASCIIIdentifier(t *testing.T) {
+	text := `DECLARE Café : STRING
+OUTPUT "😀", Café`
+
+	// Character 17 is the end of Café on the second line once the emoji is
+	// counted as two UTF-16 code units
+	if value := hoverValue(t, text, 1, 17); !strings.Contains(value, "DECLARE Café : STRING") {
+		t.Errorf("expected declaration of Café, got %q", value)
+	}
+}
+
+func TestHoverUnknown(t *testing.T) {
+	if result := computeHover(parseDocument("OUTPUT Missing"), 0, 9); result != nil {
+		t.Errorf("expected no hover, got %v", result)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	text := `FUNCTION Factorial(N : INTEGER) RETURNS INTEGER
+    IF N <= 1 THEN
+        RETURN 1
+    ENDIF
+    RETURN N * Factorial(N - 1)
+ENDFUNCTION
+DECLARE Result : INTEGER
+Result <- Factorial(5)`
+
+	tests := []struct {
+		line, character int
+		expectedLine    int
+		expectedChar    int
+	}{
+		{7, 12, 0, 0},  // Factorial call
+		{7, 2, 6, 8},   // Result
+		{4, 11, 0, 19}, // N inside the function
+	}
+
+	for _, tt := range tests {
+		result := computeDefinition("file:///test.pseudo", parseDocument(text), tt.line, tt.character)
+		if result == nil {
+			t.Errorf("no definition found at %d:%d", tt.line, tt.character)
+			continue
+		}
+		start := result.(map[string]interface{})["range"].(map[string]interface{})["start"].(map[string]int)
+		if start["line"] != tt.expectedLine || start["character"] != tt.expectedChar {
+			t.Errorf("definition at %d:%d wrong. expected=%d:%d, got=%d:%d", tt.line, tt.character,
+				tt.expectedLine, tt.expectedChar, start["line"], start["character"])
+		}
+	}
+}
+
+func TestDefinitionOfBuiltin(t *testing.T) {
+	if result := computeDefinition("file:///test.pseudo", parseDocument(`OUTPUT LENGTH("a")`), 0, 9); result != nil {
+		t.Errorf("expected no definition for builtin, got %v", result)
+	}
+}
+
+func TestCompletionIncludesDocumentSymbols(t *testing.T) {
+	text := `CONSTANT Max = 10
+DECLARE Total : INTEGER
+PROCEDURE Show(Value : INTEGER)
+    DECLARE Total : INTEGER
+    OUTPUT Value
+ENDPROCEDURE`
+
+	kinds := make(map[string]int)
+	for _, item := range computeCompletion(parseDocument(text)) {
+		label := item["label"].(string)
+		if _, dup := kinds[label]; dup {
+			t.Errorf("duplicate completion item %s", label)
+		}
+		kinds[label] = item["kind"].(int)
+	}
+
+	expected := map[string]int{
+		"Max":    21,
+		"Total":  6,
+		"Show":   3,
+		"Value":  6,
+		"LENGTH": 3,
+		"WHILE":  14,
+	}
+	for label, kind := range expected {
+		if got, ok := kinds[label]; !ok {
+			t.Errorf("missing completion item %s", label)
+		} else if got != kind {
+			t.Errorf("completion item %s has kind %d, want %d", label, got, kind)
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	text := "DECLARE x : INTEGER\nFOR i <- 1 TO 3\nIF i > 1 THEN\nx <- x + i\nENDIF\nNEXT i\n"
+
+	edits := computeFormatting(parseDocument(text))
+	if len(edits) != 1 {
+		t.Fatalf("expected 1 edit, got %d", len(edits))
+	}
+
+	expected := "DECLARE x : INTEGER\nFOR i <- 1 TO 3\n  IF i > 1 THEN\n    x <- x + i\n  ENDIF\nNEXT i\n"
+	if got := edits[0]["newText"].(string); got != expected {
+		t.Errorf("wrong formatted text.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if again := computeFormatting(parseDocument(expected)); len(again) != 0 {
+		t.Errorf("expected formatted text to be stable, got %v", again)
+	}
+}
+
+func TestFormattingKeepsExpressionsAsWritten(t *testing.T) {
+	// Only parentheses that change the grouping are kept, and none are added
+	text := "DECLARE x : INTEGER\nx <- 1 + 2 * 3\nx <- (x + 1) * -2 ^ 2\nIF x > 1 AND NOT (x = 4) THEN\n  OUTPUT x MOD 3\nENDIF\n"
+
+	if edits := computeFormatting(parseDocument(text)); len(edits) != 0 {
+		t.Errorf("expected no edits for formatted text, got %v", edits)
+	}
+
+	edits := computeFormatting(parseDocument("x <- ((1 + 2)) * (3)\n"))
+	if len(edits) != 1 {
+		t.Fatalf("expected 1 edit, got %d", len(edits))
+	}
+	if got := edits[0]["newText"].(string); got != "x <- (1 + 2) * 3\n" {
+		t.Errorf("wrong formatted text %q", got)
+	}
+}
+
+func TestFormattingKeepsComments(t *testing.T) {
+	edits := computeFormatting(parseDocument("// keep me\nIF TRUE THEN\nOUTPUT 1+2 // and me\nENDIF\n"))
+	if len(edits) != 1 {
+		t.Fatalf("expected 1 edit, got %d", len(edits))
+	}
+	if got := edits[0]["newText"].(string); got != "// keep me\nIF TRUE THEN\n  OUTPUT 1 + 2 // and me\nENDIF\n" {
+		t.Errorf("wrong formatted text %q", got)
+	}
+}
+
+func TestFormattingLeavesRejectedDocuments(t *testing.T) {
+	tests := []string{
+		"IF x > THEN\nENDIF\n",
+		"OUTPUT /* inside */ 1 + 2\n",
+	}
+
+	for _, text := range tests {
+		if edits := computeFormatting(parseDocument(text)); len(edits) != 0 {
+			t.Errorf("expected no edits for %q, got %v", text, edits)
+		}
+	}
+}
+
+func TestBrokenDocumentUsesOneParse(t *testing.T) {
+	text := "DECLARE Total : INTEGER\nTotal <- \nPROCEDURE Show()\n  OUTPUT Total\nENDPROCEDURE\n"
+	doc := parseDocument(text)
+
+	if len(doc.Errors) == 0 {
+		t.Fatal("expected the document to have parse errors")
+	}
+
+	diagnostics := computeDiagnostics(doc)
+	if len(diagnostics) != len(doc.Errors) {
+		t.Fatalf("expected %d diagnostics, got %d", len(doc.Errors), len(diagnostics))
+	}
+	for i, d := range diagnostics {
+		if d["message"] != doc.Errors[i].Message {
+			t.Errorf("diagnostic %d %q does not match parse error %q", i, d["message"], doc.Errors[i].Message)
+		}
+		start := d["range"].(map[string]interface{})["start"].(map[string]int)
+		if start["line"] != doc.Errors[i].Line-1 || start["character"] != doc.Errors[i].Column-1 {
+			t.Errorf("diagnostic %d starts at %v, expected line %d, column %d", i, start, doc.Errors[i].Line, doc.Errors[i].Column)
+		}
+	}
+
+	// Symbols come from the same partial program the diagnostics describe
+	var names []string
+	for _, sym := range collectSymbols(doc.Program) {
+		names = append(names, sym.Name)
+	}
+	if strings.Join(names, ",") != "Total,Show" {
+		t.Errorf("expected symbols Total,Show, got %v", names)
+	}
+
+	labels := make(map[string]bool)
+	for _, item := range computeCompletion(doc) {
+		labels[item["label"].(string)] = true
+	}
+	for _, name := range names {
+		if !labels[name] {
+			t.Errorf("expected completion for symbol %s", name)
+		}
+	}
+
+	if got := len(computeSemanticTokens(doc)) / 5; got == 0 || got > len(doc.Tokens) {
+		t.Errorf("expected semantic tokens from the cached tokens, got %d of %d", got, len(doc.Tokens))
+	}
+}
+
+func TestDiagnosticRangeCoversToken(t *testing.T) {
+	doc := parseDocument("x <- 99999999999999999999\n")
+	diagnostics := computeDiagnostics(doc)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(diagnostics), diagnostics)
+	}
+
+	r := diagnostics[0]["range"].(map[string]interface{})
+	start := r["start"].(map[string]int)
+	end := r["end"].(map[string]int)
+	if start["character"] != 5 || end["character"] != 25 {
+		t.Errorf("expected the range to cover the literal at 5-25, got %v-%v", start, end)
+	}
+
+	// A token without text still gets a one-character range
+	empty := diagnosticRange(3, 7, 0)
+	if got := empty["end"].(map[string]int)["character"]; got != 7 {
+		t.Errorf("expected an empty span to end at character 7, got %d", got)
+	}
+}
+
+func TestSemanticTokensClassifyCallables(t *testing.T) {
+	text := `FUNCTION Double(n : INTEGER) RETURNS INTEGER
+    RETURN n * 2
+ENDFUNCTION
+PROCEDURE Show(n : INTEGER)
+    OUTPUT Double(n)
+ENDPROCEDURE
+DECLARE n : INTEGER
+CALL Show(LENGTH("ab"))
+OUTPUT n
+`
+	doc := parseDocument(text)
+	data := computeSemanticTokens(doc)
+
+	// Decode the delta encoding back into "line:column name" -> type
+	got := make(map[string]string)
+	line, col := 0, 0
+	for idx := 0; idx+4 < len(data); idx += 5 {
+		if data[idx] > 0 {
+			col = 0
+		}
+		line += data[idx]
+		col += data[idx+1]
+		for _, tok := range doc.Tokens {
+			if tok.Line-1 == line && tok.Column-1 == col && tok.Type == token.IDENT {
+				got[fmt.Sprintf("%d:%d %s", tok.Line, tok.Column, tok.Literal)] = tokenTypes[data[idx+3]]
+			}
+		}
+	}
+
+	expected := map[string]string{
+		"1:10 Double": "function",
+		"1:17 n":      "parameter",
+		"2:12 n":      "parameter",
+		"4:11 Show":   "function",
+		"5:12 Double": "function",
+		"5:19 n":      "parameter",
+		"7:9 n":       "variable",
+		"8:6 Show":    "function",
+		"8:11 LENGTH": "function",
+		"9:8 n":       "variable",
+	}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("expected %s to be %q, got %q", key, want, got[key])
+		}
+	}
+}
+
+func TestSemanticTokenLengthsInUTF16(t *testing.T) {
+	data := computeSemanticTokens(parseDocument(`OUTPUT "naïve😀"`))
+
+	// OUTPUT, then the string, whose 6 characters take 7 UTF-16 code units
+	if len(data) != 10 {
+		t.Fatalf("expected 2 tokens, got %v", data)
+	}
+	if data[7] != 7 {
+		t.Errorf("expected string length 7, got %d", data[7])
+	}
+}
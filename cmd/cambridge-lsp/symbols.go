@@ -0,0 +1,234 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+	"github.com/andrinoff/cambridge-lang/pkg/token"
+)
+
+// symbol is a name defined in a document
+type symbol struct {
+	Name   string
+	Kind   string // "variable", "constant", "parameter", "procedure" or "function"
+	Detail string // declaration shown on hover, e.g. "DECLARE x : INTEGER"
+	Token  token.Token
+	Scope  ast.Statement // enclosing top-level procedure, function or class; nil for globals
+}
+
+// collectSymbols returns every variable, constant, procedure and function
+// defined in the program, including those nested in blocks and classes
+func collectSymbols(program *ast.Program) []symbol {
+	var symbols []symbol
+	for _, top := range program.Statements {
+		var scope ast.Statement
+		switch top.(type) {
+		case *ast.ProcedureStatement, *ast.FunctionStatement, *ast.ClassStatement:
+			scope = top
+		}
+
+		walkStatements([]ast.Statement{top}, func(stmt ast.Statement) {
+			sym := symbol{Scope: scope}
+			switch s := stmt.(type) {
+			case *ast.DeclareStatement:
+				for _, name := range s.Names {
+					symbols = append(symbols, symbol{Name: name.Value, Kind: "variable", Detail: s.String(), Token: name.Token, Scope: scope})
+				}
+				return
+			case *ast.ConstantStatement:
+				sym.Name, sym.Kind, sym.Detail, sym.Token = s.Name.Value, "constant", s.String(), s.Name.Token
+			case *ast.ProcedureStatement:
+				sym.Name, sym.Kind, sym.Detail, sym.Token = s.Name, "procedure", firstLine(s.String()), s.Token
+				symbols = append(symbols, parameterSymbols(s.Parameters, scope)...)
+			case *ast.FunctionStatement:
+				sym.Name, sym.Kind, sym.Detail, sym.Token = s.Name, "function", firstLine(s.String()), s.Token
+				symbols = append(symbols, parameterSymbols(s.Parameters, scope)...)
+			default:
+				return
+			}
+			if stmt == scope {
+				sym.Scope = nil // the definition itself is visible globally
+			}
+			symbols = append(symbols, sym)
+		})
+	}
+	return symbols
+}
+
+func parameterSymbols(params []ast.Parameter, scope ast.Statement) []symbol {
+	var symbols []symbol
+	for _, param := range params {
+		symbols = append(symbols, symbol{
+			Name:   param.Name,
+			Kind:   "parameter",
+			Detail: param.Name + " : " + param.DataType.String(),
+			Token:  param.Token,
+			Scope:  scope,
+		})
+	}
+	return symbols
+}
+
+// lookupSymbol finds the definition of name visible from the given 1-based
+// line. Definitions in the enclosing procedure, function or class win over
+// globals, and among those the closest one above the line is preferred.
+func lookupSymbol(program *ast.Program, name string, line int) (symbol, bool) {
+	return resolveSymbol(program, collectSymbols(program), name, line)
+}
+
+// resolveSymbol is lookupSymbol over symbols already collected from program
+func resolveSymbol(program *ast.Program, symbols []symbol, name string, line int) (symbol, bool) {
+	scope := scopeAt(program, line)
+
+	if scope != nil {
+		if sym, ok := closestSymbol(symbols, name, line, scope); ok {
+			return sym, true
+		}
+	}
+	return closestSymbol(symbols, name, line, nil)
+}
+
+// scopeAt returns the top-level procedure, function or class containing the
+// given 1-based line, or nil if the line is at global level
+func scopeAt(program *ast.Program, line int) ast.Statement {
+	var current ast.Statement
+	for _, stmt := range program.Statements {
+		if stmt == nil || reflect.ValueOf(stmt).IsNil() {
+			continue
+		}
+		if ast.StatementToken(stmt).Line > line {
+			break
+		}
+		current = stmt
+	}
+
+	switch current.(type) {
+	case *ast.ProcedureStatement, *ast.FunctionStatement, *ast.ClassStatement:
+		return current
+	}
+	return nil
+}
+
+// closestSymbol returns the definition of name in the given scope closest
+// above line, falling back to the first one below it
+func closestSymbol(symbols []symbol, name string, line int, scope ast.Statement) (symbol, bool) {
+	var best symbol
+	found := false
+	for _, sym := range symbols {
+		if sym.Name != name || sym.Scope != scope {
+			continue
+		}
+		switch {
+		case !found:
+			best, found = sym, true
+		case sym.Token.Line <= line && (best.Token.Line > line || sym.Token.Line > best.Token.Line):
+			best = sym
+		}
+	}
+	return best, found
+}
+
+// walkStatements calls fn for every statement, descending into nested blocks,
+// procedure and function bodies and class members
+func walkStatements(stmts []ast.Statement, fn func(ast.Statement)) {
+	for _, stmt := range stmts {
+		// Statements that failed to parse are typed nil pointers
+		if stmt == nil || reflect.ValueOf(stmt).IsNil() {
+			continue
+		}
+		fn(stmt)
+
+		switch s := stmt.(type) {
+		case *ast.IfStatement:
+			walkStatements(s.Consequence, fn)
+			walkStatements(s.Alternative, fn)
+		case *ast.CaseStatement:
+			for _, c := range s.Cases {
+				walkStatements(c.Body, fn)
+			}
+			walkStatements(s.Otherwise, fn)
+		case *ast.ForStatement:
+			walkStatements(s.Body, fn)
+		case *ast.WhileStatement:
+			walkStatements(s.Body, fn)
+		case *ast.RepeatStatement:
+			walkStatements(s.Body, fn)
+		case *ast.ProcedureStatement:
+			walkStatements(s.Body, fn)
+		case *ast.FunctionStatement:
+			walkStatements(s.Body, fn)
+		case *ast.ClassStatement:
+			walkStatements(s.Members, fn)
+		}
+	}
+}
+
+// wordAt returns the identifier under the given 0-based line and character.
+// LSP counts characters in UTF-16 code units, so the line is decoded into
+// runes and the position converted before looking around it.
+func wordAt(text string, line, character int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+
+	runes := []rune(lines[line])
+	pos := runeIndex(runes, character)
+	if pos < 0 {
+		return ""
+	}
+
+	start := pos
+	for start > 0 && isIdentChar(runes[start-1]) {
+		start--
+	}
+	end := pos
+	for end < len(runes) && isIdentChar(runes[end]) {
+		end++
+	}
+	return string(runes[start:end])
+}
+
+// runeIndex returns the index in runes of the character at an offset in
+// UTF-16 code units, or -1 if the offset is past the end
+func runeIndex(runes []rune, units int) int {
+	if units < 0 {
+		return -1
+	}
+	for idx, r := range runes {
+		if units <= 0 {
+			return idx
+		}
+		units -= utf16.RuneLen(r)
+	}
+	if units > 0 {
+		return -1
+	}
+	return len(runes)
+}
+
+// utf16Len returns the length of s in UTF-16 code units, the unit LSP
+// measures positions in
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// isIdentChar reports whether r can appear in an identifier, matching the
+// lexer, which accepts any Unicode letter
+func isIdentChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstLine(s string) string {
+	if idx := strings.Index(s, "\n"); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
@@ -4,19 +4,35 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/andrinoff/cambridge-lang/pkg/analyzer"
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
 	"github.com/andrinoff/cambridge-lang/pkg/builtins"
+	"github.com/andrinoff/cambridge-lang/pkg/formatter"
 	"github.com/andrinoff/cambridge-lang/pkg/interpreter"
 	"github.com/andrinoff/cambridge-lang/pkg/lexer"
 	"github.com/andrinoff/cambridge-lang/pkg/parser"
+	"github.com/andrinoff/cambridge-lang/pkg/token"
 )
 
 const VERSION = "0.2.0"
 
+// Usage lines for the subcommands that take flags
+const (
+	runUsage   = "Usage: cambridge run [--json] [--trace] [--time] [--watch] [--char-arithmetic] [--strict-scoping] <filename> [arguments]"
+	checkUsage = "Usage: cambridge check [--json] <filename>..."
+	fmtUsage   = "Usage: cambridge fmt [--stdout] <filename>..."
+)
+
 func main() {
 	if len(os.Args) < 2 {
 		// Start REPL
@@ -26,11 +42,61 @@ func main() {
 
 	switch os.Args[1] {
 	case "run":
+		flags, args, err := splitRunArgs(os.Args[2:])
+		if err != nil || len(args) < 1 {
+			usageError(err, runUsage)
+		}
+		opts := runOptions{
+			json:           flags["json"],
+			trace:          flags["trace"],
+			time:           flags["time"],
+			charArithmetic: flags["char-arithmetic"],
+			strictScoping:  flags["strict-scoping"],
+			args:           args[1:],
+		}
+		if flags["watch"] {
+			watchFile(args[0], opts)
+			return
+		}
+		runFile(args[0], opts)
+	case "check":
+		flags, args, err := splitFlags(os.Args[2:], "json")
+		if err != nil || len(args) < 1 {
+			usageError(err, checkUsage)
+		}
+		if flags["json"] {
+			if !reportFiles(args, os.Stdout) {
+				os.Exit(1)
+			}
+			return
+		}
+		if !checkFiles(args, os.Stdout, os.Stderr) {
+			os.Exit(1)
+		}
+	case "fmt":
+		flags, args, err := splitFlags(os.Args[2:], "stdout")
+		if err != nil || len(args) < 1 {
+			usageError(err, fmtUsage)
+		}
+		ok := true
+		for _, filename := range args {
+			if err := formatFile(filename, flags["stdout"], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+				ok = false
+			}
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	case "ast":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: cambridge run <filename>")
+			fmt.Println("Usage: cambridge ast <filename>")
+			os.Exit(1)
+		}
+		if err := dumpAST(os.Args[2], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[2], err)
 			os.Exit(1)
 		}
-		runFile(os.Args[2])
 	case "repl":
 		startREPL()
 	case "version":
@@ -40,15 +106,55 @@ func main() {
 		printHelp()
 	default:
 		// Assume it's a filename
-		runFile(os.Args[1])
+		runFile(os.Args[1], runOptions{args: os.Args[2:]})
+	}
+}
+
+// maxLoopIterations stops runaway WHILE and REPEAT loops instead of hanging
+const maxLoopIterations = 10000000
+
+// newInterpreter returns an interpreter with the builtins and the safety
+// limits used by every command
+func newInterpreter() *interpreter.Interpreter {
+	interp := interpreter.New()
+	interp.SetBuiltins(builtins.GetBuiltins())
+	interp.SetMaxIterations(maxLoopIterations)
+	return interp
+}
+
+// runOptions holds the flags accepted by the run command
+type runOptions struct {
+	json  bool     // print a JSON report instead of the program's output
+	trace bool     // print each statement before it executes
+	time  bool     // print the running time and statement counts afterwards
+	args  []string // program arguments for ARG and ARGCOUNT
+
+	charArithmetic bool // allow + and - on CHAR values
+	strictScoping  bool // assignments in a procedure never change outer variables
+}
+
+func runFile(filename string, opts runOptions) {
+	if !runProgram(filename, opts) {
+		os.Exit(1)
 	}
 }
 
-func runFile(filename string) {
+// runProgram runs a file, printing any errors to stderr, and reports whether
+// it ran without errors
+func runProgram(filename string, opts runOptions) bool {
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		os.Exit(1)
+		return false
+	}
+
+	if opts.json {
+		r := buildReport(string(content), os.Stdin, &opts)
+		r.File = filename
+
+		data, _ := json.Marshal(r)
+		fmt.Println(string(data))
+		return r.OK
 	}
 
 	l := lexer.New(string(content))
@@ -59,19 +165,286 @@ func runFile(filename string) {
 		for _, err := range p.Errors() {
 			fmt.Fprintf(os.Stderr, "Parse error: %s\n", err)
 		}
-		os.Exit(1)
+		return false
 	}
 
-	interp := interpreter.New()
-	interp.SetBuiltins(builtins.GetBuiltins())
-
-	result := interp.Eval(program)
+	interp := configureInterpreter(opts)
+	result := evalTimed(interp, program, opts)
+	for _, w := range interp.Warnings() {
+		fmt.Fprintf(os.Stderr, "%s\n", w)
+	}
 	if result != nil {
 		if err, ok := result.(*interpreter.Error); ok {
 			fmt.Fprintf(os.Stderr, "%s\n", err.Inspect())
-			os.Exit(1)
+			return false
 		}
 	}
+	return true
+}
+
+// configureInterpreter returns an interpreter set up for the run options
+func configureInterpreter(opts runOptions) *interpreter.Interpreter {
+	interp := newInterpreter()
+	interp.SetArgs(opts.args)
+	if opts.trace {
+		interp.SetTrace(newTracer(os.Stderr))
+	}
+	interp.SetCountStatements(opts.time)
+	interp.SetCharArithmetic(opts.charArithmetic)
+	interp.SetStrictScoping(opts.strictScoping)
+	return interp
+}
+
+// evalTimed runs the program, printing the timing to stderr afterwards if
+// opts.time is set
+func evalTimed(interp *interpreter.Interpreter, program *ast.Program, opts runOptions) interpreter.Object {
+	start := time.Now()
+	result := interp.Eval(program)
+	if opts.time {
+		printTiming(os.Stderr, time.Since(start), interp.StatementCounts())
+	}
+	return result
+}
+
+// printTiming reports how long a program ran and how many statements of each
+// kind it executed, most frequent first, e.g.
+//
+//	Ran in 1.52ms
+//	  AssignmentStatement  1000
+//	  ForStatement           10
+func printTiming(w io.Writer, elapsed time.Duration, counts map[string]int) {
+	fmt.Fprintf(w, "Ran in %s\n", elapsed)
+
+	kinds := make([]string, 0, len(counts))
+	width := 0
+	for kind := range counts {
+		kinds = append(kinds, kind)
+		if len(kind) > width {
+			width = len(kind)
+		}
+	}
+	sort.Slice(kinds, func(a, b int) bool {
+		if counts[kinds[a]] != counts[kinds[b]] {
+			return counts[kinds[a]] > counts[kinds[b]]
+		}
+		return kinds[a] < kinds[b]
+	})
+
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "  %-*s  %d\n", width, kind, counts[kind])
+	}
+}
+
+// checkFiles lexes, parses and analyzes each file without running it. It
+// prints every parse error and warning, prints OK for each file that parsed,
+// and reports whether all files parsed. Messages are prefixed with the file
+// name when more than one file is checked.
+func checkFiles(filenames []string, out, errOut io.Writer) bool {
+	allOK := true
+
+	for _, filename := range filenames {
+		prefix := ""
+		if len(filenames) > 1 {
+			prefix = filename + ": "
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(errOut, "%sError reading file: %v\n", prefix, err)
+			allOK = false
+			continue
+		}
+
+		l := lexer.New(string(content))
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		if len(p.Errors()) > 0 {
+			for _, err := range p.Errors() {
+				fmt.Fprintf(errOut, "%sParse error: %s\n", prefix, err)
+			}
+			allOK = false
+			continue
+		}
+
+		for _, w := range analyzer.Analyze(program) {
+			fmt.Fprintf(errOut, "%sWarning: %s\n", prefix, w)
+		}
+		fmt.Fprintf(out, "%sOK\n", prefix)
+	}
+
+	return allOK
+}
+
+// formatFile reformats a file in place, or prints the result to out when
+// toStdout is set. Files that fail to parse are left untouched.
+func formatFile(filename string, toStdout bool, out io.Writer) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := formatter.Format(string(content))
+	if err != nil {
+		return err
+	}
+
+	if toStdout {
+		_, err = io.WriteString(out, formatted)
+		return err
+	}
+	if formatted == string(content) {
+		return nil
+	}
+	return os.WriteFile(filename, []byte(formatted), 0644)
+}
+
+// dumpAST prints the syntax tree of a file as indented JSON. Every node has
+// a "type" naming its kind and the "line" and "column" where it starts.
+func dumpAST(filename string, out io.Writer) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(string(content)))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return fmt.Errorf("parse error: %s", p.Errors()[0])
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(ast.Tree(program))
+}
+
+// runFlags are the flags accepted by the run command
+var runFlags = []string{"json", "trace", "time", "watch", "char-arithmetic", "strict-scoping"}
+
+// usageError prints err, if any, and the usage line, then exits 1
+func usageError(err error, usage string) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	fmt.Println(usage)
+	os.Exit(1)
+}
+
+// splitFlags separates --name flags from positional arguments. Any flag
+// not in allowed is an error.
+func splitFlags(args []string, allowed ...string) (map[string]bool, []string, error) {
+	flags := make(map[string]bool)
+	var rest []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			name := strings.TrimPrefix(arg, "--")
+			if !slices.Contains(allowed, name) {
+				return nil, nil, fmt.Errorf("unknown flag %s", arg)
+			}
+			flags[name] = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	return flags, rest, nil
+}
+
+// splitRunArgs separates the flags of the run command from the filename and
+// the arguments passed to the program. Flags must come before the filename so
+// that program arguments starting with -- are passed through unchanged.
+func splitRunArgs(args []string) (map[string]bool, []string, error) {
+	for idx, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			flags, _, err := splitFlags(args[:idx], runFlags...)
+			if err != nil {
+				return nil, nil, err
+			}
+			return flags, args[idx:], nil
+		}
+	}
+	flags, _, err := splitFlags(args, runFlags...)
+	return flags, nil, err
+}
+
+// reportError is a single error or warning in a --json report
+type reportError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// report is the machine-readable result printed by --json
+type report struct {
+	File     string        `json:"file,omitempty"`
+	OK       bool          `json:"ok"`
+	Errors   []reportError `json:"errors"`
+	Warnings []reportError `json:"warnings"` // from the analyzer for check, raised while running for run
+	Output   string        `json:"output"`
+}
+
+// reportFiles prints a JSON report for each file to out, one per line,
+// without running them, and reports whether every file was free of errors
+func reportFiles(filenames []string, out io.Writer) bool {
+	allOK := true
+
+	for _, filename := range filenames {
+		var r report
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			r = report{Errors: []reportError{{Message: fmt.Sprintf("error reading file: %v", err)}}, Warnings: []reportError{}}
+		} else {
+			r = buildReport(string(content), nil, nil)
+		}
+		r.File = filename
+
+		data, _ := json.Marshal(r)
+		fmt.Fprintln(out, string(data))
+		allOK = allOK && r.OK
+	}
+
+	return allOK
+}
+
+// buildReport parses the source and, unless opts is nil, runs it with the
+// given input and options, capturing output, errors and warnings. Like the
+// text output, check reports the analyzer's warnings and run reports the
+// interpreter's.
+func buildReport(source string, input io.Reader, opts *runOptions) report {
+	r := report{Errors: []reportError{}, Warnings: []reportError{}}
+
+	l := lexer.New(source)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	for _, err := range p.StructuredErrors() {
+		r.Errors = append(r.Errors, reportError{Line: err.Line, Column: err.Column, Message: err.Message})
+	}
+
+	if len(r.Errors) == 0 && opts == nil {
+		for _, w := range analyzer.Analyze(program) {
+			r.Warnings = append(r.Warnings, reportError{Line: w.Line, Column: w.Column, Message: w.Message})
+		}
+	}
+
+	if len(r.Errors) == 0 && opts != nil {
+		var out bytes.Buffer
+		interp := configureInterpreter(*opts)
+		interp.SetInput(input)
+		interp.SetOutput(&out)
+
+		result := evalTimed(interp, program, *opts)
+		if err, ok := result.(*interpreter.Error); ok {
+			r.Errors = append(r.Errors, reportError{Line: err.Line, Column: err.Column, Message: err.Message})
+		}
+		for _, w := range interp.Warnings() {
+			r.Warnings = append(r.Warnings, reportError{Line: w.Line, Column: w.Column, Message: w.Message})
+		}
+		r.Output = out.String()
+	}
+
+	r.OK = len(r.Errors) == 0
+	return r
 }
 
 func startREPL() {
@@ -80,14 +453,14 @@ func startREPL() {
 	fmt.Printf("Type 'EXIT' to quit, 'HELP' for help\n")
 
 	reader := bufio.NewReader(os.Stdin)
-	interp := interpreter.New()
-	interp.SetBuiltins(builtins.GetBuiltins())
+	interp := newInterpreter()
 
 	var multilineBuffer strings.Builder
-	inMultiline := false
+	depth := 0         // number of blocks entered but not yet closed
+	continued := false // whether the last line ended with _
 
 	for {
-		if inMultiline {
+		if depth > 0 || continued {
 			fmt.Print("... ")
 		} else {
 			fmt.Print(">>> ")
@@ -116,33 +489,46 @@ func startREPL() {
 			continue
 		}
 
+		if upperLine == "FILES" {
+			files := interp.OpenFiles()
+			if len(files) == 0 {
+				fmt.Println("No open files.")
+			}
+			for _, f := range files {
+				eof := ""
+				if f.EOF {
+					eof = " (EOF)"
+				}
+				fmt.Printf("  %s  %s%s\n", f.Name, f.Mode, eof)
+			}
+			continue
+		}
+
+		if upperLine == "VARS" || upperLine == "SYMBOLS" {
+			printGlobals(os.Stdout, interp.Globals())
+			continue
+		}
+
 		if upperLine == "CLEAR" {
-			interp = interpreter.New()
-			interp.SetBuiltins(builtins.GetBuiltins())
+			interp = newInterpreter()
 			fmt.Println("Environment cleared.")
 			continue
 		}
 
-		// Check if this starts a multiline construct
-		if startsMultiline(upperLine) {
-			inMultiline = true
+		// Accumulate lines until every block that was opened is closed and
+		// the statement is not continued with a trailing _
+		depth += blockDepthChange(line)
+		continued = endsWithContinuation(line)
+		if depth > 0 || continued {
 			multilineBuffer.WriteString(line)
 			multilineBuffer.WriteString("\n")
 			continue
 		}
-
-		// If in multiline mode, accumulate lines
-		if inMultiline {
+		depth = 0
+		if multilineBuffer.Len() > 0 {
 			multilineBuffer.WriteString(line)
-			multilineBuffer.WriteString("\n")
-
-			if endsMultiline(upperLine) {
-				inMultiline = false
-				line = multilineBuffer.String()
-				multilineBuffer.Reset()
-			} else {
-				continue
-			}
+			line = multilineBuffer.String()
+			multilineBuffer.Reset()
 		}
 
 		if strings.TrimSpace(line) == "" {
@@ -169,27 +555,94 @@ func startREPL() {
 	}
 }
 
-func startsMultiline(line string) bool {
-	keywords := []string{
-		"IF", "WHILE", "FOR", "REPEAT", "CASE",
-		"PROCEDURE", "FUNCTION", "CLASS", "TYPE",
+// printGlobals lists top-level definitions for the REPL VARS command, e.g.
+//
+//	CONSTANT Pi : REAL = 3.14
+//	Name : STRING = "Ada"
+//	Greet : PROCEDURE
+func printGlobals(w io.Writer, bindings []interpreter.Binding) {
+	if len(bindings) == 0 {
+		fmt.Fprintln(w, "Nothing defined.")
+		return
 	}
-	for _, kw := range keywords {
-		if strings.HasPrefix(line, kw+" ") || line == kw {
-			return true
+
+	for _, b := range bindings {
+		switch v := b.Value.(type) {
+		case *interpreter.Procedure, *interpreter.Function, *interpreter.Class:
+			fmt.Fprintf(w, "  %s : %s\n", b.Name, v.Type())
+		case *interpreter.Record:
+			fmt.Fprintf(w, "  %s : %s = %s\n", b.Name, v.TypeName, v.Inspect())
+		case *interpreter.Instance:
+			fmt.Fprintf(w, "  %s : %s = %s\n", b.Name, v.Class.Name, v.Inspect())
+		default:
+			prefix := ""
+			if b.Constant {
+				prefix = "CONSTANT "
+			}
+			value := v.Inspect()
+			switch v.(type) {
+			case *interpreter.String:
+				value = `"` + value + `"`
+			case *interpreter.Char:
+				value = "'" + value + "'"
+			}
+			fmt.Fprintf(w, "  %s%s : %s = %s\n", prefix, b.Name, v.Type(), value)
 		}
 	}
-	return false
 }
 
-func endsMultiline(line string) bool {
-	endings := []string{
-		"ENDIF", "ENDWHILE", "ENDFOR", "UNTIL",
-		"ENDCASE", "ENDPROCEDURE", "ENDFUNCTION",
-		"ENDCLASS", "ENDTYPE",
+// blockDepthChange returns the number of blocks a line of REPL input opens
+// minus the number it closes, so that nested blocks are read in full before
+// being run. ELSE IF continues the enclosing IF, the FOR of OPENFILE is not a
+// loop, and TYPE only opens a block for records, not for TYPE Name = definitions.
+func blockDepthChange(line string) int {
+	l := lexer.New(line)
+	var tokens []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	change := 0
+	for idx, tok := range tokens {
+		switch tok.Type {
+		case token.IF:
+			if idx == 0 || tokens[idx-1].Type != token.ELSE {
+				change++
+			}
+		case token.FOR:
+			if !containsToken(tokens[:idx], token.OPENFILE) {
+				change++
+			}
+		case token.WHILE, token.REPEAT, token.CASE,
+			token.PROCEDURE, token.FUNCTION, token.CLASS:
+			change++
+		case token.TYPE:
+			if !containsToken(tokens[idx:], token.EQ) {
+				change++
+			}
+		case token.ENDIF, token.ENDWHILE, token.NEXT, token.UNTIL, token.ENDCASE,
+			token.ENDPROCEDURE, token.ENDFUNCTION, token.ENDCLASS, token.ENDTYPE:
+			change--
+		}
+	}
+	return change
+}
+
+// endsWithContinuation reports whether a line of REPL input ends with the _
+// that continues a statement on the next line. On its own line the _ is not
+// followed by a newline, so the lexer returns it as an ILLEGAL token.
+func endsWithContinuation(line string) bool {
+	l := lexer.New(line)
+	var last token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		last = tok
 	}
-	for _, end := range endings {
-		if strings.HasPrefix(line, end) {
+	return last.Type == token.ILLEGAL && last.Literal == "_"
+}
+
+func containsToken(tokens []token.Token, typ token.Type) bool {
+	for _, tok := range tokens {
+		if tok.Type == typ {
 			return true
 		}
 	}
@@ -203,18 +656,43 @@ Usage:
   cambridge [command] [arguments]
 
 Commands:
-  run <file>    Run a pseudocode file
+  run <file>    Run a pseudocode file; later arguments are read with ARG(n)
+  check <files> Check files for errors and warnings without running them
+  fmt <files>   Rewrite files in canonical format, keeping comments
+  ast <file>    Print the syntax tree of a file as JSON
   repl          Start interactive REPL
   version       Show version information
   help          Show this help message
 
+Flags (before the filename for run):
+  --json        Print a JSON summary of errors and output (run), or one
+                summary per line for each file (check)
+  --trace       Print each statement and the variables it uses before it runs
+  --time        Print the running time and how many statements of each kind ran (run)
+  --watch       Re-run the file every time it is saved (run)
+  --char-arithmetic
+                Allow 'A' + 1 and 'D' - 'A' on CHAR values (run)
+  --strict-scoping
+                Make assignments in procedures and functions create locals
+                instead of changing outer variables (run)
+  --stdout      Print formatted code instead of rewriting the file (fmt)
+
 Examples:
   cambridge run program.pseudo
+  cambridge check program.pseudo
+  cambridge run --json program.pseudo
+  cambridge run --trace program.pseudo
+  cambridge run --watch program.pseudo
+  cambridge run program.pseudo input.txt 10
+  cambridge fmt --stdout program.pseudo
   cambridge repl
 
 File Extensions:
   .pseudo, .cambridge, .cam, .txt
 
+Built-in Functions:`)
+	fmt.Print(builtinHelp(builtins.GetBuiltins()))
+	fmt.Println(`
 For more information, visit:
   https://github.com/andrinoff/cambridge-lang`)
 }
@@ -225,6 +703,8 @@ REPL Commands:
   EXIT, QUIT    Exit the REPL
   HELP          Show this help
   CLEAR         Clear the environment
+  FILES         List open files and their modes
+  VARS, SYMBOLS List defined variables, constants, procedures, functions and classes
 
 Syntax Reference:
   Variables:    DECLARE x : INTEGER
@@ -243,16 +723,42 @@ Syntax Reference:
 
   I/O:          INPUT x
                 OUTPUT "Hello", x
+                EPRINT "Warning: ", x   (to standard error)
 
-  Files:        OPENFILE "file.txt" FOR READ/WRITE/APPEND
+  Files:        OPENFILE "file.txt" FOR READ/WRITE/APPEND/RANDOM
                 READFILE "file.txt", variable
-                WRITEFILE "file.txt", data
+                WRITEFILE "file.txt", data, ... (trailing comma: no newline)
+                SEEK "file.dat", recordNumber
+                GETRECORD "file.dat", variable
+                PUTRECORD "file.dat", variable
                 CLOSEFILE "file.txt"
 
 Built-in Functions:
-  String:       LENGTH, LEFT, RIGHT, MID, LCASE, UCASE
-  Numeric:      INT, RAND, RANDOM, ROUND, ABS, SQRT, POW
-  Conversion:   ASC, CHR, NUM_TO_STR, STR_TO_NUM
-  File:         EOF
 `)
+	fmt.Print(builtinHelp(builtins.GetBuiltins()))
+	fmt.Println()
+}
+
+// builtinHelp lists the registered builtins in alphabetical order by
+// signature, each followed by an indented description when it has one
+func builtinHelp(fns map[string]*interpreter.Builtin) string {
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		fn := fns[name]
+		usage := fn.Signature
+		if usage == "" {
+			usage = name
+		}
+		fmt.Fprintf(&out, "  %s\n", usage)
+		if fn.Description != "" {
+			fmt.Fprintf(&out, "      %s\n", fn.Description)
+		}
+	}
+	return out.String()
 }
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrinoff/cambridge-lang/pkg/builtins"
+	"github.com/andrinoff/cambridge-lang/pkg/interpreter"
+	"github.com/andrinoff/cambridge-lang/pkg/lexer"
+	"github.com/andrinoff/cambridge-lang/pkg/parser"
+)
+
+func TestBuildReportParseError(t *testing.T) {
+	source := `DECLARE x : INTEGER
+x <- `
+
+	data, err := json.Marshal(buildReport(source, strings.NewReader(""), &runOptions{}))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var got struct {
+		OK     bool `json:"ok"`
+		Errors []struct {
+			Line    int    `json:"line"`
+			Column  int    `json:"column"`
+			Message string `json:"message"`
+		} `json:"errors"`
+		Output *string `json:"output"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+
+	if got.OK {
+		t.Error("expected ok to be false")
+	}
+	if len(got.Errors) == 0 {
+		t.Fatalf("expected errors, got %s", data)
+	}
+	if got.Errors[0].Line != 2 {
+		t.Errorf("expected error on line 2, got %d", got.Errors[0].Line)
+	}
+	if got.Errors[0].Message == "" || strings.HasPrefix(got.Errors[0].Message, "line") {
+		t.Errorf("expected bare message, got %q", got.Errors[0].Message)
+	}
+	if got.Output == nil {
+		t.Error("expected output field to be present")
+	}
+}
+
+func TestBuildReportSuccess(t *testing.T) {
+	r := buildReport(`OUTPUT "Hello"`, strings.NewReader(""), &runOptions{})
+
+	if !r.OK {
+		t.Fatalf("expected ok, got errors %v", r.Errors)
+	}
+	if r.Output != "Hello\n" {
+		t.Errorf("expected output %q, got %q", "Hello\n", r.Output)
+	}
+	if r.Errors == nil {
+		t.Error("expected errors to be an empty list, not null")
+	}
+}
+
+func TestBuildReportRuntimeError(t *testing.T) {
+	r := buildReport(`OUTPUT 1 DIV 0`, strings.NewReader(""), &runOptions{})
+
+	if r.OK || len(r.Errors) != 1 {
+		t.Fatalf("expected one runtime error, got %+v", r)
+	}
+	if r.Errors[0].Line != 1 || r.Errors[0].Column != 1 {
+		t.Errorf("expected the error at line 1, column 1, got %+v", r.Errors[0])
+	}
+}
+
+func TestBuildReportWarnings(t *testing.T) {
+	source := `CONSTANT Rate = 2
+PROCEDURE Show(Rate : INTEGER)
+    OUTPUT Rate
+ENDPROCEDURE`
+
+	r := buildReport(source, nil, nil)
+	if !r.OK || len(r.Warnings) != 1 {
+		t.Fatalf("expected one warning from check, got %+v", r)
+	}
+	if w := r.Warnings[0]; w.Line != 2 || w.Column != 16 || !strings.Contains(w.Message, "shadows global constant Rate") {
+		t.Errorf("unexpected warning %+v", w)
+	}
+
+	data, err := json.Marshal(buildReport(`OUTPUT "Hello"`, strings.NewReader(""), &runOptions{}))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"warnings":[]`) {
+		t.Errorf("expected an empty warnings list, got %s", data)
+	}
+}
+
+func TestBuildReportCheckDoesNotRun(t *testing.T) {
+	r := buildReport(`OUTPUT "Hello"`, strings.NewReader(""), nil)
+
+	if !r.OK || r.Output != "" {
+		t.Errorf("expected ok with no output, got %+v", r)
+	}
+}
+
+func TestBuildReportUsesRunOptions(t *testing.T) {
+	opts := &runOptions{args: []string{"first", "second"}, charArithmetic: true}
+	r := buildReport(`OUTPUT ARGCOUNT(), " ", ARG(1), " ", 'A' + 1`, strings.NewReader(""), opts)
+
+	if !r.OK || r.Output != "2 first B\n" {
+		t.Errorf("expected the arguments and char arithmetic to apply, got %+v", r)
+	}
+}
+
+func TestBuiltinHelpListsRegisteredBuiltins(t *testing.T) {
+	fns := builtins.GetBuiltins()
+	listed := make(map[string]bool)
+
+	for _, line := range strings.Split(strings.TrimRight(builtinHelp(fns), "\n"), "\n") {
+		if strings.HasPrefix(line, "      ") {
+			continue // description
+		}
+		name := strings.Fields(line)[0]
+		if idx := strings.Index(name, "("); idx >= 0 {
+			name = name[:idx]
+		}
+		if listed[name] {
+			t.Errorf("builtin %s listed twice", name)
+		}
+		listed[name] = true
+	}
+
+	for name := range fns {
+		if !listed[name] {
+			t.Errorf("builtin %s missing from help", name)
+		}
+	}
+	for name := range listed {
+		if _, ok := fns[name]; !ok {
+			t.Errorf("help lists unknown builtin %s", name)
+		}
+	}
+}
+
+func TestTracerPrintsStatementsAndValues(t *testing.T) {
+	source := `DECLARE Total : INTEGER
+Total <- 4
+IF Total > 3 THEN
+    Total <- Total * 2
+ENDIF`
+
+	var trace bytes.Buffer
+	interp := interpreter.New()
+	interp.SetOutput(&bytes.Buffer{})
+	interp.SetTrace(newTracer(&trace))
+	interp.Eval(parser.New(lexer.New(source)).ParseProgram())
+
+	expected := `line 1: DECLARE Total : INTEGER
+line 2: Total <- 4    [Total = 0]
+line 3: IF Total > 3 THEN    [Total = 4]
+line 4: Total <- Total * 2    [Total = 4]
+`
+	if trace.String() != expected {
+		t.Errorf("unexpected trace.\nexpected:\n%s\ngot:\n%s", expected, trace.String())
+	}
+}
+
+func TestPrintGlobals(t *testing.T) {
+	source := `CONSTANT Pi = 3.14
+DECLARE Name : STRING
+Name <- "Ada"
+PROCEDURE Greet()
+    OUTPUT "Hi"
+ENDPROCEDURE`
+
+	interp := newInterpreter()
+	interp.SetOutput(&bytes.Buffer{})
+	interp.Eval(parser.New(lexer.New(source)).ParseProgram())
+
+	var out bytes.Buffer
+	printGlobals(&out, interp.Globals())
+
+	expected := `  Greet : PROCEDURE
+  Name : STRING = "Ada"
+  CONSTANT Pi : REAL = 3.14
+`
+	if out.String() != expected {
+		t.Errorf("unexpected listing.\nexpected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	printGlobals(&out, newInterpreter().Globals())
+	if out.String() != "Nothing defined.\n" {
+		t.Errorf("expected empty listing, got %q", out.String())
+	}
+}
+
+func TestPrintTiming(t *testing.T) {
+	counts := map[string]int{"OutputStatement": 1, "AssignmentStatement": 10, "ForStatement": 1}
+
+	var out bytes.Buffer
+	printTiming(&out, 1500*time.Microsecond, counts)
+
+	expected := `Ran in 1.5ms
+  AssignmentStatement  10
+  ForStatement         1
+  OutputStatement      1
+`
+	if out.String() != expected {
+		t.Errorf("unexpected timing report.\nexpected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestEndsWithContinuation(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{`OUTPUT "Total: ", _`, true},
+		{`Total <- Total + _  `, true},
+		{`Total_ <- 1`, false},
+		{`Name <- "a _"`, false},
+		{`OUTPUT Total`, false},
+	}
+
+	for _, tt := range tests {
+		if got := endsWithContinuation(tt.line); got != tt.expected {
+			t.Errorf("endsWithContinuation(%q) = %v, want %v", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestBlockDepthChange(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected int
+	}{
+		{"FOR i <- 1 TO 10", 1},
+		{"  IF x > 1 THEN", 1},
+		{"ELSE IF x > 2 THEN", 0},
+		{"ELSE", 0},
+		{"ENDIF", -1},
+		{"NEXT i", -1},
+		{"REPEAT", 1},
+		{"UNTIL x > 5", -1},
+		{"CASE OF x", 1},
+		{"ENDCASE", -1},
+		{"PUBLIC PROCEDURE Speak()", 1},
+		{"FUNCTION Sq(n : INTEGER) RETURNS INTEGER", 1},
+		{"TYPE Point", 1},
+		{"TYPE Letters = SET OF CHAR", 0},
+		{"OPENFILE \"data.txt\" FOR READ", 0},
+		{"OUTPUT \"IF in a string\"", 0},
+		{"x <- 5 // FOR in a comment", 0},
+	}
+
+	for _, tt := range tests {
+		if got := blockDepthChange(tt.line); got != tt.expected {
+			t.Errorf("blockDepthChange(%q) = %d, want %d", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestSplitRunArgs(t *testing.T) {
+	flags, args, err := splitRunArgs([]string{"--trace", "prog.pseudo", "a", "--json"})
+	if err != nil || !flags["trace"] || flags["json"] {
+		t.Errorf("expected only --trace as a flag, got %v", flags)
+	}
+	expected := []string{"prog.pseudo", "a", "--json"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected args %v, got %v", expected, args)
+	}
+
+	flags, args, err = splitRunArgs([]string{"--json"})
+	if err != nil || !flags["json"] || len(args) != 0 {
+		t.Errorf("expected --json and no args, got %v %v", flags, args)
+	}
+
+	if _, _, err = splitRunArgs([]string{"--stdout", "prog.pseudo"}); err == nil {
+		t.Error("expected an error for a flag run does not accept")
+	}
+}
+
+func TestSplitFlagsRejectsUnknownFlags(t *testing.T) {
+	flags, args, err := splitFlags([]string{"a.pseudo", "--stdout", "b.pseudo"}, "stdout")
+	if err != nil || !flags["stdout"] || len(args) != 2 {
+		t.Errorf("expected --stdout and two files, got %v %v %v", flags, args, err)
+	}
+
+	_, _, err = splitFlags([]string{"--jsno", "a.pseudo"}, "json")
+	if err == nil || err.Error() != "unknown flag --jsno" {
+		t.Errorf("expected an unknown flag error, got %v", err)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.pseudo")
+	bad := filepath.Join(dir, "bad.pseudo")
+	os.WriteFile(good, []byte("OUTPUT \"Hello\"\n"), 0644)
+	os.WriteFile(bad, []byte("DECLARE x : INTEGER\nx <- \n"), 0644)
+
+	var out, errOut bytes.Buffer
+	if !checkFiles([]string{good}, &out, &errOut) {
+		t.Errorf("expected %s to pass, got %q", good, errOut.String())
+	}
+	if out.String() != "OK\n" {
+		t.Errorf("expected OK, got %q", out.String())
+	}
+
+	out.Reset()
+	errOut.Reset()
+	if checkFiles([]string{good, bad}, &out, &errOut) {
+		t.Error("expected check to fail when one file has parse errors")
+	}
+	if out.String() != good+": OK\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if !strings.HasPrefix(errOut.String(), bad+": Parse error: line 2") {
+		t.Errorf("unexpected errors %q", errOut.String())
+	}
+}
+
+func TestReportFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.pseudo")
+	bad := filepath.Join(dir, "bad.pseudo")
+	os.WriteFile(good, []byte("OUTPUT \"Hello\"\n"), 0644)
+	os.WriteFile(bad, []byte("DECLARE x : INTEGER\nx <- \n"), 0644)
+
+	var out bytes.Buffer
+	if reportFiles([]string{good, bad}, &out) {
+		t.Error("expected the report to fail when one file has parse errors")
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one report per file, got %q", out.String())
+	}
+
+	var reports [2]report
+	for idx, line := range lines {
+		if err := json.Unmarshal([]byte(line), &reports[idx]); err != nil {
+			t.Fatalf("invalid JSON %s: %v", line, err)
+		}
+	}
+	if reports[0].File != good || !reports[0].OK {
+		t.Errorf("expected %s to pass, got %+v", good, reports[0])
+	}
+	if reports[1].File != bad || reports[1].OK || len(reports[1].Errors) == 0 {
+		t.Errorf("expected %s to fail with errors, got %+v", bad, reports[1])
+	}
+
+	out.Reset()
+	if !reportFiles([]string{good, good}, &out) {
+		t.Errorf("expected files without errors to pass, got %q", out.String())
+	}
+}
+
+func TestFormatFileWritesBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prog.pseudo")
+	if err := os.WriteFile(path, []byte("output 1+2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := formatFile(path, true, &out); err != nil {
+		t.Fatalf("formatFile returned error: %v", err)
+	}
+	if out.String() != "OUTPUT 1 + 2\n" {
+		t.Errorf("wrong --stdout output: %q", out.String())
+	}
+	if content, _ := os.ReadFile(path); string(content) != "output 1+2\n" {
+		t.Errorf("--stdout must not modify the file, got %q", content)
+	}
+
+	if err := formatFile(path, false, &out); err != nil {
+		t.Fatalf("formatFile returned error: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "OUTPUT 1 + 2\n" {
+		t.Errorf("file not rewritten, got %q", content)
+	}
+}
+
+func TestDumpAST(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "prog.pseudo")
+	source := "DECLARE Scores : ARRAY[1:3] OF INTEGER\nScores[1] <- 2 * 3\n"
+	if err := os.WriteFile(filename, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := dumpAST(filename, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var tree struct {
+		Type       string
+		Statements []map[string]interface{}
+	}
+	if err := json.Unmarshal(out.Bytes(), &tree); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+
+	if tree.Type != "Program" || len(tree.Statements) != 2 {
+		t.Fatalf("expected a Program with 2 statements, got %s with %d", tree.Type, len(tree.Statements))
+	}
+
+	declare := tree.Statements[0]
+	dataType := declare["dataType"].(map[string]interface{})
+	if declare["type"] != "DeclareStatement" || dataType["type"] != "ArrayType" {
+		t.Errorf("expected a DeclareStatement of an ArrayType, got %v", declare)
+	}
+
+	value := tree.Statements[1]["value"].(map[string]interface{})
+	if value["type"] != "InfixExpression" || value["operator"] != "*" || value["line"] != float64(2) {
+		t.Errorf("expected the infix expression 2 * 3 on line 2, got %v", value)
+	}
+
+	if err := os.WriteFile(filename, []byte("x <- "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dumpAST(filename, &out); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestFileChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prog.pseudo")
+	if err := os.WriteFile(path, []byte("OUTPUT 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stamp := statFile(path)
+	if stamp == (fileStamp{}) {
+		t.Fatal("expected a stamp for an existing file")
+	}
+
+	if _, changed := fileChanged(path, stamp); changed {
+		t.Error("unmodified file reported as changed")
+	}
+
+	later := stamp.modTime.Add(2 * time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	stamp, changed := fileChanged(path, stamp)
+	if !changed {
+		t.Error("new modification time not reported as a change")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	stamp, changed = fileChanged(path, stamp)
+	if !changed || stamp != (fileStamp{}) {
+		t.Errorf("deleted file should be a change to the zero stamp, got %v %v", changed, stamp)
+	}
+	if _, changed = fileChanged(path, stamp); changed {
+		t.Error("file still missing reported as changed again")
+	}
+
+	if err := os.WriteFile(path, []byte("OUTPUT 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed = fileChanged(path, stamp); !changed {
+		t.Error("recreated file not reported as a change")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+	"github.com/andrinoff/cambridge-lang/pkg/interpreter"
+)
+
+// newTracer returns a trace hook that prints each statement with its line
+// number and the current values of the variables it reads or writes, e.g.
+//
+//	line 4: Total <- Total + Count    [Total = 10, Count = 5]
+func newTracer(w io.Writer) interpreter.TraceFunc {
+	return func(stmt ast.Statement, env *interpreter.Environment) {
+		line := fmt.Sprintf("line %d: %s", ast.StatementToken(stmt).Line, traceText(stmt))
+
+		var values []string
+		seen := make(map[string]bool)
+		for _, name := range statementVariables(stmt) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			val, ok := env.Get(name)
+			if !ok {
+				continue
+			}
+			switch val.(type) {
+			case *interpreter.Function, *interpreter.Procedure, *interpreter.Class:
+				continue
+			}
+			values = append(values, fmt.Sprintf("%s = %s", name, val.Inspect()))
+		}
+
+		if len(values) > 0 {
+			line += "    [" + strings.Join(values, ", ") + "]"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// traceText returns the source form of a statement, shortened to its first
+// line for statements that contain a body
+func traceText(stmt ast.Statement) string {
+	switch s := stmt.(type) {
+	case *ast.IfStatement:
+		return "IF " + s.Condition.String() + " THEN"
+	case *ast.CaseStatement:
+		return "CASE OF " + s.Expr.String()
+	case *ast.ForStatement:
+		text := "FOR " + s.Variable.String() + " <- " + s.Start.String() + " TO " + s.End.String()
+		if s.Step != nil {
+			text += " STEP " + s.Step.String()
+		}
+		return text
+	case *ast.WhileStatement:
+		return "WHILE " + s.Condition.String()
+	case *ast.RepeatStatement:
+		return "REPEAT"
+	case *ast.ProcedureStatement:
+		return "PROCEDURE " + s.Name
+	case *ast.FunctionStatement:
+		return "FUNCTION " + s.Name
+	case *ast.ClassStatement:
+		return "CLASS " + s.Name
+	case *ast.TypeStatement:
+		return "TYPE " + s.Name
+	}
+	return stmt.String()
+}
+
+// statementVariables returns the names of the variables a statement reads or
+// writes, not counting those inside nested bodies
+func statementVariables(stmt ast.Statement) []string {
+	var exprs []ast.Expression
+
+	switch s := stmt.(type) {
+	case *ast.DeclareStatement:
+		exprs = []ast.Expression{s.Value}
+	case *ast.AssignmentStatement:
+		exprs = []ast.Expression{s.Name, s.Value}
+	case *ast.ConstantStatement:
+		exprs = []ast.Expression{s.Value}
+	case *ast.IfStatement:
+		exprs = []ast.Expression{s.Condition}
+	case *ast.CaseStatement:
+		exprs = []ast.Expression{s.Expr}
+	case *ast.ForStatement:
+		exprs = []ast.Expression{s.Start, s.End, s.Step}
+	case *ast.WhileStatement:
+		exprs = []ast.Expression{s.Condition}
+	case *ast.CallStatement:
+		exprs = s.Arguments
+	case *ast.ReturnStatement:
+		exprs = []ast.Expression{s.Value}
+	case *ast.OutputStatement:
+		exprs = s.Values
+	case *ast.WriteFileStatement:
+		exprs = s.Values
+	case *ast.SeekStatement:
+		exprs = []ast.Expression{s.Address}
+	case *ast.PutRecordStatement:
+		exprs = []ast.Expression{s.Variable}
+	case *ast.ExpressionStatement:
+		exprs = []ast.Expression{s.Expression}
+	}
+
+	var names []string
+	for _, expr := range exprs {
+		names = append(names, expressionVariables(expr)...)
+	}
+	return names
+}
+
+func expressionVariables(expr ast.Expression) []string {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return []string{e.Value}
+	case *ast.PrefixExpression:
+		return expressionVariables(e.Right)
+	case *ast.InfixExpression:
+		return append(expressionVariables(e.Left), expressionVariables(e.Right)...)
+	case *ast.ArrayAccess:
+		names := expressionVariables(e.Array)
+		for _, idx := range e.Indices {
+			names = append(names, expressionVariables(idx)...)
+		}
+		return names
+	case *ast.SliceExpression:
+		names := expressionVariables(e.Array)
+		names = append(names, expressionVariables(e.Start)...)
+		return append(names, expressionVariables(e.End)...)
+	case *ast.MemberAccess:
+		return expressionVariables(e.Object)
+	case *ast.CallExpression:
+		var names []string
+		for _, arg := range e.Arguments {
+			names = append(names, expressionVariables(arg)...)
+		}
+		return names
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// watchInterval is how often a watched file is checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// fileStamp identifies one version of a watched file. The zero value means
+// the file does not exist.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(filename string) fileStamp {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// fileChanged reports the current stamp of filename and whether it differs
+// from last. A deleted file counts as a change once, and again when it is
+// recreated.
+func fileChanged(filename string, last fileStamp) (fileStamp, bool) {
+	current := statFile(filename)
+	return current, current != last
+}
+
+// watchFile runs the file, then re-runs it whenever it is saved, clearing
+// the screen first. Errors are printed without exiting.
+func watchFile(filename string, opts runOptions) {
+	stamp := statFile(filename)
+	runWatched(filename, stamp, opts)
+
+	for {
+		time.Sleep(watchInterval)
+
+		var changed bool
+		stamp, changed = fileChanged(filename, stamp)
+		if changed {
+			fmt.Print("\033[H\033[2J")
+			runWatched(filename, stamp, opts)
+		}
+	}
+}
+
+func runWatched(filename string, stamp fileStamp, opts runOptions) {
+	if stamp == (fileStamp{}) {
+		fmt.Fprintf(os.Stderr, "Waiting for %s to be created...\n", filename)
+		return
+	}
+	runProgram(filename, opts)
+	fmt.Fprintf(os.Stderr, "\nWatching %s for changes (Ctrl+C to stop)\n", filename)
+}
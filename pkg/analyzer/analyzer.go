@@ -0,0 +1,129 @@
+// Package analyzer performs static checks over a parsed Cambridge Pseudocode program
+package analyzer
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+	"github.com/andrinoff/cambridge-lang/pkg/token"
+)
+
+// Warning represents a non-fatal issue found by static analysis
+type Warning struct {
+	Line    int
+	Column  int
+	Length  int // length of the offending token in characters, for editor highlighting
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d, column %d: %s", w.Line, w.Column, w.Message)
+}
+
+type analyzer struct {
+	constants map[string]bool // global constants
+	warnings  []Warning
+}
+
+// Analyze runs all static checks over the program and returns any warnings
+func Analyze(program *ast.Program) []Warning {
+	a := &analyzer{constants: make(map[string]bool)}
+
+	for _, stmt := range program.Statements {
+		if c, ok := stmt.(*ast.ConstantStatement); ok {
+			a.constants[c.Name.Value] = true
+		}
+	}
+
+	a.checkStatements(program.Statements)
+	return a.warnings
+}
+
+func (a *analyzer) warn(tok token.Token, format string, args ...interface{}) {
+	a.warnings = append(a.warnings, Warning{
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Length:  utf8.RuneCountInString(tok.Literal),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkStatements looks for procedure and function definitions, descending
+// into class bodies and nested blocks
+func (a *analyzer) checkStatements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.ProcedureStatement:
+			a.checkCallable(s.Name, s.Parameters, s.Body)
+		case *ast.FunctionStatement:
+			a.checkCallable(s.Name, s.Parameters, s.Body)
+		case *ast.ClassStatement:
+			a.checkStatements(s.Members)
+		default:
+			for _, block := range childBlocks(stmt) {
+				a.checkStatements(block)
+			}
+		}
+	}
+}
+
+// checkCallable reports parameters that shadow global constants and local
+// declarations that shadow parameters
+func (a *analyzer) checkCallable(name string, params []ast.Parameter, body []ast.Statement) {
+	paramNames := make(map[string]bool)
+	for _, param := range params {
+		if a.constants[param.Name] {
+			a.warn(param.Token, "parameter %s of %s shadows global constant %s", param.Name, name, param.Name)
+		}
+		paramNames[param.Name] = true
+	}
+
+	walkDeclarations(body, func(decl *ast.DeclareStatement) {
+		for _, declName := range decl.Names {
+			if paramNames[declName.Value] {
+				a.warn(declName.Token, "local variable %s shadows parameter of %s", declName.Value, name)
+			}
+		}
+	})
+
+	// Nested definitions get their own scope
+	a.checkStatements(body)
+}
+
+// walkDeclarations calls fn for every DECLARE in the given scope, including
+// those inside nested blocks but not inside nested procedure/function bodies
+func walkDeclarations(stmts []ast.Statement, fn func(*ast.DeclareStatement)) {
+	for _, stmt := range stmts {
+		if decl, ok := stmt.(*ast.DeclareStatement); ok {
+			fn(decl)
+			continue
+		}
+		for _, block := range childBlocks(stmt) {
+			walkDeclarations(block, fn)
+		}
+	}
+}
+
+// childBlocks returns the statement lists nested directly inside a control
+// structure. Procedure, function and class bodies open a new scope and are
+// not included.
+func childBlocks(stmt ast.Statement) [][]ast.Statement {
+	switch s := stmt.(type) {
+	case *ast.IfStatement:
+		return [][]ast.Statement{s.Consequence, s.Alternative}
+	case *ast.CaseStatement:
+		blocks := [][]ast.Statement{s.Otherwise}
+		for _, c := range s.Cases {
+			blocks = append(blocks, c.Body)
+		}
+		return blocks
+	case *ast.ForStatement:
+		return [][]ast.Statement{s.Body}
+	case *ast.WhileStatement:
+		return [][]ast.Statement{s.Body}
+	case *ast.RepeatStatement:
+		return [][]ast.Statement{s.Body}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+	"github.com/andrinoff/cambridge-lang/pkg/lexer"
+	"github.com/andrinoff/cambridge-lang/pkg/parser"
+)
+
+func TestLocalDeclarationShadowsParameter(t *testing.T) {
+	input := `PROCEDURE Greet(Name : STRING)
+    IF TRUE THEN
+        DECLARE Name : STRING
+    ENDIF
+    OUTPUT Name
+ENDPROCEDURE`
+
+	warnings := Analyze(parse(t, input))
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d: %v", len(warnings), warnings)
+	}
+
+	w := warnings[0]
+	if !strings.Contains(w.Message, "local variable Name shadows parameter of Greet") {
+		t.Errorf("unexpected warning message: %q", w.Message)
+	}
+	if w.Line != 3 {
+		t.Errorf("expected warning on line 3, got %d", w.Line)
+	}
+}
+
+func TestParameterShadowsGlobalConstant(t *testing.T) {
+	input := `CONSTANT Rate = 5
+FUNCTION Scale(Rate : INTEGER) RETURNS INTEGER
+    RETURN Rate * 2
+ENDFUNCTION`
+
+	warnings := Analyze(parse(t, input))
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d: %v", len(warnings), warnings)
+	}
+
+	if !strings.Contains(warnings[0].Message, "parameter Rate of Scale shadows global constant Rate") {
+		t.Errorf("unexpected warning message: %q", warnings[0].Message)
+	}
+}
+
+func TestDistinctNamesNotWarned(t *testing.T) {
+	input := `CONSTANT Max = 10
+PROCEDURE Count(Limit : INTEGER)
+    DECLARE Total : INTEGER
+    FOR i <- 1 TO Limit
+        Total <- Total + i
+    NEXT i
+ENDPROCEDURE`
+
+	warnings := Analyze(parse(t, input))
+
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestClassMethodShadowing(t *testing.T) {
+	input := `CLASS Counter
+    PUBLIC PROCEDURE Add(Amount : INTEGER)
+        DECLARE Amount : INTEGER
+    ENDPROCEDURE
+ENDCLASS`
+
+	warnings := Analyze(parse(t, input))
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d: %v", len(warnings), warnings)
+	}
+}
+
+// Helper functions
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	return program
+}
@@ -0,0 +1,193 @@
+package analyzer
+
+import "github.com/andrinoff/cambridge-lang/pkg/ast"
+
+// TypeHint is the inferred type of a value assigned to a variable, positioned
+// just after the variable name
+type TypeHint struct {
+	Line   int
+	Column int
+	Type   string
+}
+
+// typeScope tracks the statically known types of variables and function
+// results. An empty string means the type is unknown.
+type typeScope struct {
+	vars      map[string]string
+	functions map[string]string
+	hints     *[]TypeHint
+}
+
+// TypeHints infers the types of values assigned to plain variables. The
+// inference is deliberately shallow: literals, operators over known types,
+// declared variables and calls to functions with a primitive return type.
+// Assignments whose type cannot be determined produce no hint.
+func TypeHints(program *ast.Program) []TypeHint {
+	var hints []TypeHint
+	s := &typeScope{
+		vars:      make(map[string]string),
+		functions: make(map[string]string),
+		hints:     &hints,
+	}
+
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*ast.FunctionStatement); ok {
+			s.functions[fn.Name] = primitiveName(fn.ReturnType)
+		}
+	}
+
+	s.walk(program.Statements)
+	return hints
+}
+
+// InferType returns the static type of expr given the known variable types,
+// or an empty string if it cannot be determined
+func InferType(expr ast.Expression, vars map[string]string) string {
+	s := &typeScope{vars: vars, functions: map[string]string{}}
+	return s.typeOf(expr)
+}
+
+func (s *typeScope) walk(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		switch st := stmt.(type) {
+		case *ast.DeclareStatement:
+			dataType := primitiveName(st.DataType)
+			if st.DataType == nil {
+				dataType = s.typeOf(st.Value)
+			}
+			for _, name := range st.Names {
+				s.vars[name.Value] = dataType
+			}
+		case *ast.ConstantStatement:
+			s.vars[st.Name.Value] = s.typeOf(st.Value)
+		case *ast.AssignmentStatement:
+			s.assign(st)
+		case *ast.ForStatement:
+			s.vars[st.Variable.Value] = s.forType(st)
+			s.walk(st.Body)
+		case *ast.ProcedureStatement:
+			s.enter(st.Parameters).walk(st.Body)
+		case *ast.FunctionStatement:
+			s.enter(st.Parameters).walk(st.Body)
+		case *ast.ClassStatement:
+			s.enter(nil).walk(st.Members)
+		default:
+			for _, block := range childBlocks(stmt) {
+				s.walk(block)
+			}
+		}
+	}
+}
+
+func (s *typeScope) assign(stmt *ast.AssignmentStatement) {
+	ident, ok := stmt.Name.(*ast.Identifier)
+	if !ok {
+		return
+	}
+
+	typ := s.typeOf(stmt.Value)
+	if typ == "" {
+		return
+	}
+
+	if _, declared := s.vars[ident.Value]; !declared {
+		s.vars[ident.Value] = typ
+	}
+
+	*s.hints = append(*s.hints, TypeHint{
+		Line:   ident.Token.Line,
+		Column: ident.Token.Column + len(ident.Token.Literal),
+		Type:   typ,
+	})
+}
+
+// enter returns a nested scope for a procedure, function or class body
+func (s *typeScope) enter(params []ast.Parameter) *typeScope {
+	vars := make(map[string]string, len(s.vars)+len(params))
+	for name, typ := range s.vars {
+		vars[name] = typ
+	}
+	for _, param := range params {
+		vars[param.Name] = primitiveName(param.DataType)
+	}
+	return &typeScope{vars: vars, functions: s.functions, hints: s.hints}
+}
+
+func (s *typeScope) typeOf(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteral:
+		return "INTEGER"
+	case *ast.RealLiteral:
+		return "REAL"
+	case *ast.StringLiteral:
+		return "STRING"
+	case *ast.CharLiteral:
+		return "CHAR"
+	case *ast.BooleanLiteral:
+		return "BOOLEAN"
+	case *ast.Identifier:
+		return s.vars[e.Value]
+	case *ast.PrefixExpression:
+		if e.Operator == "NOT" {
+			return "BOOLEAN"
+		}
+		return numeric(s.typeOf(e.Right))
+	case *ast.InfixExpression:
+		return s.infixType(e)
+	case *ast.CallExpression:
+		if ident, ok := e.Function.(*ast.Identifier); ok {
+			return s.functions[ident.Value]
+		}
+	}
+	return ""
+}
+
+// forType returns REAL for a FOR loop whose start, end or step is REAL, and
+// INTEGER otherwise
+func (s *typeScope) forType(st *ast.ForStatement) string {
+	for _, expr := range []ast.Expression{st.Start, st.End, st.Step} {
+		if expr != nil && s.typeOf(expr) == "REAL" {
+			return "REAL"
+		}
+	}
+	return "INTEGER"
+}
+
+func (s *typeScope) infixType(e *ast.InfixExpression) string {
+	switch e.Operator {
+	case "=", "<>", "<", ">", "<=", ">=", "AND", "OR", "IN":
+		return "BOOLEAN"
+	case "&":
+		return "STRING"
+	case "DIV", "MOD":
+		return "INTEGER"
+	}
+
+	left := numeric(s.typeOf(e.Left))
+	right := numeric(s.typeOf(e.Right))
+	if left == "" || right == "" {
+		return ""
+	}
+
+	if e.Operator == "/" || left == "REAL" || right == "REAL" {
+		return "REAL"
+	}
+	return "INTEGER"
+}
+
+// numeric returns typ if it is INTEGER or REAL, otherwise unknown
+func numeric(typ string) string {
+	if typ == "INTEGER" || typ == "REAL" {
+		return typ
+	}
+	return ""
+}
+
+// primitiveName returns the name of a primitive data type, or unknown for
+// arrays, records and other composite types
+func primitiveName(dt ast.DataType) string {
+	if p, ok := dt.(*ast.PrimitiveType); ok {
+		return p.Name
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+)
+
+func TestTypeHints(t *testing.T) {
+	input := `PROCEDURE Greet()
+    OUTPUT "Hi"
+ENDPROCEDURE
+x <- 1 + 2
+y <- "a" & "b"
+z <- Greet()`
+
+	hints := TypeHints(parse(t, input))
+
+	if len(hints) != 2 {
+		t.Fatalf("expected 2 hints, got %d: %v", len(hints), hints)
+	}
+
+	expected := []TypeHint{
+		{Line: 4, Column: 2, Type: "INTEGER"},
+		{Line: 5, Column: 2, Type: "STRING"},
+	}
+	for idx, want := range expected {
+		if hints[idx] != want {
+			t.Errorf("hints[%d] wrong. expected=%+v, got=%+v", idx, want, hints[idx])
+		}
+	}
+}
+
+func TestTypeHintsForLoopVariable(t *testing.T) {
+	input := `FOR i <- 1 TO 3
+    a <- i
+NEXT i
+FOR x <- 0 TO 1 STEP 0.5
+    b <- x
+NEXT x`
+
+	hints := TypeHints(parse(t, input))
+
+	expected := []TypeHint{
+		{Line: 2, Column: 6, Type: "INTEGER"},
+		{Line: 5, Column: 6, Type: "REAL"},
+	}
+	if len(hints) != len(expected) {
+		t.Fatalf("expected %d hints, got %d: %v", len(expected), len(hints), hints)
+	}
+	for idx, want := range expected {
+		if hints[idx] != want {
+			t.Errorf("hints[%d] wrong. expected=%+v, got=%+v", idx, want, hints[idx])
+		}
+	}
+}
+
+func TestInferType(t *testing.T) {
+	vars := map[string]string{"Count": "INTEGER", "Rate": "REAL"}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x <- Count * 2", "INTEGER"},
+		{"x <- Count * Rate", "REAL"},
+		{"x <- Count / 2", "REAL"},
+		{"x <- Count > 2", "BOOLEAN"},
+		{"x <- -Rate", "REAL"},
+		{"x <- Unknown + 1", ""},
+		{"x <- Greet()", ""},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		assign := program.Statements[0].(*ast.AssignmentStatement)
+		if got := InferType(assign.Value, vars); got != tt.expected {
+			t.Errorf("InferType(%s) wrong. expected=%q, got=%q", assign.Value, tt.expected, got)
+		}
+	}
+}
@@ -98,7 +98,10 @@ type CharLiteral struct {
 
 func (cl *CharLiteral) expressionNode()      {}
 func (cl *CharLiteral) TokenLiteral() string { return cl.Token.Literal }
-func (cl *CharLiteral) String() string       { return "'" + cl.Value + "'" }
+func (cl *CharLiteral) String() string       { return "'" + charEscaper.Replace(cl.Value) + "'" }
+
+// charEscaper writes characters that need an escape in a character literal
+var charEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "'", `\'`)
 
 // BooleanLiteral represents a boolean value
 type BooleanLiteral struct {
@@ -108,7 +111,21 @@ type BooleanLiteral struct {
 
 func (bl *BooleanLiteral) expressionNode()      {}
 func (bl *BooleanLiteral) TokenLiteral() string { return bl.Token.Literal }
-func (bl *BooleanLiteral) String() string       { return bl.Token.Literal }
+func (bl *BooleanLiteral) String() string {
+	if bl.Value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// NullLiteral represents the NULL reference
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) String() string       { return "NULL" }
 
 // PrefixExpression represents a prefix operation (e.g., NOT, -)
 type PrefixExpression struct {
@@ -120,7 +137,16 @@ type PrefixExpression struct {
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 func (pe *PrefixExpression) String() string {
-	return "(" + pe.Operator + " " + pe.Right.String() + ")"
+	op := pe.Operator
+	if op == "NOT" {
+		op += " "
+	}
+	// A prefix operator takes everything that binds more tightly than it,
+	// so -2 ^ 2 is -(2 ^ 2) and needs no parentheses
+	if _, ok := pe.Right.(*PrefixExpression); ok {
+		return op + pe.Right.String()
+	}
+	return op + operand(pe.Right, precPower)
 }
 
 // InfixExpression represents a binary operation
@@ -134,7 +160,87 @@ type InfixExpression struct {
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
 func (ie *InfixExpression) String() string {
-	return "(" + ie.Left.String() + " " + ie.Operator + " " + ie.Right.String() + ")"
+	prec := operatorPrecedence[ie.Operator]
+	leftMin, rightMin := prec, prec+1
+	if ie.Operator == "^" {
+		leftMin, rightMin = prec+1, prec // right-associative
+	}
+
+	right := operand(ie.Right, rightMin)
+	if _, ok := ie.Right.(*PrefixExpression); ok {
+		right = ie.Right.String() // a prefix operator can always start an operand
+	}
+	return operand(ie.Left, leftMin) + " " + ie.Operator + " " + right
+}
+
+// Binding strengths of operators, mirroring the parser's precedences. They
+// let String() add only the parentheses needed to parse an expression back
+// into the same tree.
+const (
+	precLowest = iota
+	precOr
+	precAnd
+	precEquals
+	precCompare
+	precSum
+	precProduct
+	precPrefix
+	precPower
+	precPostfix // literals, names, calls, indexing and member access
+)
+
+var operatorPrecedence = map[string]int{
+	"OR":  precOr,
+	"AND": precAnd,
+	"=":   precEquals,
+	"<>":  precEquals,
+	"IN":  precEquals,
+	"<":   precCompare,
+	">":   precCompare,
+	"<=":  precCompare,
+	">=":  precCompare,
+	"+":   precSum,
+	"-":   precSum,
+	"&":   precSum,
+	"*":   precProduct,
+	"/":   precProduct,
+	"DIV": precProduct,
+	"MOD": precProduct,
+	"^":   precPower,
+}
+
+func precedence(expr Expression) int {
+	switch e := expr.(type) {
+	case *InfixExpression:
+		return operatorPrecedence[e.Operator]
+	case *PrefixExpression:
+		return precPrefix
+	}
+	return precPostfix
+}
+
+// operand prints expr, in parentheses if it binds less tightly than min
+func operand(expr Expression, min int) string {
+	if precedence(expr) < min {
+		return "(" + expr.String() + ")"
+	}
+	return expr.String()
+}
+
+// SetLiteral represents a set of values: {1, 2, 3}
+type SetLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) String() string {
+	var elems []string
+	for _, e := range sl.Elements {
+		elems = append(elems, e.String())
+	}
+	return "{" + strings.Join(elems, ", ") + "}"
 }
 
 // ArrayAccess represents array indexing: arr[i] or arr[i,j]
@@ -151,7 +257,21 @@ func (aa *ArrayAccess) String() string {
 	for _, idx := range aa.Indices {
 		indices = append(indices, idx.String())
 	}
-	return aa.Array.String() + "[" + strings.Join(indices, ", ") + "]"
+	return operand(aa.Array, precPostfix) + "[" + strings.Join(indices, ", ") + "]"
+}
+
+// SliceExpression represents part of an array: arr[start..end]
+type SliceExpression struct {
+	Token token.Token
+	Array Expression
+	Start Expression
+	End   Expression
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	return operand(se.Array, precPostfix) + "[" + se.Start.String() + ".." + se.End.String() + "]"
 }
 
 // MemberAccess represents object member access: obj.field
@@ -164,7 +284,7 @@ type MemberAccess struct {
 func (ma *MemberAccess) expressionNode()      {}
 func (ma *MemberAccess) TokenLiteral() string { return ma.Token.Literal }
 func (ma *MemberAccess) String() string {
-	return ma.Object.String() + "." + ma.Member
+	return operand(ma.Object, precPostfix) + "." + ma.Member
 }
 
 // CallExpression represents a function/procedure call
@@ -181,7 +301,7 @@ func (ce *CallExpression) String() string {
 	for _, a := range ce.Arguments {
 		args = append(args, a.String())
 	}
-	return ce.Function.String() + "(" + strings.Join(args, ", ") + ")"
+	return operand(ce.Function, precPostfix) + "(" + strings.Join(args, ", ") + ")"
 }
 
 // NewExpression represents object instantiation: NEW ClassName(args)
@@ -212,12 +332,15 @@ func (se *SuperExpression) String() string       { return "SUPER" }
 
 // ============ STATEMENTS ============
 
-// DeclareStatement represents: DECLARE x : INTEGER
+// DeclareStatement represents: DECLARE x : INTEGER, or DECLARE x, y, z :
+// INTEGER for several variables of one type, optionally followed by <- value.
+// In DECLARE x <- value the type is inferred and DataType is nil.
 type DeclareStatement struct {
 	Token    token.Token
-	Name     *Identifier
+	Names    []*Identifier
 	DataType DataType
-	Access   string // "PUBLIC" or "PRIVATE" for class properties
+	Value    Expression // initial value, nil if none
+	Access   string     // "PUBLIC" or "PRIVATE" for class properties
 }
 
 func (ds *DeclareStatement) statementNode()       {}
@@ -227,7 +350,17 @@ func (ds *DeclareStatement) String() string {
 	if ds.Access != "" {
 		out.WriteString(ds.Access + " ")
 	}
-	out.WriteString("DECLARE " + ds.Name.String() + " : " + ds.DataType.String())
+	var names []string
+	for _, name := range ds.Names {
+		names = append(names, name.String())
+	}
+	out.WriteString("DECLARE " + strings.Join(names, ", "))
+	if ds.DataType != nil {
+		out.WriteString(" : " + ds.DataType.String())
+	}
+	if ds.Value != nil {
+		out.WriteString(" <- " + ds.Value.String())
+	}
 	return out.String()
 }
 
@@ -271,12 +404,12 @@ func (is *IfStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("IF " + is.Condition.String() + " THEN\n")
 	for _, s := range is.Consequence {
-		out.WriteString("  " + s.String() + "\n")
+		out.WriteString(indent(s.String(), "  ") + "\n")
 	}
 	if is.Alternative != nil {
 		out.WriteString("ELSE\n")
 		for _, s := range is.Alternative {
-			out.WriteString("  " + s.String() + "\n")
+			out.WriteString(indent(s.String(), "  ") + "\n")
 		}
 	}
 	out.WriteString("ENDIF")
@@ -307,21 +440,29 @@ func (cs *CaseStatement) String() string {
 		for _, v := range c.Values {
 			vals = append(vals, v.String())
 		}
-		out.WriteString("  " + strings.Join(vals, ", ") + " :\n")
-		for _, s := range c.Body {
-			out.WriteString("    " + s.String() + "\n")
-		}
+		out.WriteString(caseBody("  "+strings.Join(vals, ", ")+" :", c.Body))
 	}
 	if cs.Otherwise != nil {
-		out.WriteString("  OTHERWISE :\n")
-		for _, s := range cs.Otherwise {
-			out.WriteString("    " + s.String() + "\n")
-		}
+		out.WriteString(caseBody("  OTHERWISE :", cs.Otherwise))
 	}
 	out.WriteString("ENDCASE")
 	return out.String()
 }
 
+// caseBody prints a CASE label followed by its statements, on the same line
+// when the body is a single one-line statement
+func caseBody(label string, body []Statement) string {
+	if len(body) == 1 && !strings.Contains(body[0].String(), "\n") {
+		return label + " " + body[0].String() + "\n"
+	}
+	var out bytes.Buffer
+	out.WriteString(label + "\n")
+	for _, s := range body {
+		out.WriteString(indent(s.String(), "    ") + "\n")
+	}
+	return out.String()
+}
+
 // ForStatement represents: FOR i ← 1 TO 10 STEP 1...NEXT i
 type ForStatement struct {
 	Token    token.Token
@@ -342,7 +483,7 @@ func (fs *ForStatement) String() string {
 	}
 	out.WriteString("\n")
 	for _, s := range fs.Body {
-		out.WriteString("  " + s.String() + "\n")
+		out.WriteString(indent(s.String(), "  ") + "\n")
 	}
 	out.WriteString("NEXT " + fs.Variable.String())
 	return out.String()
@@ -361,7 +502,7 @@ func (ws *WhileStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("WHILE " + ws.Condition.String() + "\n")
 	for _, s := range ws.Body {
-		out.WriteString("  " + s.String() + "\n")
+		out.WriteString(indent(s.String(), "  ") + "\n")
 	}
 	out.WriteString("ENDWHILE")
 	return out.String()
@@ -380,7 +521,7 @@ func (rs *RepeatStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("REPEAT\n")
 	for _, s := range rs.Body {
-		out.WriteString("  " + s.String() + "\n")
+		out.WriteString(indent(s.String(), "  ") + "\n")
 	}
 	out.WriteString("UNTIL " + rs.Condition.String())
 	return out.String()
@@ -388,6 +529,7 @@ func (rs *RepeatStatement) String() string {
 
 // Parameter represents a procedure/function parameter
 type Parameter struct {
+	Token    token.Token // the parameter name token
 	Name     string
 	DataType DataType
 	ByRef    bool
@@ -422,7 +564,7 @@ func (ps *ProcedureStatement) String() string {
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")\n")
 	for _, s := range ps.Body {
-		out.WriteString("  " + s.String() + "\n")
+		out.WriteString(indent(s.String(), "  ") + "\n")
 	}
 	out.WriteString("ENDPROCEDURE")
 	return out.String()
@@ -458,7 +600,7 @@ func (fs *FunctionStatement) String() string {
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") RETURNS " + fs.ReturnType.String() + "\n")
 	for _, s := range fs.Body {
-		out.WriteString("  " + s.String() + "\n")
+		out.WriteString(indent(s.String(), "  ") + "\n")
 	}
 	out.WriteString("ENDFUNCTION")
 	return out.String()
@@ -496,22 +638,28 @@ func (rs *ReturnStatement) String() string {
 	return "RETURN"
 }
 
-// InputStatement represents: INPUT variable
+// InputStatement represents: INPUT var1, var2, ...
 type InputStatement struct {
-	Token    token.Token
-	Variable Expression
+	Token     token.Token
+	Variables []Expression
 }
 
 func (is *InputStatement) statementNode()       {}
 func (is *InputStatement) TokenLiteral() string { return is.Token.Literal }
 func (is *InputStatement) String() string {
-	return "INPUT " + is.Variable.String()
+	var vars []string
+	for _, v := range is.Variables {
+		vars = append(vars, v.String())
+	}
+	return "INPUT " + strings.Join(vars, ", ")
 }
 
-// OutputStatement represents: OUTPUT expr1, expr2, ...
+// OutputStatement represents: OUTPUT expr1, expr2, ... or, when ToError is
+// set, EPRINT expr1, expr2, ... which writes to the error output
 type OutputStatement struct {
-	Token  token.Token
-	Values []Expression
+	Token   token.Token
+	Values  []Expression
+	ToError bool
 }
 
 func (os *OutputStatement) statementNode()       {}
@@ -521,14 +669,21 @@ func (os *OutputStatement) String() string {
 	for _, v := range os.Values {
 		vals = append(vals, v.String())
 	}
-	return "OUTPUT " + strings.Join(vals, ", ")
+	keyword := "OUTPUT"
+	if os.ToError {
+		keyword = "EPRINT"
+	}
+	if len(vals) == 0 {
+		return keyword
+	}
+	return keyword + " " + strings.Join(vals, ", ")
 }
 
 // OpenFileStatement represents: OPENFILE filename FOR mode
 type OpenFileStatement struct {
 	Token    token.Token
 	Filename Expression
-	Mode     string // "READ", "WRITE", "APPEND"
+	Mode     string // "READ", "WRITE", "APPEND", "RANDOM"
 }
 
 func (of *OpenFileStatement) statementNode()       {}
@@ -562,17 +717,66 @@ func (rf *ReadFileStatement) String() string {
 	return "READFILE " + rf.Filename.String() + ", " + rf.Variable.String()
 }
 
-// WriteFileStatement represents: WRITEFILE filename, data
+// WriteFileStatement represents: WRITEFILE filename, value1, value2, ...
+// A trailing comma after the last value leaves the line unfinished.
 type WriteFileStatement struct {
-	Token    token.Token
-	Filename Expression
-	Data     Expression
+	Token     token.Token
+	Filename  Expression
+	Values    []Expression
+	NoNewline bool
 }
 
 func (wf *WriteFileStatement) statementNode()       {}
 func (wf *WriteFileStatement) TokenLiteral() string { return wf.Token.Literal }
 func (wf *WriteFileStatement) String() string {
-	return "WRITEFILE " + wf.Filename.String() + ", " + wf.Data.String()
+	var vals []string
+	for _, v := range wf.Values {
+		vals = append(vals, v.String())
+	}
+	out := "WRITEFILE " + wf.Filename.String() + ", " + strings.Join(vals, ", ")
+	if wf.NoNewline {
+		out += ","
+	}
+	return out
+}
+
+// SeekStatement represents: SEEK filename, address
+type SeekStatement struct {
+	Token    token.Token
+	Filename Expression
+	Address  Expression
+}
+
+func (ss *SeekStatement) statementNode()       {}
+func (ss *SeekStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SeekStatement) String() string {
+	return "SEEK " + ss.Filename.String() + ", " + ss.Address.String()
+}
+
+// GetRecordStatement represents: GETRECORD filename, variable
+type GetRecordStatement struct {
+	Token    token.Token
+	Filename Expression
+	Variable Expression
+}
+
+func (gr *GetRecordStatement) statementNode()       {}
+func (gr *GetRecordStatement) TokenLiteral() string { return gr.Token.Literal }
+func (gr *GetRecordStatement) String() string {
+	return "GETRECORD " + gr.Filename.String() + ", " + gr.Variable.String()
+}
+
+// PutRecordStatement represents: PUTRECORD filename, variable
+type PutRecordStatement struct {
+	Token    token.Token
+	Filename Expression
+	Variable Expression
+}
+
+func (pr *PutRecordStatement) statementNode()       {}
+func (pr *PutRecordStatement) TokenLiteral() string { return pr.Token.Literal }
+func (pr *PutRecordStatement) String() string {
+	return "PUTRECORD " + pr.Filename.String() + ", " + pr.Variable.String()
 }
 
 // TypeStatement represents: TYPE name...ENDTYPE (for records, enums, etc.)
@@ -585,7 +789,28 @@ type TypeStatement struct {
 func (ts *TypeStatement) statementNode()       {}
 func (ts *TypeStatement) TokenLiteral() string { return ts.Token.Literal }
 func (ts *TypeStatement) String() string {
-	return "TYPE " + ts.Name + "\n" + ts.Definition.String() + "\nENDTYPE"
+	if _, ok := ts.Definition.(*RecordType); ok {
+		return "TYPE " + ts.Name + "\n" + ts.Definition.String() + "ENDTYPE"
+	}
+	return "TYPE " + ts.Name + " = " + ts.Definition.String()
+}
+
+// DefineStatement represents: DEFINE name (value1, value2, ...) : SetType
+type DefineStatement struct {
+	Token    token.Token
+	Name     *Identifier
+	Values   []Expression
+	TypeName string
+}
+
+func (ds *DefineStatement) statementNode()       {}
+func (ds *DefineStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DefineStatement) String() string {
+	var vals []string
+	for _, v := range ds.Values {
+		vals = append(vals, v.String())
+	}
+	return "DEFINE " + ds.Name.String() + " (" + strings.Join(vals, ", ") + ") : " + ds.TypeName
 }
 
 // ClassStatement represents: CLASS name INHERITS parent...ENDCLASS
@@ -606,7 +831,7 @@ func (cs *ClassStatement) String() string {
 	}
 	out.WriteString("\n")
 	for _, m := range cs.Members {
-		out.WriteString("  " + m.String() + "\n")
+		out.WriteString(indent(m.String(), "  ") + "\n")
 	}
 	out.WriteString("ENDCLASS")
 	return out.String()
@@ -627,6 +852,64 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+// StatementToken returns the token stored on a statement, its first token,
+// which gives its source position
+func StatementToken(stmt Statement) token.Token {
+	switch s := stmt.(type) {
+	case *DeclareStatement:
+		return s.Token
+	case *ConstantStatement:
+		return s.Token
+	case *AssignmentStatement:
+		return s.Token
+	case *IfStatement:
+		return s.Token
+	case *CaseStatement:
+		return s.Token
+	case *ForStatement:
+		return s.Token
+	case *WhileStatement:
+		return s.Token
+	case *RepeatStatement:
+		return s.Token
+	case *ProcedureStatement:
+		return s.Token
+	case *FunctionStatement:
+		return s.Token
+	case *CallStatement:
+		return s.Token
+	case *ReturnStatement:
+		return s.Token
+	case *InputStatement:
+		return s.Token
+	case *OutputStatement:
+		return s.Token
+	case *OpenFileStatement:
+		return s.Token
+	case *CloseFileStatement:
+		return s.Token
+	case *ReadFileStatement:
+		return s.Token
+	case *WriteFileStatement:
+		return s.Token
+	case *SeekStatement:
+		return s.Token
+	case *GetRecordStatement:
+		return s.Token
+	case *PutRecordStatement:
+		return s.Token
+	case *TypeStatement:
+		return s.Token
+	case *DefineStatement:
+		return s.Token
+	case *ClassStatement:
+		return s.Token
+	case *ExpressionStatement:
+		return s.Token
+	}
+	return token.Token{}
+}
+
 // ============ DATA TYPES ============
 
 // DataType represents a data type
@@ -650,12 +933,28 @@ type ArrayType struct {
 type ArrayDimension struct {
 	Lower int
 	Upper int
+
+	// LowerExpr and UpperExpr hold bounds that are not integer literals,
+	// such as constants. They are resolved when the declaration is executed.
+	LowerExpr Expression
+	UpperExpr Expression
+}
+
+func (d ArrayDimension) String() string {
+	lower, upper := fmt.Sprintf("%d", d.Lower), fmt.Sprintf("%d", d.Upper)
+	if d.LowerExpr != nil {
+		lower = d.LowerExpr.String()
+	}
+	if d.UpperExpr != nil {
+		upper = d.UpperExpr.String()
+	}
+	return lower + ":" + upper
 }
 
 func (at *ArrayType) String() string {
 	var dims []string
 	for _, d := range at.Dimensions {
-		dims = append(dims, fmt.Sprintf("%d:%d", d.Lower, d.Upper))
+		dims = append(dims, d.String())
 	}
 	return "ARRAY[" + strings.Join(dims, ",") + "] OF " + at.ElementType.String()
 }
@@ -696,6 +995,25 @@ func (pt *PointerType) String() string {
 	return "^" + pt.TargetType.String()
 }
 
+// SetType represents: SET OF type
+type SetType struct {
+	ElementType DataType
+}
+
+func (st *SetType) String() string {
+	return "SET OF " + st.ElementType.String()
+}
+
+// DictionaryType represents: DICTIONARY OF keyType TO valueType
+type DictionaryType struct {
+	KeyType   DataType
+	ValueType DataType
+}
+
+func (dt *DictionaryType) String() string {
+	return "DICTIONARY OF " + dt.KeyType.String() + " TO " + dt.ValueType.String()
+}
+
 // CustomType represents a user-defined type reference
 type CustomType struct {
 	Name string
@@ -715,3 +1033,9 @@ func (re *RangeExpression) TokenLiteral() string { return re.Token.Literal }
 func (re *RangeExpression) String() string {
 	return re.Start.String() + " TO " + re.End.String()
 }
+
+// indent prefixes every line of s with prefix so that nested blocks keep
+// their structure when printed inside an enclosing block
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
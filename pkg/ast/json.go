@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/andrinoff/cambridge-lang/pkg/token"
+)
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// Tree converts a node into maps, slices and plain values that encoding/json
+// can marshal. Each node becomes an object whose "type" is the name of its Go
+// type, e.g. "InfixExpression", so that Expression, Statement and DataType
+// fields can be told apart. A node's Token is replaced by its "line" and
+// "column", and the remaining fields keep their names in lower camel case.
+func Tree(node interface{}) interface{} {
+	return treeValue(reflect.ValueOf(node))
+}
+
+func treeValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return treeValue(v.Elem())
+	case reflect.Struct:
+		return treeStruct(v)
+	case reflect.Slice:
+		items := make([]interface{}, v.Len())
+		for idx := range items {
+			items[idx] = treeValue(v.Index(idx))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+func treeStruct(v reflect.Value) map[string]interface{} {
+	t := v.Type()
+	obj := map[string]interface{}{"type": t.Name()}
+
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		if field.Type == tokenType {
+			tok := v.Field(idx).Interface().(token.Token)
+			obj["line"] = tok.Line
+			obj["column"] = tok.Column
+			continue
+		}
+		obj[lowerFirst(field.Name)] = treeValue(v.Field(idx))
+	}
+	return obj
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
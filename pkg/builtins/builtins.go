@@ -3,6 +3,7 @@
 package builtins
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -10,56 +11,312 @@ import (
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
 	"github.com/andrinoff/cambridge-lang/pkg/interpreter"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// rng is the generator behind RAND and RANDOM. It is kept at package level
+// so RANDOMIZE (and tests) can reseed it for reproducible sequences.
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// Seed reseeds the generator used by RAND and RANDOM
+func Seed(seed int64) {
+	rng.Seed(seed)
 }
 
 // GetBuiltins returns all built-in functions
 func GetBuiltins() map[string]*interpreter.Builtin {
 	return map[string]*interpreter.Builtin{
 		// String functions
-		"LENGTH":   {Name: "LENGTH", Fn: length},
-		"LEFT":     {Name: "LEFT", Fn: left},
-		"RIGHT":    {Name: "RIGHT", Fn: right},
-		"MID":      {Name: "MID", Fn: mid},
-		"LCASE":    {Name: "LCASE", Fn: lcase},
-		"UCASE":    {Name: "UCASE", Fn: ucase},
-		"TO_UPPER": {Name: "TO_UPPER", Fn: toUpper},
-		"TO_LOWER": {Name: "TO_LOWER", Fn: toLower},
+		"LENGTH": {
+			Name: "LENGTH", Fn: length,
+			Signature:   "LENGTH(s: STRING) RETURNS INTEGER",
+			Description: "Returns the number of characters in s",
+		},
+		"LEFT": {
+			Name: "LEFT", Fn: left,
+			Signature:   "LEFT(s: STRING, n: INTEGER) RETURNS STRING",
+			Description: "Returns the leftmost n characters of s",
+		},
+		"RIGHT": {
+			Name: "RIGHT", Fn: right,
+			Signature:   "RIGHT(s: STRING, n: INTEGER) RETURNS STRING",
+			Description: "Returns the rightmost n characters of s",
+		},
+		"MID": {
+			Name: "MID", Fn: mid,
+			Signature:   "MID(s: STRING, start: INTEGER, length: INTEGER) RETURNS STRING",
+			Description: "Returns length characters of s starting at position start",
+		},
+		"LCASE": {
+			Name: "LCASE", Fn: lcase,
+			Signature:   "LCASE(c: CHAR) RETURNS CHAR",
+			Description: "Converts a character or string to lowercase",
+		},
+		"UCASE": {
+			Name: "UCASE", Fn: ucase,
+			Signature:   "UCASE(c: CHAR) RETURNS CHAR",
+			Description: "Converts a character or string to uppercase",
+		},
+		"TO_UPPER": {
+			Name: "TO_UPPER", Fn: toUpper,
+			Signature:   "TO_UPPER(s: STRING) RETURNS STRING",
+			Description: "Converts a string to uppercase",
+		},
+		"TO_LOWER": {
+			Name: "TO_LOWER", Fn: toLower,
+			Signature:   "TO_LOWER(s: STRING) RETURNS STRING",
+			Description: "Converts a string to lowercase",
+		},
+
+		"EQUALS_IGNORE_CASE": {
+			Name: "EQUALS_IGNORE_CASE", Fn: equalsIgnoreCase,
+			Signature:   "EQUALS_IGNORE_CASE(a: STRING, b: STRING) RETURNS BOOLEAN",
+			Description: "Returns TRUE if a and b are equal ignoring case",
+		},
+		"COMPARE_IGNORE_CASE": {
+			Name: "COMPARE_IGNORE_CASE", Fn: compareIgnoreCase,
+			Signature:   "COMPARE_IGNORE_CASE(a: STRING, b: STRING) RETURNS INTEGER",
+			Description: "Compares a and b ignoring case, returning -1, 0 or 1",
+		},
+		"COMPARE": {
+			Name: "COMPARE", Fn: compare,
+			Signature:   "COMPARE(a: STRING, b: STRING) RETURNS INTEGER",
+			Description: "Compares a and b, returning -1, 0 or 1",
+		},
+		"TAB": {
+			Name: "TAB", Fn: tab,
+			Signature:   "TAB(n: INTEGER) RETURNS STRING",
+			Description: "Returns a string of n spaces",
+		},
+		"PAD_LEFT": {
+			Name: "PAD_LEFT", Fn: padLeft,
+			Signature:   "PAD_LEFT(s: STRING, width: INTEGER) RETURNS STRING",
+			Description: "Adds spaces before s to make it width characters long; longer strings are unchanged",
+		},
+		"PAD_RIGHT": {
+			Name: "PAD_RIGHT", Fn: padRight,
+			Signature:   "PAD_RIGHT(s: STRING, width: INTEGER) RETURNS STRING",
+			Description: "Adds spaces after s to make it width characters long; longer strings are unchanged",
+		},
 
 		// Character/ASCII functions
-		"ASC": {Name: "ASC", Fn: asc},
-		"CHR": {Name: "CHR", Fn: chr},
+		"ASC": {
+			Name: "ASC", Fn: asc,
+			Signature:   "ASC(c: CHAR) RETURNS INTEGER",
+			Description: "Returns the ASCII value of a character",
+		},
+		"CHR": {
+			Name: "CHR", Fn: chr,
+			Signature:   "CHR(n: INTEGER) RETURNS CHAR",
+			Description: "Returns the character with ASCII value n",
+		},
+		"IS_ALPHA": {
+			Name: "IS_ALPHA", Fn: isAlpha,
+			Signature:   "IS_ALPHA(s: STRING) RETURNS BOOLEAN",
+			Description: "Returns TRUE if s is a letter or a non-empty string of letters",
+		},
+		"IS_DIGIT": {
+			Name: "IS_DIGIT", Fn: isDigit,
+			Signature:   "IS_DIGIT(c: CHAR) RETURNS BOOLEAN",
+			Description: "Returns TRUE if c is a digit or a non-empty string of digits",
+		},
 
 		// Numeric functions
-		"INT":    {Name: "INT", Fn: intFunc},
-		"RAND":   {Name: "RAND", Fn: randFunc},
-		"RANDOM": {Name: "RANDOM", Fn: random},
-		"ROUND":  {Name: "ROUND", Fn: round},
+		"INT": {
+			Name: "INT", Fn: intFunc,
+			Signature:   "INT(x: REAL) RETURNS INTEGER",
+			Description: "Returns the integer part of x",
+		},
+		"RAND": {
+			Name: "RAND", Fn: randFunc,
+			Signature:   "RAND(n: INTEGER) RETURNS REAL",
+			Description: "Returns a random real number from 0 up to but not including n",
+		},
+		"RANDOM": {
+			Name: "RANDOM", Fn: random,
+			Signature:   "RANDOM() RETURNS REAL",
+			Description: "Returns a random real number from 0 to 1",
+		},
+		"ROUND": {
+			Name: "ROUND", Fn: round,
+			Signature:   "ROUND(x: REAL, places: INTEGER) RETURNS REAL",
+			Description: "Rounds x to the given number of decimal places, halves away from zero",
+		},
+		"ROUNDEVEN": {
+			Name: "ROUNDEVEN", Fn: roundEven,
+			Signature:   "ROUNDEVEN(x: REAL, places: INTEGER) RETURNS REAL",
+			Description: "Rounds x to the given number of decimal places, halves to the nearest even digit",
+		},
+
+		"RANDOMIZE": {
+			Name: "RANDOMIZE", Fn: randomize,
+			Signature:   "RANDOMIZE(seed: INTEGER)",
+			Description: "Reseeds the random number generator for repeatable results",
+		},
 
 		// Conversion functions
-		"NUM_TO_STR": {Name: "NUM_TO_STR", Fn: numToStr},
-		"STR_TO_NUM": {Name: "STR_TO_NUM", Fn: strToNum},
+		"NUM_TO_STR": {
+			Name: "NUM_TO_STR", Fn: numToStr,
+			Signature:   "NUM_TO_STR(n: REAL) RETURNS STRING",
+			Description: "Converts a number to a string",
+		},
+		"STR_TO_NUM": {
+			Name: "STR_TO_NUM", Fn: strToNum,
+			Signature:   "STR_TO_NUM(s: STRING) RETURNS REAL",
+			Description: "Converts a string to a number",
+		},
+		"IS_NUMERIC": {
+			Name: "IS_NUMERIC", Fn: isNumeric,
+			Signature:   "IS_NUMERIC(s: STRING) RETURNS BOOLEAN",
+			Description: "Returns TRUE if STR_TO_NUM can convert s to a number",
+		},
+		"PARSEINT": {
+			Name: "PARSEINT", Fn: parseInt,
+			Signature:   "PARSEINT(s: STRING, base: INTEGER) RETURNS INTEGER",
+			Description: "Converts a string of digits in the given base, from 2 to 36, to an integer",
+		},
+		"TO_BINARY": {
+			Name: "TO_BINARY", Fn: toBinary,
+			Signature:   "TO_BINARY(n: INTEGER) RETURNS STRING",
+			Description: "Returns n in base 2, with a leading - if n is negative",
+		},
+		"TO_HEX": {
+			Name: "TO_HEX", Fn: toHex,
+			Signature:   "TO_HEX(n: INTEGER) RETURNS STRING",
+			Description: "Returns n in base 16 with upper-case digits, with a leading - if n is negative",
+		},
+		"ARRAY_TO_STRING": {
+			Name: "ARRAY_TO_STRING", Fn: arrayToString,
+			Signature:   "ARRAY_TO_STRING(arr: ARRAY OF CHAR) RETURNS STRING",
+			Description: "Joins the characters of a 1D array into a string",
+		},
+		"STRING_TO_ARRAY": {
+			Name: "STRING_TO_ARRAY", Fn: stringToArray,
+			Signature:   "STRING_TO_ARRAY(s: STRING) RETURNS ARRAY OF CHAR",
+			Description: "Splits s into an array of characters indexed from 1",
+		},
+
+		// Program arguments
+		"ARG": {
+			Name: "ARG", ProgramFn: arg,
+			Signature:   "ARG(n: INTEGER) RETURNS STRING",
+			Description: "Returns the nth command-line argument passed to the program, counting from 1",
+		},
+		"ARGCOUNT": {
+			Name: "ARGCOUNT", ProgramFn: argCount,
+			Signature:   "ARGCOUNT() RETURNS INTEGER",
+			Description: "Returns the number of command-line arguments passed to the program",
+		},
+
+		// Object functions
+		"ISINSTANCE": {
+			Name: "ISINSTANCE", Fn: isInstance,
+			Signature:   "ISINSTANCE(obj, className: STRING) RETURNS BOOLEAN",
+			Description: "Returns TRUE if obj is an instance of className or a class that inherits from it",
+		},
+		"TYPEOF": {
+			Name: "TYPEOF", Fn: typeOf,
+			Signature:   "TYPEOF(value) RETURNS STRING",
+			Description: "Returns the name of value's type, such as \"INTEGER\" or \"ARRAY\", or its class name for an object",
+		},
+
+		// Collection functions
+		"CONTAINSKEY": {
+			Name: "CONTAINSKEY", Fn: containsKey,
+			Signature:   "CONTAINSKEY(d: DICTIONARY, key) RETURNS BOOLEAN",
+			Description: "Returns TRUE if key has been assigned a value in dictionary d",
+		},
+		"CONTAINS": {
+			Name: "CONTAINS", Fn: contains,
+			Signature:   "CONTAINS(arr: ARRAY, value) RETURNS BOOLEAN",
+			Description: "Returns TRUE if any assigned element of arr equals value",
+		},
+		"FILL": {
+			Name: "FILL", Fn: fill,
+			Signature:   "FILL(arr: ARRAY, value)",
+			Description: "Sets every element of arr, within its declared bounds, to value",
+		},
+
+		// Testing functions
+		"ASSERT": {
+			Name: "ASSERT", Fn: assert,
+			Signature:   "ASSERT(condition: BOOLEAN, message: STRING)",
+			Description: "Stops the program with message as an error if condition is FALSE",
+		},
 
 		// File function
-		"EOF": {Name: "EOF", Fn: eof},
+		"EOF": {
+			Name: "EOF", Fn: eof,
+			Signature:   "EOF(filename: STRING) RETURNS BOOLEAN",
+			Description: "Returns TRUE if the end of the file has been reached",
+		},
 
 		// Math functions (additional)
-		"ABS":  {Name: "ABS", Fn: abs},
-		"SQRT": {Name: "SQRT", Fn: sqrt},
-		"POW":  {Name: "POW", Fn: pow},
+		"ABS": {
+			Name: "ABS", Fn: abs,
+			Signature:   "ABS(n: REAL) RETURNS REAL",
+			Description: "Returns the absolute value of n",
+		},
+		"SQRT": {
+			Name: "SQRT", Fn: sqrt,
+			Signature:   "SQRT(n: REAL) RETURNS REAL",
+			Description: "Returns the square root of n",
+		},
+		"POW": {
+			Name: "POW", Fn: pow,
+			Signature:   "POW(base: REAL, exp: REAL) RETURNS REAL",
+			Description: "Returns base raised to the power exp",
+		},
 
 		// Date functions
-		"DAY":      {Name: "DAY", Fn: day},
-		"MONTH":    {Name: "MONTH", Fn: month},
-		"YEAR":     {Name: "YEAR", Fn: year},
-		"DAYINDEX": {Name: "DAYINDEX", Fn: dayIndex},
-		"SETDATE":  {Name: "SETDATE", Fn: setDate},
-		"TODAY":    {Name: "TODAY", Fn: today},
+		"DAY": {
+			Name: "DAY", Fn: day,
+			Signature:   "DAY(ThisDate: DATE) RETURNS INTEGER",
+			Description: "Returns the day number from ThisDate",
+		},
+		"MONTH": {
+			Name: "MONTH", Fn: month,
+			Signature:   "MONTH(ThisDate: DATE) RETURNS INTEGER",
+			Description: "Returns the month number from ThisDate",
+		},
+		"YEAR": {
+			Name: "YEAR", Fn: year,
+			Signature:   "YEAR(ThisDate: DATE) RETURNS INTEGER",
+			Description: "Returns the year number from ThisDate",
+		},
+		"DAYINDEX": {
+			Name: "DAYINDEX", Fn: dayIndex,
+			Signature:   "DAYINDEX(ThisDate: DATE) RETURNS INTEGER",
+			Description: "Returns the day of the week of ThisDate, where Sunday is 1",
+		},
+		"ADDDAYS": {
+			Name: "ADDDAYS", Fn: addDays,
+			Signature:   "ADDDAYS(ThisDate: DATE, Days: INTEGER) RETURNS DATE",
+			Description: "Returns the date Days days after ThisDate, or before it if Days is negative",
+		},
+		"DATEDIFF": {
+			Name: "DATEDIFF", Fn: dateDiff,
+			Signature:   "DATEDIFF(Date1: DATE, Date2: DATE) RETURNS INTEGER",
+			Description: "Returns the number of days from Date1 to Date2, negative if Date2 is earlier",
+		},
+		"DATE_TO_STR": {
+			Name: "DATE_TO_STR", Fn: dateToStr,
+			Signature:   "DATE_TO_STR(ThisDate: DATE [, Format: STRING]) RETURNS STRING",
+			Description: "Returns ThisDate as DD/MM/YYYY, or in Format, where DD, MM, YYYY and YY are replaced by the day, month and year",
+		},
+		"SETDATE": {
+			Name: "SETDATE", Fn: setDate,
+			Signature:   "SETDATE(Day: INTEGER, Month: INTEGER, Year: INTEGER) RETURNS DATE",
+			Description: "Returns a DATE with the value Day/Month/Year",
+		},
+		"TODAY": {
+			Name: "TODAY", Fn: today,
+			Signature:   "TODAY() RETURNS DATE",
+			Description: "Returns the current date",
+		},
 	}
 }
 
@@ -71,7 +328,7 @@ func length(args ...interpreter.Object) interpreter.Object {
 
 	switch arg := args[0].(type) {
 	case *interpreter.String:
-		return &interpreter.Integer{Value: int64(len(arg.Value))}
+		return &interpreter.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 	default:
 		return newError("LENGTH requires STRING argument, got %s", args[0].Type())
 	}
@@ -97,11 +354,12 @@ func left(args ...interpreter.Object) interpreter.Object {
 		return newError("LEFT: length cannot be negative")
 	}
 
-	if int(n.Value) >= len(str.Value) {
+	runes := []rune(str.Value)
+	if n.Value >= int64(len(runes)) {
 		return &interpreter.String{Value: str.Value}
 	}
 
-	return &interpreter.String{Value: str.Value[:n.Value]}
+	return &interpreter.String{Value: string(runes[:n.Value])}
 }
 
 // RIGHT(s, n) - returns rightmost n characters
@@ -124,12 +382,12 @@ func right(args ...interpreter.Object) interpreter.Object {
 		return newError("RIGHT: length cannot be negative")
 	}
 
-	length := len(str.Value)
-	if int(n.Value) >= length {
+	runes := []rune(str.Value)
+	if n.Value >= int64(len(runes)) {
 		return &interpreter.String{Value: str.Value}
 	}
 
-	return &interpreter.String{Value: str.Value[length-int(n.Value):]}
+	return &interpreter.String{Value: string(runes[int64(len(runes))-n.Value:])}
 }
 
 // MID(s, start, length) - returns substring starting at position start with given length
@@ -154,23 +412,28 @@ func mid(args ...interpreter.Object) interpreter.Object {
 		return newError("MID requires INTEGER as third argument")
 	}
 
+	if length.Value < 0 {
+		return newError("MID: length cannot be negative")
+	}
+
 	// Convert to 0-based indexing
-	startIdx := int(start.Value) - 1
+	startIdx := start.Value - 1
 	if startIdx < 0 {
 		startIdx = 0
 	}
 
-	strLen := len(str.Value)
+	runes := []rune(str.Value)
+	strLen := int64(len(runes))
 	if startIdx >= strLen {
 		return &interpreter.String{Value: ""}
 	}
 
-	endIdx := startIdx + int(length.Value)
-	if endIdx > strLen {
+	endIdx := startIdx + length.Value
+	if endIdx > strLen || endIdx < startIdx {
 		endIdx = strLen
 	}
 
-	return &interpreter.String{Value: str.Value[startIdx:endIdx]}
+	return &interpreter.String{Value: string(runes[startIdx:endIdx])}
 }
 
 // LCASE(c) - converts character to lowercase
@@ -233,6 +496,129 @@ func toLower(args ...interpreter.Object) interpreter.Object {
 	return &interpreter.String{Value: strings.ToLower(str.Value)}
 }
 
+// EQUALS_IGNORE_CASE(a, b) - returns TRUE if the strings are equal ignoring case
+func equalsIgnoreCase(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("EQUALS_IGNORE_CASE requires 2 arguments, got %d", len(args))
+	}
+
+	a, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("EQUALS_IGNORE_CASE requires STRING as first argument")
+	}
+
+	b, ok := args[1].(*interpreter.String)
+	if !ok {
+		return newError("EQUALS_IGNORE_CASE requires STRING as second argument")
+	}
+
+	return &interpreter.Boolean{Value: strings.EqualFold(a.Value, b.Value)}
+}
+
+// COMPARE_IGNORE_CASE(a, b) - compares strings ignoring case, returning
+// -1 if a < b, 0 if equal and 1 if a > b
+func compareIgnoreCase(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("COMPARE_IGNORE_CASE requires 2 arguments, got %d", len(args))
+	}
+
+	a, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("COMPARE_IGNORE_CASE requires STRING as first argument")
+	}
+
+	b, ok := args[1].(*interpreter.String)
+	if !ok {
+		return newError("COMPARE_IGNORE_CASE requires STRING as second argument")
+	}
+
+	result := strings.Compare(strings.ToLower(a.Value), strings.ToLower(b.Value))
+	return &interpreter.Integer{Value: int64(result)}
+}
+
+// COMPARE(a, b) - compares strings by character code, returning -1 if
+// a < b, 0 if equal and 1 if a > b
+func compare(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("COMPARE requires 2 arguments, got %d", len(args))
+	}
+
+	a, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("COMPARE requires STRING as first argument")
+	}
+
+	b, ok := args[1].(*interpreter.String)
+	if !ok {
+		return newError("COMPARE requires STRING as second argument")
+	}
+
+	return &interpreter.Integer{Value: int64(strings.Compare(a.Value, b.Value))}
+}
+
+// maxPadding caps the spaces TAB, PAD_LEFT and PAD_RIGHT may produce, so a
+// huge count gives an error instead of exhausting memory
+const maxPadding = 1000000
+
+// TAB(n) - returns n spaces
+func tab(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("TAB requires 1 argument, got %d", len(args))
+	}
+
+	n, ok := args[0].(*interpreter.Integer)
+	if !ok {
+		return newError("TAB requires INTEGER argument, got %s", args[0].Type())
+	}
+
+	if n.Value < 0 {
+		return newError("TAB: count cannot be negative")
+	}
+	if n.Value > maxPadding {
+		return newError("TAB: count cannot be more than %d, got %d", maxPadding, n.Value)
+	}
+
+	return &interpreter.String{Value: strings.Repeat(" ", int(n.Value))}
+}
+
+// PAD_LEFT(s, width) - right-aligns s in a field of width characters
+func padLeft(args ...interpreter.Object) interpreter.Object {
+	return pad("PAD_LEFT", args, func(s, spaces string) string { return spaces + s })
+}
+
+// PAD_RIGHT(s, width) - left-aligns s in a field of width characters
+func padRight(args ...interpreter.Object) interpreter.Object {
+	return pad("PAD_RIGHT", args, func(s, spaces string) string { return s + spaces })
+}
+
+// pad adds the spaces needed to make the STRING argument as long as the
+// width argument, leaving strings already that long unchanged
+func pad(name string, args []interpreter.Object, join func(s, spaces string) string) interpreter.Object {
+	if len(args) != 2 {
+		return newError("%s requires 2 arguments, got %d", name, len(args))
+	}
+
+	str, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("%s requires STRING as first argument, got %s", name, args[0].Type())
+	}
+
+	width, ok := args[1].(*interpreter.Integer)
+	if !ok {
+		return newError("%s requires INTEGER as second argument, got %s", name, args[1].Type())
+	}
+
+	if width.Value > maxPadding {
+		return newError("%s: width cannot be more than %d, got %d", name, maxPadding, width.Value)
+	}
+
+	missing := int(width.Value) - utf8.RuneCountInString(str.Value)
+	if missing <= 0 {
+		return &interpreter.String{Value: str.Value}
+	}
+	return &interpreter.String{Value: join(str.Value, strings.Repeat(" ", missing))}
+}
+
 // ASC(c) - returns ASCII value of character
 func asc(args ...interpreter.Object) interpreter.Object {
 	if len(args) != 1 {
@@ -246,12 +632,51 @@ func asc(args ...interpreter.Object) interpreter.Object {
 		if len(arg.Value) == 0 {
 			return newError("ASC: empty string")
 		}
-		return &interpreter.Integer{Value: int64(arg.Value[0])}
+		r, _ := utf8.DecodeRuneInString(arg.Value)
+		return &interpreter.Integer{Value: int64(r)}
 	default:
 		return newError("ASC requires CHAR or STRING argument")
 	}
 }
 
+// IS_ALPHA(s) - returns TRUE if every character of s is a letter
+func isAlpha(args ...interpreter.Object) interpreter.Object {
+	return allChars("IS_ALPHA", unicode.IsLetter, args)
+}
+
+// IS_DIGIT(c) - returns TRUE if every character of c is a decimal digit
+func isDigit(args ...interpreter.Object) interpreter.Object {
+	return allChars("IS_DIGIT", unicode.IsDigit, args)
+}
+
+// allChars reports whether the CHAR or non-empty STRING argument consists
+// only of characters satisfying test
+func allChars(name string, test func(rune) bool, args []interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("%s requires 1 argument, got %d", name, len(args))
+	}
+
+	var s string
+	switch arg := args[0].(type) {
+	case *interpreter.Char:
+		s = string(arg.Value)
+	case *interpreter.String:
+		s = arg.Value
+	default:
+		return newError("%s requires CHAR or STRING argument, got %s", name, arg.Type())
+	}
+
+	if s == "" {
+		return &interpreter.Boolean{Value: false}
+	}
+	for _, r := range s {
+		if !test(r) {
+			return &interpreter.Boolean{Value: false}
+		}
+	}
+	return &interpreter.Boolean{Value: true}
+}
+
 // CHR(n) - returns character with given ASCII value
 func chr(args ...interpreter.Object) interpreter.Object {
 	if len(args) != 1 {
@@ -298,7 +723,7 @@ func randFunc(args ...interpreter.Object) interpreter.Object {
 		return newError("RAND requires numeric argument")
 	}
 
-	return &interpreter.Real{Value: rand.Float64() * max}
+	return &interpreter.Real{Value: rng.Float64() * max}
 }
 
 // RANDOM() - returns random real number from 0 to 1 (inclusive)
@@ -306,13 +731,38 @@ func random(args ...interpreter.Object) interpreter.Object {
 	if len(args) != 0 {
 		return newError("RANDOM requires 0 arguments, got %d", len(args))
 	}
-	return &interpreter.Real{Value: rand.Float64()}
+	return &interpreter.Real{Value: rng.Float64()}
 }
 
-// ROUND(x, places) - rounds to specified decimal places
+// RANDOMIZE(seed) - reseeds the random number generator so that
+// subsequent RAND/RANDOM calls produce a repeatable sequence
+func randomize(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("RANDOMIZE requires 1 argument, got %d", len(args))
+	}
+
+	seed, ok := args[0].(*interpreter.Integer)
+	if !ok {
+		return newError("RANDOMIZE requires INTEGER argument")
+	}
+
+	Seed(seed.Value)
+	return &interpreter.Null{}
+}
+
+// ROUND(x, places) - rounds to specified decimal places, half away from zero
 func round(args ...interpreter.Object) interpreter.Object {
+	return roundWith("ROUND", math.Round, args)
+}
+
+// ROUNDEVEN(x, places) - rounds to specified decimal places, half to even
+func roundEven(args ...interpreter.Object) interpreter.Object {
+	return roundWith("ROUNDEVEN", math.RoundToEven, args)
+}
+
+func roundWith(name string, roundFn func(float64) float64, args []interpreter.Object) interpreter.Object {
 	if len(args) != 2 {
-		return newError("ROUND requires 2 arguments, got %d", len(args))
+		return newError("%s requires 2 arguments, got %d", name, len(args))
 	}
 
 	var value float64
@@ -322,16 +772,16 @@ func round(args ...interpreter.Object) interpreter.Object {
 	case *interpreter.Integer:
 		value = float64(arg.Value)
 	default:
-		return newError("ROUND requires numeric first argument")
+		return newError("%s requires numeric first argument", name)
 	}
 
 	places, ok := args[1].(*interpreter.Integer)
 	if !ok {
-		return newError("ROUND requires INTEGER as second argument")
+		return newError("%s requires INTEGER as second argument", name)
 	}
 
 	multiplier := math.Pow(10, float64(places.Value))
-	rounded := math.Round(value*multiplier) / multiplier
+	rounded := roundFn(value*multiplier) / multiplier
 
 	return &interpreter.Real{Value: rounded}
 }
@@ -363,17 +813,308 @@ func strToNum(args ...interpreter.Object) interpreter.Object {
 		return newError("STR_TO_NUM requires STRING argument")
 	}
 
+	if num := parseNumber(str.Value); num != nil {
+		return num
+	}
+	return newError("STR_TO_NUM: cannot convert '%s' to number", str.Value)
+}
+
+// parseNumber converts s to an INTEGER, or failing that a REAL, returning nil
+// if it is not a decimal number
+func parseNumber(s string) interpreter.Object {
+	// ParseFloat also accepts hex floats such as 0x1p3 and the special
+	// values Inf and NaN, none of which are numbers here
+	if strings.ContainsFunc(s, func(r rune) bool { return !strings.ContainsRune("0123456789+-.eE", r) }) {
+		return nil
+	}
+
 	// Try to parse as integer first
-	if i, err := strconv.ParseInt(str.Value, 10, 64); err == nil {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
 		return &interpreter.Integer{Value: i}
 	}
 
 	// Try to parse as float
-	if f, err := strconv.ParseFloat(str.Value, 64); err == nil {
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
 		return &interpreter.Real{Value: f}
 	}
 
-	return newError("STR_TO_NUM: cannot convert '%s' to number", str.Value)
+	return nil
+}
+
+// IS_NUMERIC(s) - returns TRUE if STR_TO_NUM(s) would succeed
+func isNumeric(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("IS_NUMERIC requires 1 argument, got %d", len(args))
+	}
+
+	str, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("IS_NUMERIC requires STRING argument, got %s", args[0].Type())
+	}
+
+	return &interpreter.Boolean{Value: parseNumber(str.Value) != nil}
+}
+
+// PARSEINT(s, base) - converts a string of digits in base 2 to 36 to an
+// integer, e.g. PARSEINT("ff", 16) is 255
+func parseInt(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("PARSEINT requires 2 arguments, got %d", len(args))
+	}
+
+	str, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("PARSEINT requires STRING as first argument, got %s", args[0].Type())
+	}
+	base, ok := args[1].(*interpreter.Integer)
+	if !ok {
+		return newError("PARSEINT requires INTEGER as second argument, got %s", args[1].Type())
+	}
+	if base.Value < 2 || base.Value > 36 {
+		return newError("PARSEINT base must be between 2 and 36, got %d", base.Value)
+	}
+
+	n, err := strconv.ParseInt(str.Value, int(base.Value), 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return newError("PARSEINT: '%s' is too large for 64-bit", str.Value)
+		}
+		return newError("PARSEINT: '%s' is not a valid base %d number", str.Value, base.Value)
+	}
+	return &interpreter.Integer{Value: n}
+}
+
+// TO_BINARY(n) - returns n in base 2
+func toBinary(args ...interpreter.Object) interpreter.Object {
+	return formatInt("TO_BINARY", 2, args)
+}
+
+// TO_HEX(n) - returns n in base 16
+func toHex(args ...interpreter.Object) interpreter.Object {
+	return formatInt("TO_HEX", 16, args)
+}
+
+// formatInt writes an INTEGER in the given base. Negative numbers keep their
+// sign rather than being shown in two's complement, so TO_BINARY(-5) is
+// "-101".
+func formatInt(name string, base int, args []interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("%s requires 1 argument, got %d", name, len(args))
+	}
+
+	n, ok := args[0].(*interpreter.Integer)
+	if !ok {
+		return newError("%s requires INTEGER argument, got %s", name, args[0].Type())
+	}
+	return &interpreter.String{Value: strings.ToUpper(strconv.FormatInt(n.Value, base))}
+}
+
+// ARRAY_TO_STRING(arr) - joins the assigned elements of a 1D array of
+// characters or strings in index order
+func arrayToString(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("ARRAY_TO_STRING requires 1 argument, got %d", len(args))
+	}
+
+	arr, ok := args[0].(*interpreter.Array)
+	if !ok {
+		return newError("ARRAY_TO_STRING requires ARRAY argument")
+	}
+	if len(arr.Dimensions) != 1 {
+		return newError("ARRAY_TO_STRING requires a one-dimensional array")
+	}
+
+	var sb strings.Builder
+	dim := arr.Dimensions[0]
+	for idx := dim.Lower; idx <= dim.Upper; idx++ {
+		switch elem := arr.Elements[arr.GetIndex(int64(idx))].(type) {
+		case nil, *interpreter.Null:
+			// unassigned elements are skipped
+		case *interpreter.Char:
+			sb.WriteRune(elem.Value)
+		case *interpreter.String:
+			sb.WriteString(elem.Value)
+		default:
+			return newError("ARRAY_TO_STRING requires an array of CHAR or STRING, got %s at index %d", elem.Type(), idx)
+		}
+	}
+
+	return &interpreter.String{Value: sb.String()}
+}
+
+// STRING_TO_ARRAY(s) - returns an ARRAY[1:LENGTH(s)] OF CHAR holding the
+// characters of s
+func stringToArray(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("STRING_TO_ARRAY requires 1 argument, got %d", len(args))
+	}
+
+	str, ok := args[0].(*interpreter.String)
+	if !ok {
+		return newError("STRING_TO_ARRAY requires STRING argument")
+	}
+
+	runes := []rune(str.Value)
+	arr := &interpreter.Array{
+		Elements:    make(map[string]interpreter.Object, len(runes)),
+		Dimensions:  []ast.ArrayDimension{{Lower: 1, Upper: len(runes)}},
+		ElementType: &ast.PrimitiveType{Name: "CHAR"},
+	}
+	for idx, r := range runes {
+		arr.Elements[arr.GetIndex(int64(idx+1))] = &interpreter.Char{Value: r}
+	}
+
+	return arr
+}
+
+// ARG(n) - returns the nth program argument
+func arg(programArgs []string, args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("ARG requires 1 argument, got %d", len(args))
+	}
+
+	n, ok := args[0].(*interpreter.Integer)
+	if !ok {
+		return newError("ARG requires INTEGER argument")
+	}
+
+	if n.Value < 1 || n.Value > int64(len(programArgs)) {
+		return newError("ARG index %d out of range: %d arguments given", n.Value, len(programArgs))
+	}
+
+	return &interpreter.String{Value: programArgs[n.Value-1]}
+}
+
+// ARGCOUNT() - returns the number of program arguments
+func argCount(programArgs []string, args ...interpreter.Object) interpreter.Object {
+	if len(args) != 0 {
+		return newError("ARGCOUNT requires 0 arguments, got %d", len(args))
+	}
+
+	return &interpreter.Integer{Value: int64(len(programArgs))}
+}
+
+// ISINSTANCE(obj, className) - returns TRUE if obj's class is className or
+// inherits from it. A NULL object is not an instance of any class.
+func isInstance(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("ISINSTANCE requires 2 arguments, got %d", len(args))
+	}
+
+	className, ok := args[1].(*interpreter.String)
+	if !ok {
+		return newError("ISINSTANCE requires STRING class name as second argument")
+	}
+
+	switch obj := args[0].(type) {
+	case *interpreter.Null:
+		return &interpreter.Boolean{Value: false}
+	case *interpreter.Instance:
+		for class := obj.Class; class != nil; class = class.Parent {
+			if class.Name == className.Value {
+				return &interpreter.Boolean{Value: true}
+			}
+		}
+		return &interpreter.Boolean{Value: false}
+	default:
+		return newError("ISINSTANCE requires an object as first argument, got %s", obj.Type())
+	}
+}
+
+// TYPEOF(value) - returns the type name of value, or the class name of an
+// object
+func typeOf(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 {
+		return newError("TYPEOF requires 1 argument, got %d", len(args))
+	}
+
+	if obj, ok := args[0].(*interpreter.Instance); ok {
+		return &interpreter.String{Value: obj.Class.Name}
+	}
+	return &interpreter.String{Value: string(args[0].Type())}
+}
+
+// CONTAINSKEY(d, key) - returns TRUE if key is in the dictionary. The key
+// must be of the dictionary's key type.
+func containsKey(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("CONTAINSKEY requires 2 arguments, got %d", len(args))
+	}
+
+	dict, ok := args[0].(*interpreter.Dictionary)
+	if !ok {
+		return newError("CONTAINSKEY requires DICTIONARY as first argument, got %s", args[0].Type())
+	}
+
+	key, ok := dict.Key(args[1])
+	if !ok {
+		return newError("CONTAINSKEY key must be %s, got %s", dict.KeyType, args[1].Type())
+	}
+	_, found := dict.Entries[key]
+	return &interpreter.Boolean{Value: found}
+}
+
+// CONTAINS(arr, value) - returns TRUE if an assigned element of arr equals
+// value. Elements that have never been assigned are not compared.
+func contains(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("CONTAINS requires 2 arguments, got %d", len(args))
+	}
+
+	arr, ok := args[0].(*interpreter.Array)
+	if !ok {
+		return newError("CONTAINS requires ARRAY as first argument, got %s", args[0].Type())
+	}
+
+	for _, elem := range arr.Elements {
+		if interpreter.ObjectsEqual(elem, args[1]) {
+			return &interpreter.Boolean{Value: true}
+		}
+	}
+	return &interpreter.Boolean{Value: false}
+}
+
+// FILL(arr, value) - sets every element of arr to a copy of value
+func fill(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("FILL requires 2 arguments, got %d", len(args))
+	}
+
+	arr, ok := args[0].(*interpreter.Array)
+	if !ok {
+		return newError("FILL requires ARRAY as first argument, got %s", args[0].Type())
+	}
+
+	value := args[1]
+	if arr.ElementType != nil {
+		converted, ok := interpreter.ConvertValue(arr.ElementType, value)
+		if rec, isRecord := value.(*interpreter.Record); isRecord {
+			custom, isCustom := arr.ElementType.(*ast.CustomType)
+			ok = isCustom && custom.Name == rec.TypeName
+		} else if arr.RecordElements {
+			ok = false
+		}
+		if !ok {
+			return newError("FILL: cannot fill ARRAY OF %s with %s", arr.ElementType, value.Type())
+		}
+		value = converted
+	}
+
+	indices := make([]int64, len(arr.Dimensions))
+	var fillFrom func(dim int)
+	fillFrom = func(dim int) {
+		if dim == len(arr.Dimensions) {
+			arr.Elements[arr.GetIndex(indices...)] = interpreter.CopyObject(value)
+			return
+		}
+		for idx := arr.Dimensions[dim].Lower; idx <= arr.Dimensions[dim].Upper; idx++ {
+			indices[dim] = int64(idx)
+			fillFrom(dim + 1)
+		}
+	}
+	fillFrom(0)
+
+	return &interpreter.Null{}
 }
 
 // EOF(filename) - checks if at end of file
@@ -386,6 +1127,28 @@ func eof(args ...interpreter.Object) interpreter.Object {
 	return &interpreter.Boolean{Value: true}
 }
 
+// ASSERT(condition, message) - does nothing if condition is TRUE, otherwise
+// fails with message
+func assert(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("ASSERT requires 2 arguments, got %d", len(args))
+	}
+
+	condition, ok := args[0].(*interpreter.Boolean)
+	if !ok {
+		return newError("ASSERT requires BOOLEAN as first argument, got %s", args[0].Type())
+	}
+	message, ok := args[1].(*interpreter.String)
+	if !ok {
+		return newError("ASSERT requires STRING as second argument, got %s", args[1].Type())
+	}
+
+	if !condition.Value {
+		return newError("assertion failed: %s", message.Value)
+	}
+	return &interpreter.Null{}
+}
+
 // ABS(n) - returns absolute value
 func abs(args ...interpreter.Object) interpreter.Object {
 	if len(args) != 1 {
@@ -511,8 +1274,7 @@ func dayIndex(args ...interpreter.Object) interpreter.Object {
 		return newError("DAYINDEX requires DATE argument, got %s", args[0].Type())
 	}
 
-	// Create a Go time.Time from the date components
-	t := time.Date(date.Year, time.Month(date.Month), date.Day, 0, 0, 0, 0, time.UTC)
+	t := dateTime(date)
 	// Go's Weekday: Sunday = 0, Monday = 1, ..., Saturday = 6
 	// Cambridge wants: Sunday = 1, Monday = 2, ..., Saturday = 7
 	dayOfWeek := int(t.Weekday()) + 1
@@ -520,6 +1282,93 @@ func dayIndex(args ...interpreter.Object) interpreter.Object {
 	return &interpreter.Integer{Value: int64(dayOfWeek)}
 }
 
+// ADDDAYS(ThisDate, Days) - returns the date Days days after ThisDate
+func addDays(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("ADDDAYS requires 2 arguments, got %d", len(args))
+	}
+
+	date, ok := args[0].(*interpreter.Date)
+	if !ok {
+		return newError("ADDDAYS requires DATE as first argument, got %s", args[0].Type())
+	}
+	days, ok := args[1].(*interpreter.Integer)
+	if !ok {
+		return newError("ADDDAYS requires INTEGER as second argument, got %s", args[1].Type())
+	}
+	if !validDate(date) {
+		return newError("ADDDAYS: %s is not a valid date", date.Inspect())
+	}
+
+	t := dateTime(date).AddDate(0, 0, int(days.Value))
+	return &interpreter.Date{Day: t.Day(), Month: int(t.Month()), Year: t.Year()}
+}
+
+// DATEDIFF(Date1, Date2) - returns the number of days from Date1 to Date2
+func dateDiff(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 2 {
+		return newError("DATEDIFF requires 2 arguments, got %d", len(args))
+	}
+
+	var dates [2]*interpreter.Date
+	for idx, arg := range args {
+		date, ok := arg.(*interpreter.Date)
+		if !ok {
+			return newError("DATEDIFF requires DATE arguments, got %s", arg.Type())
+		}
+		if !validDate(date) {
+			return newError("DATEDIFF: %s is not a valid date", date.Inspect())
+		}
+		dates[idx] = date
+	}
+
+	// Both times are midnight UTC, so the difference is a whole number of
+	// days. Unix seconds are used because time.Duration only spans about 292
+	// years.
+	diff := dateTime(dates[1]).Unix() - dateTime(dates[0]).Unix()
+	return &interpreter.Integer{Value: diff / 86400}
+}
+
+// DATE_TO_STR(ThisDate [, Format]) - returns ThisDate as a string
+func dateToStr(args ...interpreter.Object) interpreter.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("DATE_TO_STR requires 1 or 2 arguments, got %d", len(args))
+	}
+
+	date, ok := args[0].(*interpreter.Date)
+	if !ok {
+		return newError("DATE_TO_STR requires DATE as first argument, got %s", args[0].Type())
+	}
+	if len(args) == 1 {
+		return &interpreter.String{Value: date.Inspect()}
+	}
+
+	format, ok := args[1].(*interpreter.String)
+	if !ok {
+		return newError("DATE_TO_STR requires STRING as second argument, got %s", args[1].Type())
+	}
+
+	// YYYY is listed before YY so that it is matched first
+	r := strings.NewReplacer(
+		"DD", fmt.Sprintf("%02d", date.Day),
+		"MM", fmt.Sprintf("%02d", date.Month),
+		"YYYY", fmt.Sprintf("%04d", date.Year),
+		"YY", fmt.Sprintf("%02d", date.Year%100),
+	)
+	return &interpreter.String{Value: r.Replace(format.Value)}
+}
+
+// dateTime returns midnight UTC on the given date
+func dateTime(date *interpreter.Date) time.Time {
+	return time.Date(date.Year, time.Month(date.Month), date.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// validDate reports whether a date names a real day, rejecting e.g. 31/02
+func validDate(date *interpreter.Date) bool {
+	t := dateTime(date)
+	return t.Day() == date.Day && int(t.Month()) == date.Month && t.Year() == date.Year
+}
+
 // SETDATE(Day, Month, Year) - returns a DATE with the value Day/Month/Year
 func setDate(args ...interpreter.Object) interpreter.Object {
 	if len(args) != 3 {
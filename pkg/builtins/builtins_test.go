@@ -1,11 +1,30 @@
 package builtins
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
 	"github.com/andrinoff/cambridge-lang/pkg/interpreter"
 )
 
+func TestBuiltinsDocumented(t *testing.T) {
+	for key, b := range GetBuiltins() {
+		if b.Name != key {
+			t.Errorf("builtin registered as %s has Name %s", key, b.Name)
+		}
+		if b.Signature == "" {
+			t.Errorf("builtin %s has no Signature", key)
+		} else if !strings.HasPrefix(b.Signature, key+"(") {
+			t.Errorf("builtin %s has Signature %q not starting with its name", key, b.Signature)
+		}
+		if b.Description == "" {
+			t.Errorf("builtin %s has no Description", key)
+		}
+	}
+}
+
 func TestLength(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -15,6 +34,7 @@ func TestLength(t *testing.T) {
 		{"", 0},
 		{"Hello World", 11},
 		{"123", 3},
+		{"naïve", 5},
 	}
 
 	builtins := GetBuiltins()
@@ -66,6 +86,7 @@ func TestLeft(t *testing.T) {
 		{"Hello", 0, ""},
 		{"Hello", 5, "Hello"},
 		{"Hello", 10, "Hello"},
+		{"naïve", 3, "naï"},
 	}
 
 	builtins := GetBuiltins()
@@ -106,6 +127,7 @@ func TestRight(t *testing.T) {
 		{"Hello", 0, ""},
 		{"Hello", 5, "Hello"},
 		{"Hello", 10, "Hello"},
+		{"naïve", 3, "ïve"},
 	}
 
 	builtins := GetBuiltins()
@@ -149,6 +171,8 @@ func TestMid(t *testing.T) {
 		{"Hello", 1, 10, "Hello"}, // Length exceeds string
 		{"Hello", 3, 2, "ll"},     // Middle portion
 		{"Hello", 10, 2, ""},      // Start beyond string
+		{"naïve", 3, 1, "ï"},      // Counts characters, not bytes
+		{"naïve", 4, 5, "ve"},
 	}
 
 	builtins := GetBuiltins()
@@ -173,6 +197,15 @@ func TestMid(t *testing.T) {
 	}
 }
 
+func TestMidNegativeLength(t *testing.T) {
+	result := GetBuiltins()["MID"].Fn(&interpreter.String{Value: "Hello"}, &interpreter.Integer{Value: 2}, &interpreter.Integer{Value: -1})
+
+	errObj, ok := result.(*interpreter.Error)
+	if !ok || errObj.Message != "MID: length cannot be negative" {
+		t.Errorf("expected negative length error, got %+v", result)
+	}
+}
+
 func TestLcase(t *testing.T) {
 	tests := []struct {
 		input    interface{}
@@ -283,6 +316,106 @@ func TestToLower(t *testing.T) {
 	}
 }
 
+func TestEqualsIgnoreCase(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected bool
+	}{
+		{"Hello", "hELLO", true},
+		{"abc", "ABC", true},
+		{"", "", true},
+		{"Hello", "World", false},
+		{"abc", "abcd", false},
+	}
+
+	builtins := GetBuiltins()
+	equalsFn := builtins["EQUALS_IGNORE_CASE"]
+
+	for _, tt := range tests {
+		result := equalsFn.Fn(&interpreter.String{Value: tt.a}, &interpreter.String{Value: tt.b})
+
+		boolResult, ok := result.(*interpreter.Boolean)
+		if !ok {
+			t.Fatalf("expected Boolean, got %T", result)
+		}
+
+		if boolResult.Value != tt.expected {
+			t.Errorf("EQUALS_IGNORE_CASE(%q, %q) = %t, want %t", tt.a, tt.b, boolResult.Value, tt.expected)
+		}
+	}
+}
+
+func TestCompareIgnoreCase(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int64
+	}{
+		{"apple", "APPLE", 0},
+		{"Apple", "banana", -1},
+		{"apple", "Banana", -1},
+		{"Cherry", "banana", 1},
+		{"abc", "ab", 1},
+	}
+
+	builtins := GetBuiltins()
+	compareFn := builtins["COMPARE_IGNORE_CASE"]
+
+	for _, tt := range tests {
+		result := compareFn.Fn(&interpreter.String{Value: tt.a}, &interpreter.String{Value: tt.b})
+
+		intResult, ok := result.(*interpreter.Integer)
+		if !ok {
+			t.Fatalf("expected Integer, got %T", result)
+		}
+
+		if intResult.Value != tt.expected {
+			t.Errorf("COMPARE_IGNORE_CASE(%q, %q) = %d, want %d", tt.a, tt.b, intResult.Value, tt.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int64
+	}{
+		{"apple", "apple", 0},
+		{"apple", "APPLE", 1},
+		{"Apple", "apple", -1},
+		{"ab", "abc", -1},
+	}
+
+	compareFn := GetBuiltins()["COMPARE"]
+
+	for _, tt := range tests {
+		result := compareFn.Fn(&interpreter.String{Value: tt.a}, &interpreter.String{Value: tt.b})
+
+		intResult, ok := result.(*interpreter.Integer)
+		if !ok {
+			t.Fatalf("expected Integer, got %T", result)
+		}
+
+		if intResult.Value != tt.expected {
+			t.Errorf("COMPARE(%q, %q) = %d, want %d", tt.a, tt.b, intResult.Value, tt.expected)
+		}
+	}
+}
+
+func TestCompareIgnoreCaseWrongArgType(t *testing.T) {
+	builtins := GetBuiltins()
+
+	for _, name := range []string{"EQUALS_IGNORE_CASE", "COMPARE_IGNORE_CASE", "COMPARE"} {
+		result := builtins[name].Fn(&interpreter.String{Value: "a"}, &interpreter.Integer{Value: 1})
+
+		if _, ok := result.(*interpreter.Error); !ok {
+			t.Errorf("%s: expected Error for wrong arg type, got %T", name, result)
+		}
+	}
+}
+
 func TestAsc(t *testing.T) {
 	tests := []struct {
 		input    interpreter.Object
@@ -293,6 +426,7 @@ func TestAsc(t *testing.T) {
 		{&interpreter.Char{Value: '0'}, 48},
 		{&interpreter.String{Value: "A"}, 65},
 		{&interpreter.String{Value: "Hello"}, 72}, // First character
+		{&interpreter.String{Value: "ïve"}, 239},
 	}
 
 	builtins := GetBuiltins()
@@ -417,6 +551,45 @@ func TestRandom(t *testing.T) {
 	}
 }
 
+func TestRandomize(t *testing.T) {
+	builtins := GetBuiltins()
+	randomizeFn := builtins["RANDOMIZE"]
+	randFn := builtins["RAND"]
+
+	sequence := func() []float64 {
+		result := randomizeFn.Fn(&interpreter.Integer{Value: 42})
+		if _, ok := result.(*interpreter.Null); !ok {
+			t.Fatalf("expected Null, got %T", result)
+		}
+
+		values := make([]float64, 5)
+		for i := range values {
+			values[i] = randFn.Fn(&interpreter.Integer{Value: 100}).(*interpreter.Real).Value
+		}
+		return values
+	}
+
+	first := sequence()
+	second := sequence()
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("RAND sequence differs after reseeding at %d: %f != %f", i, first[i], second[i])
+		}
+	}
+}
+
+func TestRandomizeWrongArgType(t *testing.T) {
+	builtins := GetBuiltins()
+	randomizeFn := builtins["RANDOMIZE"]
+
+	result := randomizeFn.Fn(&interpreter.String{Value: "42"})
+
+	if _, ok := result.(*interpreter.Error); !ok {
+		t.Errorf("expected Error for wrong arg type, got %T", result)
+	}
+}
+
 func TestRound(t *testing.T) {
 	tests := []struct {
 		value    float64
@@ -452,6 +625,37 @@ func TestRound(t *testing.T) {
 	}
 }
 
+func TestRoundEven(t *testing.T) {
+	tests := []struct {
+		value    float64
+		places   int64
+		round    float64
+		expected float64
+	}{
+		{2.5, 0, 3.0, 2.0},
+		{3.5, 0, 4.0, 4.0},
+		{-2.5, 0, -3.0, -2.0},
+		{2.6, 0, 3.0, 3.0},
+		{1.25, 1, 1.3, 1.2},
+	}
+
+	builtins := GetBuiltins()
+
+	for _, tt := range tests {
+		args := []interpreter.Object{
+			&interpreter.Real{Value: tt.value},
+			&interpreter.Integer{Value: tt.places},
+		}
+
+		if r := builtins["ROUND"].Fn(args...).(*interpreter.Real); r.Value != tt.round {
+			t.Errorf("ROUND(%v, %d) = %v, want %v", tt.value, tt.places, r.Value, tt.round)
+		}
+		if r := builtins["ROUNDEVEN"].Fn(args...).(*interpreter.Real); r.Value != tt.expected {
+			t.Errorf("ROUNDEVEN(%v, %d) = %v, want %v", tt.value, tt.places, r.Value, tt.expected)
+		}
+	}
+}
+
 func TestAbs(t *testing.T) {
 	tests := []struct {
 		input    interpreter.Object
@@ -628,6 +832,59 @@ func TestStrToNum(t *testing.T) {
 	}
 }
 
+func TestStringValidation(t *testing.T) {
+	str := func(s string) interpreter.Object { return &interpreter.String{Value: s} }
+
+	tests := []struct {
+		name     string
+		arg      interpreter.Object
+		expected bool
+	}{
+		{"IS_NUMERIC", str("42"), true},
+		{"IS_NUMERIC", str("-3.5"), true},
+		{"IS_NUMERIC", str(""), false},
+		{"IS_NUMERIC", str("12abc"), false},
+		{"IS_NUMERIC", str(" 42"), false},
+		{"IS_NUMERIC", str("1.5e3"), true},
+		{"IS_NUMERIC", str("NaN"), false},
+		{"IS_NUMERIC", str("Inf"), false},
+		{"IS_NUMERIC", str("-infinity"), false},
+		{"IS_NUMERIC", str("0x1p3"), false},
+		{"IS_NUMERIC", str("0x10"), false},
+		{"IS_NUMERIC", str("1e400"), false},
+		{"IS_ALPHA", str("Hello"), true},
+		{"IS_ALPHA", str("Hello1"), false},
+		{"IS_ALPHA", str("two words"), false},
+		{"IS_ALPHA", str(""), false},
+		{"IS_ALPHA", &interpreter.Char{Value: 'x'}, true},
+		{"IS_DIGIT", &interpreter.Char{Value: '7'}, true},
+		{"IS_DIGIT", &interpreter.Char{Value: 'a'}, false},
+		{"IS_DIGIT", str("2024"), true},
+		{"IS_DIGIT", str("-1"), false},
+		{"IS_DIGIT", str(""), false},
+	}
+
+	builtins := GetBuiltins()
+
+	for _, tt := range tests {
+		result := builtins[tt.name].Fn(tt.arg)
+
+		boolResult, ok := result.(*interpreter.Boolean)
+		if !ok {
+			t.Fatalf("%s(%s): expected Boolean, got %T (%+v)", tt.name, tt.arg.Inspect(), result, result)
+		}
+		if boolResult.Value != tt.expected {
+			t.Errorf("%s(%q) = %v, want %v", tt.name, tt.arg.Inspect(), boolResult.Value, tt.expected)
+		}
+	}
+
+	for _, name := range []string{"IS_NUMERIC", "IS_ALPHA", "IS_DIGIT"} {
+		if _, ok := builtins[name].Fn(&interpreter.Integer{Value: 1}).(*interpreter.Error); !ok {
+			t.Errorf("%s: expected error for INTEGER argument", name)
+		}
+	}
+}
+
 func TestStrToNumInvalid(t *testing.T) {
 	builtins := GetBuiltins()
 	strToNumFn := builtins["STR_TO_NUM"]
@@ -639,6 +896,515 @@ func TestStrToNumInvalid(t *testing.T) {
 	}
 }
 
+func TestArrayToStringErrors(t *testing.T) {
+	arrayToStringFn := GetBuiltins()["ARRAY_TO_STRING"]
+
+	matrix := &interpreter.Array{
+		Elements:   map[string]interpreter.Object{},
+		Dimensions: []ast.ArrayDimension{{Lower: 1, Upper: 2}, {Lower: 1, Upper: 2}},
+	}
+	numbers := &interpreter.Array{
+		Elements:   map[string]interpreter.Object{"1": &interpreter.Integer{Value: 7}},
+		Dimensions: []ast.ArrayDimension{{Lower: 1, Upper: 3}},
+	}
+
+	tests := []struct {
+		arg      interpreter.Object
+		expected string
+	}{
+		{&interpreter.String{Value: "abc"}, "ARRAY_TO_STRING requires ARRAY argument"},
+		{matrix, "ARRAY_TO_STRING requires a one-dimensional array"},
+		{numbers, "ARRAY_TO_STRING requires an array of CHAR or STRING, got INTEGER at index 1"},
+	}
+
+	for _, tt := range tests {
+		result := arrayToStringFn.Fn(tt.arg)
+		errObj, ok := result.(*interpreter.Error)
+		if !ok {
+			t.Fatalf("expected Error, got %T", result)
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestStringToArray(t *testing.T) {
+	stringToArrayFn := GetBuiltins()["STRING_TO_ARRAY"]
+
+	result := stringToArrayFn.Fn(&interpreter.String{Value: "Café"})
+	arr, ok := result.(*interpreter.Array)
+	if !ok {
+		t.Fatalf("expected Array, got %T", result)
+	}
+
+	if len(arr.Dimensions) != 1 || arr.Dimensions[0].Lower != 1 || arr.Dimensions[0].Upper != 4 {
+		t.Fatalf("expected dimensions [1:4], got %v", arr.Dimensions)
+	}
+	if len(arr.Elements) != 4 {
+		t.Errorf("expected 4 elements, got %d", len(arr.Elements))
+	}
+
+	first, ok := arr.Elements["1"].(*interpreter.Char)
+	if !ok || first.Value != 'C' {
+		t.Errorf("expected element 1 to be 'C', got %v", arr.Elements["1"])
+	}
+	last, ok := arr.Elements["4"].(*interpreter.Char)
+	if !ok || last.Value != 'é' {
+		t.Errorf("expected element 4 to be 'é', got %v", arr.Elements["4"])
+	}
+
+	if _, ok := stringToArrayFn.Fn(&interpreter.Integer{Value: 5}).(*interpreter.Error); !ok {
+		t.Error("expected error for non-string argument")
+	}
+}
+
+func TestArgs(t *testing.T) {
+	programArgs := []string{"alice", "42"}
+	b := GetBuiltins()
+
+	count, ok := b["ARGCOUNT"].ProgramFn(programArgs).(*interpreter.Integer)
+	if !ok || count.Value != 2 {
+		t.Fatalf("ARGCOUNT() = %v, want 2", count)
+	}
+
+	second, ok := b["ARG"].ProgramFn(programArgs, &interpreter.Integer{Value: 2}).(*interpreter.String)
+	if !ok || second.Value != "42" {
+		t.Errorf("ARG(2) = %v, want \"42\"", second)
+	}
+
+	for _, n := range []int64{0, 3} {
+		result := b["ARG"].ProgramFn(programArgs, &interpreter.Integer{Value: n})
+		errObj, ok := result.(*interpreter.Error)
+		if !ok {
+			t.Fatalf("ARG(%d): expected Error, got %T", n, result)
+		}
+		expected := fmt.Sprintf("ARG index %d out of range: 2 arguments given", n)
+		if errObj.Message != expected {
+			t.Errorf("wrong error. expected=%q, got=%q", expected, errObj.Message)
+		}
+	}
+}
+
+func TestIsInstance(t *testing.T) {
+	animal := &interpreter.Class{Name: "Animal"}
+	dog := &interpreter.Class{Name: "Dog", Parent: animal}
+	rex := &interpreter.Instance{Class: dog}
+
+	tests := []struct {
+		obj      interpreter.Object
+		class    string
+		expected bool
+	}{
+		{rex, "Dog", true},
+		{rex, "Animal", true},
+		{rex, "Cat", false},
+		{&interpreter.Instance{Class: animal}, "Dog", false},
+		{&interpreter.Null{}, "Dog", false},
+	}
+
+	isInstanceFn := GetBuiltins()["ISINSTANCE"]
+
+	for _, tt := range tests {
+		result := isInstanceFn.Fn(tt.obj, &interpreter.String{Value: tt.class})
+
+		boolResult, ok := result.(*interpreter.Boolean)
+		if !ok {
+			t.Fatalf("expected Boolean, got %T", result)
+		}
+		if boolResult.Value != tt.expected {
+			t.Errorf("ISINSTANCE(%s, %q) = %v, want %v", tt.obj.Inspect(), tt.class, boolResult.Value, tt.expected)
+		}
+	}
+
+	if _, ok := isInstanceFn.Fn(&interpreter.Integer{Value: 1}, &interpreter.String{Value: "Dog"}).(*interpreter.Error); !ok {
+		t.Error("expected error for non-object argument")
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		value    interpreter.Object
+		expected string
+	}{
+		{&interpreter.Integer{Value: 1}, "INTEGER"},
+		{&interpreter.Real{Value: 1.5}, "REAL"},
+		{&interpreter.String{Value: "a"}, "STRING"},
+		{&interpreter.Char{Value: 'a'}, "CHAR"},
+		{&interpreter.Boolean{Value: true}, "BOOLEAN"},
+		{&interpreter.Date{Day: 1, Month: 1, Year: 2024}, "DATE"},
+		{&interpreter.Array{}, "ARRAY"},
+		{&interpreter.Record{TypeName: "Point"}, "RECORD"},
+		{&interpreter.Set{}, "SET"},
+		{&interpreter.Null{}, "NULL"},
+		{&interpreter.Instance{Class: &interpreter.Class{Name: "Dog"}}, "Dog"},
+	}
+
+	typeOfFn := GetBuiltins()["TYPEOF"]
+
+	for _, tt := range tests {
+		result := typeOfFn.Fn(tt.value)
+
+		strResult, ok := result.(*interpreter.String)
+		if !ok {
+			t.Fatalf("expected String, got %T", result)
+		}
+		if strResult.Value != tt.expected {
+			t.Errorf("TYPEOF(%s) = %q, want %q", tt.value.Inspect(), strResult.Value, tt.expected)
+		}
+	}
+
+	if _, ok := typeOfFn.Fn().(*interpreter.Error); !ok {
+		t.Error("expected error for missing argument")
+	}
+}
+
+func TestFill(t *testing.T) {
+	fillFn := GetBuiltins()["FILL"]
+
+	list := &interpreter.Array{
+		Elements:    map[string]interpreter.Object{"2": &interpreter.Integer{Value: 9}},
+		Dimensions:  []ast.ArrayDimension{{Lower: 1, Upper: 3}},
+		ElementType: &ast.PrimitiveType{Name: "INTEGER"},
+	}
+	if errObj, ok := fillFn.Fn(list, &interpreter.Integer{Value: 0}).(*interpreter.Error); ok {
+		t.Fatalf("unexpected error: %s", errObj.Message)
+	}
+	if len(list.Elements) != 3 {
+		t.Errorf("expected 3 elements, got %d", len(list.Elements))
+	}
+	for _, key := range []string{"1", "2", "3"} {
+		if n, ok := list.Elements[key].(*interpreter.Integer); !ok || n.Value != 0 {
+			t.Errorf("element %s = %v, want 0", key, list.Elements[key])
+		}
+	}
+
+	grid := &interpreter.Array{
+		Elements:    make(map[string]interpreter.Object),
+		Dimensions:  []ast.ArrayDimension{{Lower: 1, Upper: 2}, {Lower: 0, Upper: 2}},
+		ElementType: &ast.PrimitiveType{Name: "REAL"},
+	}
+	if errObj, ok := fillFn.Fn(grid, &interpreter.Integer{Value: 2}).(*interpreter.Error); ok {
+		t.Fatalf("unexpected error: %s", errObj.Message)
+	}
+	if len(grid.Elements) != 6 {
+		t.Errorf("expected 6 elements, got %d", len(grid.Elements))
+	}
+	if r, ok := grid.Elements["2,0"].(*interpreter.Real); !ok || r.Value != 2 {
+		t.Errorf("element 2,0 = %v, want REAL 2", grid.Elements["2,0"])
+	}
+
+	people := &interpreter.Array{
+		Elements:       make(map[string]interpreter.Object),
+		Dimensions:     []ast.ArrayDimension{{Lower: 1, Upper: 2}},
+		ElementType:    &ast.CustomType{Name: "P"},
+		RecordElements: true,
+	}
+
+	errorTests := []struct {
+		args     []interpreter.Object
+		expected string
+	}{
+		{[]interpreter.Object{grid, &interpreter.String{Value: "x"}}, "FILL: cannot fill ARRAY OF REAL with STRING"},
+		{[]interpreter.Object{people, &interpreter.Integer{Value: 5}}, "FILL: cannot fill ARRAY OF P with INTEGER"},
+		{[]interpreter.Object{&interpreter.Integer{Value: 1}, &interpreter.Integer{Value: 0}}, "FILL requires ARRAY as first argument, got INTEGER"},
+		{[]interpreter.Object{grid}, "FILL requires 2 arguments, got 1"},
+	}
+	for _, tt := range errorTests {
+		errObj, ok := fillFn.Fn(tt.args...).(*interpreter.Error)
+		if !ok {
+			t.Errorf("expected error %q", tt.expected)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestPadding(t *testing.T) {
+	str := func(s string) interpreter.Object { return &interpreter.String{Value: s} }
+	num := func(n int64) interpreter.Object { return &interpreter.Integer{Value: n} }
+
+	tests := []struct {
+		name     string
+		args     []interpreter.Object
+		expected string
+	}{
+		{"TAB", []interpreter.Object{num(3)}, "   "},
+		{"TAB", []interpreter.Object{num(0)}, ""},
+		{"PAD_LEFT", []interpreter.Object{str("42"), num(5)}, "   42"},
+		{"PAD_RIGHT", []interpreter.Object{str("Ann"), num(5)}, "Ann  "},
+		{"PAD_LEFT", []interpreter.Object{str("café"), num(6)}, "  café"},
+		{"PAD_LEFT", []interpreter.Object{str("Elizabeth"), num(5)}, "Elizabeth"},
+		{"PAD_RIGHT", []interpreter.Object{str("Elizabeth"), num(5)}, "Elizabeth"},
+		{"PAD_RIGHT", []interpreter.Object{str("exact"), num(5)}, "exact"},
+		{"PAD_RIGHT", []interpreter.Object{str(""), num(2)}, "  "},
+	}
+
+	builtins := GetBuiltins()
+
+	for _, tt := range tests {
+		result := builtins[tt.name].Fn(tt.args...)
+
+		strResult, ok := result.(*interpreter.String)
+		if !ok {
+			t.Fatalf("%s: expected String, got %T (%+v)", tt.name, result, result)
+		}
+		if strResult.Value != tt.expected {
+			t.Errorf("%s = %q, want %q", tt.name, strResult.Value, tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		name     string
+		args     []interpreter.Object
+		expected string
+	}{
+		{"TAB", []interpreter.Object{num(-1)}, "TAB: count cannot be negative"},
+		{"TAB", []interpreter.Object{str("3")}, "TAB requires INTEGER argument, got STRING"},
+		{"PAD_LEFT", []interpreter.Object{num(42), num(5)}, "PAD_LEFT requires STRING as first argument, got INTEGER"},
+		{"PAD_RIGHT", []interpreter.Object{str("a")}, "PAD_RIGHT requires 2 arguments, got 1"},
+		{"TAB", []interpreter.Object{num(9223372036854775807)}, "TAB: count cannot be more than 1000000, got 9223372036854775807"},
+		{"PAD_LEFT", []interpreter.Object{str("a"), num(9223372036854775807)}, "PAD_LEFT: width cannot be more than 1000000, got 9223372036854775807"},
+		{"PAD_RIGHT", []interpreter.Object{str("a"), num(1000001)}, "PAD_RIGHT: width cannot be more than 1000000, got 1000001"},
+	}
+
+	for _, tt := range errorTests {
+		errObj, ok := builtins[tt.name].Fn(tt.args...).(*interpreter.Error)
+		if !ok {
+			t.Errorf("%s: expected error %q", tt.name, tt.expected)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestContainsKey(t *testing.T) {
+	dict := &interpreter.Dictionary{
+		Entries:   make(map[string]interpreter.Object),
+		KeyType:   &ast.PrimitiveType{Name: "STRING"},
+		ValueType: &ast.PrimitiveType{Name: "INTEGER"},
+	}
+	key, _ := dict.Key(&interpreter.String{Value: "a"})
+	dict.Entries[key] = &interpreter.Integer{Value: 1}
+
+	tests := []struct {
+		key      interpreter.Object
+		expected bool
+	}{
+		{&interpreter.String{Value: "a"}, true},
+		{&interpreter.Char{Value: 'a'}, true},
+		{&interpreter.String{Value: "b"}, false},
+	}
+
+	containsKeyFn := GetBuiltins()["CONTAINSKEY"]
+
+	for _, tt := range tests {
+		result := containsKeyFn.Fn(dict, tt.key)
+
+		boolResult, ok := result.(*interpreter.Boolean)
+		if !ok {
+			t.Fatalf("expected Boolean, got %T (%+v)", result, result)
+		}
+		if boolResult.Value != tt.expected {
+			t.Errorf("CONTAINSKEY(d, %s) = %v, want %v", tt.key.Inspect(), boolResult.Value, tt.expected)
+		}
+	}
+
+	errTests := []struct {
+		args     []interpreter.Object
+		expected string
+	}{
+		{[]interpreter.Object{dict, &interpreter.Integer{Value: 1}}, "CONTAINSKEY key must be STRING, got INTEGER"},
+		{[]interpreter.Object{&interpreter.String{Value: "a"}, &interpreter.String{Value: "a"}}, "CONTAINSKEY requires DICTIONARY as first argument, got STRING"},
+	}
+
+	for _, tt := range errTests {
+		errObj, ok := containsKeyFn.Fn(tt.args...).(*interpreter.Error)
+		if !ok {
+			t.Fatalf("expected error for %v", tt.args)
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		s        string
+		base     int64
+		expected int64
+	}{
+		{"1010", 2, 10},
+		{"-1010", 2, -10},
+		{"ff", 16, 255},
+		{"FF", 16, 255},
+		{"777", 8, 511},
+		{"z", 36, 35},
+		{"42", 10, 42},
+	}
+
+	parseIntFn := GetBuiltins()["PARSEINT"]
+
+	for _, tt := range tests {
+		result := parseIntFn.Fn(&interpreter.String{Value: tt.s}, &interpreter.Integer{Value: tt.base})
+		intResult, ok := result.(*interpreter.Integer)
+		if !ok {
+			t.Errorf("PARSEINT(%q, %d): expected Integer, got %T (%+v)", tt.s, tt.base, result, result)
+			continue
+		}
+		if intResult.Value != tt.expected {
+			t.Errorf("PARSEINT(%q, %d) = %d, want %d", tt.s, tt.base, intResult.Value, tt.expected)
+		}
+	}
+}
+
+func TestParseIntErrors(t *testing.T) {
+	tests := []struct {
+		args     []interpreter.Object
+		expected string
+	}{
+		{[]interpreter.Object{&interpreter.String{Value: "102"}, &interpreter.Integer{Value: 2}}, "PARSEINT: '102' is not a valid base 2 number"},
+		{[]interpreter.Object{&interpreter.String{Value: "fg"}, &interpreter.Integer{Value: 16}}, "PARSEINT: 'fg' is not a valid base 16 number"},
+		{[]interpreter.Object{&interpreter.String{Value: ""}, &interpreter.Integer{Value: 10}}, "PARSEINT: '' is not a valid base 10 number"},
+		{[]interpreter.Object{&interpreter.String{Value: "1"}, &interpreter.Integer{Value: 37}}, "PARSEINT base must be between 2 and 36, got 37"},
+		{[]interpreter.Object{&interpreter.String{Value: "ffffffffffffffffff"}, &interpreter.Integer{Value: 16}}, "PARSEINT: 'ffffffffffffffffff' is too large for 64-bit"},
+		{[]interpreter.Object{&interpreter.Integer{Value: 1}, &interpreter.Integer{Value: 2}}, "PARSEINT requires STRING as first argument, got INTEGER"},
+		{[]interpreter.Object{&interpreter.String{Value: "1"}}, "PARSEINT requires 2 arguments, got 1"},
+	}
+
+	parseIntFn := GetBuiltins()["PARSEINT"]
+
+	for _, tt := range tests {
+		result := parseIntFn.Fn(tt.args...)
+		errObj, ok := result.(*interpreter.Error)
+		if !ok {
+			t.Errorf("expected Error, got %T (%+v)", result, result)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestToBinaryAndHex(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int64
+		expected string
+	}{
+		{"TO_BINARY", 10, "1010"},
+		{"TO_BINARY", 0, "0"},
+		{"TO_BINARY", -5, "-101"},
+		{"TO_HEX", 255, "FF"},
+		{"TO_HEX", 4096, "1000"},
+		{"TO_HEX", -26, "-1A"},
+	}
+
+	builtins := GetBuiltins()
+	for _, tt := range tests {
+		result := builtins[tt.name].Fn(&interpreter.Integer{Value: tt.n})
+		strResult, ok := result.(*interpreter.String)
+		if !ok {
+			t.Errorf("%s(%d): expected String, got %T (%+v)", tt.name, tt.n, result, result)
+			continue
+		}
+		if strResult.Value != tt.expected {
+			t.Errorf("%s(%d) = %q, want %q", tt.name, tt.n, strResult.Value, tt.expected)
+		}
+	}
+
+	// The result parses back with PARSEINT
+	back := builtins["PARSEINT"].Fn(builtins["TO_HEX"].Fn(&interpreter.Integer{Value: -300}), &interpreter.Integer{Value: 16})
+	if n, ok := back.(*interpreter.Integer); !ok || n.Value != -300 {
+		t.Errorf("expected PARSEINT(TO_HEX(-300), 16) to be -300, got %+v", back)
+	}
+
+	result := builtins["TO_HEX"].Fn(&interpreter.Real{Value: 1.5})
+	errObj, ok := result.(*interpreter.Error)
+	if !ok || errObj.Message != "TO_HEX requires INTEGER argument, got REAL" {
+		t.Errorf("expected an error for a REAL argument, got %T (%+v)", result, result)
+	}
+}
+
+func TestAssert(t *testing.T) {
+	assertFn := GetBuiltins()["ASSERT"]
+
+	result := assertFn.Fn(&interpreter.Boolean{Value: true}, &interpreter.String{Value: "never shown"})
+	if _, ok := result.(*interpreter.Null); !ok {
+		t.Errorf("expected Null for a passing assertion, got %T (%+v)", result, result)
+	}
+
+	tests := []struct {
+		args     []interpreter.Object
+		expected string
+	}{
+		{[]interpreter.Object{&interpreter.Boolean{Value: false}, &interpreter.String{Value: "total is wrong"}}, "assertion failed: total is wrong"},
+		{[]interpreter.Object{&interpreter.Boolean{Value: true}}, "ASSERT requires 2 arguments, got 1"},
+		{[]interpreter.Object{&interpreter.Integer{Value: 1}, &interpreter.String{Value: "x"}}, "ASSERT requires BOOLEAN as first argument, got INTEGER"},
+		{[]interpreter.Object{&interpreter.Boolean{Value: false}, &interpreter.Integer{Value: 1}}, "ASSERT requires STRING as second argument, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		result := assertFn.Fn(tt.args...)
+		errObj, ok := result.(*interpreter.Error)
+		if !ok {
+			t.Errorf("expected Error, got %T (%+v)", result, result)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	arr := &interpreter.Array{
+		Elements: map[string]interpreter.Object{
+			"1": &interpreter.Integer{Value: 4},
+			"3": &interpreter.Integer{Value: 9},
+		},
+		Dimensions: []ast.ArrayDimension{{Lower: 1, Upper: 5}},
+	}
+
+	tests := []struct {
+		value    interpreter.Object
+		expected bool
+	}{
+		{&interpreter.Integer{Value: 9}, true},
+		{&interpreter.Integer{Value: 5}, false},
+		{&interpreter.String{Value: "9"}, false},
+	}
+
+	containsFn := GetBuiltins()["CONTAINS"]
+
+	for _, tt := range tests {
+		result := containsFn.Fn(arr, tt.value)
+
+		boolResult, ok := result.(*interpreter.Boolean)
+		if !ok {
+			t.Fatalf("expected Boolean, got %T (%+v)", result, result)
+		}
+		if boolResult.Value != tt.expected {
+			t.Errorf("CONTAINS(arr, %s) = %v, want %v", tt.value.Inspect(), boolResult.Value, tt.expected)
+		}
+	}
+
+	errObj, ok := containsFn.Fn(&interpreter.Integer{Value: 1}, &interpreter.Integer{Value: 1}).(*interpreter.Error)
+	if !ok {
+		t.Fatal("expected error for non-array argument")
+	}
+	expected := "CONTAINS requires ARRAY as first argument, got INTEGER"
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
 func TestEOF(t *testing.T) {
 	builtins := GetBuiltins()
 	eofFn := builtins["EOF"]
@@ -860,6 +1626,157 @@ func TestDayIndexWrongArgType(t *testing.T) {
 	}
 }
 
+func TestAddDays(t *testing.T) {
+	tests := []struct {
+		date     interpreter.Date
+		days     int64
+		expected interpreter.Date
+	}{
+		{interpreter.Date{Day: 4, Month: 10, Year: 2003}, 1, interpreter.Date{Day: 5, Month: 10, Year: 2003}},
+		{interpreter.Date{Day: 31, Month: 1, Year: 2023}, 1, interpreter.Date{Day: 1, Month: 2, Year: 2023}},
+		{interpreter.Date{Day: 31, Month: 12, Year: 2023}, 1, interpreter.Date{Day: 1, Month: 1, Year: 2024}},
+		{interpreter.Date{Day: 28, Month: 2, Year: 2024}, 1, interpreter.Date{Day: 29, Month: 2, Year: 2024}},
+		{interpreter.Date{Day: 28, Month: 2, Year: 2023}, 1, interpreter.Date{Day: 1, Month: 3, Year: 2023}},
+		{interpreter.Date{Day: 1, Month: 3, Year: 2024}, -1, interpreter.Date{Day: 29, Month: 2, Year: 2024}},
+		{interpreter.Date{Day: 1, Month: 1, Year: 2000}, 366, interpreter.Date{Day: 1, Month: 1, Year: 2001}},
+	}
+
+	addDaysFn := GetBuiltins()["ADDDAYS"]
+
+	for _, tt := range tests {
+		date := tt.date
+		result := addDaysFn.Fn(&date, &interpreter.Integer{Value: tt.days})
+
+		dateResult, ok := result.(*interpreter.Date)
+		if !ok {
+			t.Fatalf("expected Date, got %T (%+v)", result, result)
+		}
+		if *dateResult != tt.expected {
+			t.Errorf("ADDDAYS(%s, %d) = %s, want %s", tt.date.Inspect(), tt.days, dateResult.Inspect(), tt.expected.Inspect())
+		}
+		if date != tt.date {
+			t.Errorf("ADDDAYS changed its argument to %s", date.Inspect())
+		}
+	}
+}
+
+func TestDateDiff(t *testing.T) {
+	tests := []struct {
+		from     interpreter.Date
+		to       interpreter.Date
+		expected int64
+	}{
+		{interpreter.Date{Day: 4, Month: 10, Year: 2003}, interpreter.Date{Day: 4, Month: 10, Year: 2003}, 0},
+		{interpreter.Date{Day: 28, Month: 2, Year: 2024}, interpreter.Date{Day: 1, Month: 3, Year: 2024}, 2},
+		{interpreter.Date{Day: 28, Month: 2, Year: 2023}, interpreter.Date{Day: 1, Month: 3, Year: 2023}, 1},
+		{interpreter.Date{Day: 1, Month: 1, Year: 2024}, interpreter.Date{Day: 1, Month: 1, Year: 2025}, 366},
+		{interpreter.Date{Day: 10, Month: 5, Year: 2023}, interpreter.Date{Day: 1, Month: 5, Year: 2023}, -9},
+		{interpreter.Date{Day: 1, Month: 1, Year: 1600}, interpreter.Date{Day: 1, Month: 1, Year: 2024}, 154863},
+		{interpreter.Date{Day: 31, Month: 12, Year: 9999}, interpreter.Date{Day: 1, Month: 1, Year: 1}, -3652058},
+	}
+
+	dateDiffFn := GetBuiltins()["DATEDIFF"]
+
+	for _, tt := range tests {
+		from, to := tt.from, tt.to
+		result := dateDiffFn.Fn(&from, &to)
+
+		intResult, ok := result.(*interpreter.Integer)
+		if !ok {
+			t.Fatalf("expected Integer, got %T (%+v)", result, result)
+		}
+		if intResult.Value != tt.expected {
+			t.Errorf("DATEDIFF(%s, %s) = %d, want %d", tt.from.Inspect(), tt.to.Inspect(), intResult.Value, tt.expected)
+		}
+	}
+}
+
+func TestDateArithmeticErrors(t *testing.T) {
+	valid := &interpreter.Date{Day: 1, Month: 1, Year: 2024}
+	tests := []struct {
+		name     string
+		args     []interpreter.Object
+		expected string
+	}{
+		{"ADDDAYS", []interpreter.Object{valid}, "ADDDAYS requires 2 arguments, got 1"},
+		{"ADDDAYS", []interpreter.Object{&interpreter.String{Value: "01/01/2024"}, &interpreter.Integer{Value: 1}}, "ADDDAYS requires DATE as first argument, got STRING"},
+		{"ADDDAYS", []interpreter.Object{valid, &interpreter.Real{Value: 1.5}}, "ADDDAYS requires INTEGER as second argument, got REAL"},
+		{"ADDDAYS", []interpreter.Object{&interpreter.Date{Day: 31, Month: 2, Year: 2024}, &interpreter.Integer{Value: 1}}, "ADDDAYS: 31/02/2024 is not a valid date"},
+		{"DATEDIFF", []interpreter.Object{valid, &interpreter.Integer{Value: 1}}, "DATEDIFF requires DATE arguments, got INTEGER"},
+		{"DATEDIFF", []interpreter.Object{valid, &interpreter.Date{Day: 29, Month: 2, Year: 2023}}, "DATEDIFF: 29/02/2023 is not a valid date"},
+	}
+
+	builtins := GetBuiltins()
+	for _, tt := range tests {
+		result := builtins[tt.name].Fn(tt.args...)
+		errObj, ok := result.(*interpreter.Error)
+		if !ok {
+			t.Errorf("%s: expected Error, got %T (%+v)", tt.name, result, result)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestDateToStr(t *testing.T) {
+	date := &interpreter.Date{Day: 4, Month: 10, Year: 2003}
+	tests := []struct {
+		args     []interpreter.Object
+		expected string
+	}{
+		{[]interpreter.Object{date}, "04/10/2003"},
+		{[]interpreter.Object{date, &interpreter.String{Value: "YYYY-MM-DD"}}, "2003-10-04"},
+		{[]interpreter.Object{date, &interpreter.String{Value: "DD.MM.YY"}}, "04.10.03"},
+		{[]interpreter.Object{&interpreter.Date{Day: 9, Month: 1, Year: 5}}, "09/01/0005"},
+	}
+
+	dateToStrFn := GetBuiltins()["DATE_TO_STR"]
+
+	for _, tt := range tests {
+		result := dateToStrFn.Fn(tt.args...)
+		strResult, ok := result.(*interpreter.String)
+		if !ok {
+			t.Fatalf("expected String, got %T (%+v)", result, result)
+		}
+		if strResult.Value != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, strResult.Value)
+		}
+	}
+
+	// Without a format the result matches how OUTPUT shows a date
+	if got := dateToStrFn.Fn(date).Inspect(); got != date.Inspect() {
+		t.Errorf("expected %q to match Inspect %q", got, date.Inspect())
+	}
+}
+
+func TestDateToStrErrors(t *testing.T) {
+	date := &interpreter.Date{Day: 4, Month: 10, Year: 2003}
+	tests := []struct {
+		args     []interpreter.Object
+		expected string
+	}{
+		{nil, "DATE_TO_STR requires 1 or 2 arguments, got 0"},
+		{[]interpreter.Object{&interpreter.String{Value: "04/10/2003"}}, "DATE_TO_STR requires DATE as first argument, got STRING"},
+		{[]interpreter.Object{date, &interpreter.Integer{Value: 1}}, "DATE_TO_STR requires STRING as second argument, got INTEGER"},
+	}
+
+	dateToStrFn := GetBuiltins()["DATE_TO_STR"]
+
+	for _, tt := range tests {
+		result := dateToStrFn.Fn(tt.args...)
+		errObj, ok := result.(*interpreter.Error)
+		if !ok {
+			t.Errorf("expected Error, got %T (%+v)", result, result)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, errObj.Message)
+		}
+	}
+}
+
 func TestSetDate(t *testing.T) {
 	tests := []struct {
 		day   int64
@@ -985,7 +1902,7 @@ func TestTodayWrongArgCount(t *testing.T) {
 func TestDateBuiltinsRegistered(t *testing.T) {
 	builtins := GetBuiltins()
 
-	dateFunctions := []string{"DAY", "MONTH", "YEAR", "DAYINDEX", "SETDATE", "TODAY"}
+	dateFunctions := []string{"DAY", "MONTH", "YEAR", "DAYINDEX", "ADDDAYS", "DATEDIFF", "DATE_TO_STR", "SETDATE", "TODAY"}
 
 	for _, name := range dateFunctions {
 		if _, ok := builtins[name]; !ok {
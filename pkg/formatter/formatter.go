@@ -0,0 +1,203 @@
+// Package formatter prints Cambridge Pseudocode source in canonical form
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrinoff/cambridge-lang/pkg/lexer"
+	"github.com/andrinoff/cambridge-lang/pkg/parser"
+	"github.com/andrinoff/cambridge-lang/pkg/token"
+)
+
+// Format reprints source in canonical form from its AST: upper-case
+// keywords, <- for assignment and two-space indentation per block.
+//
+// Comments and blank lines are not part of the AST. They are written back
+// next to the statement they were next to in the source, with runs of blank
+// lines collapsed to one. This relies on each statement and block keyword
+// starting its own line; a source with comments that does not is rejected
+// rather than having its comments silently stripped.
+func Format(source string) (string, error) {
+	l := lexer.New(source)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return "", fmt.Errorf("parse error: %s", p.Errors()[0])
+	}
+
+	formatted := program.String()
+	comments := l.Comments()
+	blanks := blankLines(source, comments)
+	if len(comments) == 0 && len(blanks) == 0 {
+		return formatted, nil
+	}
+
+	var out []string
+	if formatted != "" {
+		out = strings.Split(strings.TrimSuffix(formatted, "\n"), "\n")
+	}
+	code := lineTokens(source)
+	if !linesMatch(code, out) {
+		if len(comments) > 0 {
+			return "", fmt.Errorf("cannot keep comments unless every statement starts on its own line")
+		}
+		return formatted, nil
+	}
+
+	return restore(source, out, code, comments, blanks)
+}
+
+// lineTokens returns the tokens of source grouped by line, one entry per
+// line holding at least one token
+func lineTokens(source string) [][]token.Token {
+	var lines [][]token.Token
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.NEWLINE {
+			continue
+		}
+		if n := len(lines); n > 0 && lines[n-1][0].Line == tok.Line {
+			lines[n-1] = append(lines[n-1], tok)
+		} else {
+			lines = append(lines, []token.Token{tok})
+		}
+	}
+	return lines
+}
+
+// linesMatch reports whether each formatted line starts with the same kind
+// of token as the source line in the same position, so that the two can be
+// paired up
+func linesMatch(code [][]token.Token, out []string) bool {
+	if len(code) != len(out) {
+		return false
+	}
+	for idx, line := range out {
+		if lexer.New(line).NextToken().Type != code[idx][0].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// blankLines returns the set of source lines that are empty or only
+// whitespace, not counting lines inside block comments
+func blankLines(source string, comments []lexer.Comment) map[int]bool {
+	blanks := make(map[int]bool)
+	for idx, line := range strings.Split(source, "\n") {
+		if strings.TrimSpace(line) == "" {
+			blanks[idx+1] = true
+		}
+	}
+	for _, c := range comments {
+		for line := c.Line + 1; line <= c.EndLine; line++ {
+			delete(blanks, line)
+		}
+	}
+	return blanks
+}
+
+// restore writes the formatted lines out in source order, adding back the
+// comments and blank lines found between and after them
+func restore(source string, out []string, code [][]token.Token, comments []lexer.Comment, blanks map[int]bool) (string, error) {
+	codeAt := make(map[int]int, len(code)) // source line to index in out
+	for idx, line := range code {
+		codeAt[line[0].Line] = idx
+	}
+
+	ownLine := make(map[int][]lexer.Comment) // keyed by the line they start on
+	trailing := make(map[int][]string)       // keyed by the code line they follow
+	for _, c := range comments {
+		before, after := codeAround(c, code)
+		switch {
+		case before && after:
+			return "", fmt.Errorf("line %d: cannot keep a comment in the middle of a line", c.Line)
+		case before:
+			trailing[c.Line] = append(trailing[c.Line], c.Text)
+		default:
+			ownLine[c.Line] = append(ownLine[c.Line], c)
+		}
+	}
+
+	var lines []string
+	pendingBlank := false
+	for line := 1; line <= strings.Count(source, "\n")+1; line++ {
+		if blanks[line] {
+			pendingBlank = len(lines) > 0
+			continue
+		}
+		idx, isCode := codeAt[line]
+		if !isCode && len(ownLine[line]) == 0 {
+			continue
+		}
+		if pendingBlank {
+			lines = append(lines, "")
+			pendingBlank = false
+		}
+
+		for _, c := range ownLine[line] {
+			lines = append(lines, commentIndent(c, out, code)+c.Text)
+		}
+		if isCode {
+			text := out[idx]
+			for _, t := range trailing[line] {
+				text += " " + t
+			}
+			lines = append(lines, text)
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// codeAround reports whether code shares the comment's first line before
+// it, and whether code shares its last line after it
+func codeAround(c lexer.Comment, code [][]token.Token) (before, after bool) {
+	for _, line := range code {
+		for _, tok := range line {
+			if tok.Line == c.Line && tok.Column < c.Column {
+				before = true
+			}
+			if tok.Line == c.EndLine && (c.EndLine > c.Line || tok.Column > c.Column) {
+				after = true
+			}
+		}
+	}
+	return before, after
+}
+
+// commentIndent returns the indentation for a comment on its own line: that
+// of the next formatted line, or one level deeper when the comment was
+// indented past a block keyword such as ENDIF that closes the block it ends
+func commentIndent(c lexer.Comment, out []string, code [][]token.Token) string {
+	next := -1
+	for idx, line := range code {
+		if line[0].Line >= c.Line {
+			next = idx
+			break
+		}
+	}
+	if next < 0 {
+		return ""
+	}
+
+	indent := out[next][:len(out[next])-len(strings.TrimLeft(out[next], " "))]
+	if c.Column > code[next][0].Column && closesBlock(code[next][0]) {
+		indent += "  "
+	}
+	return indent
+}
+
+// closesBlock reports whether tok ends a block or starts the next part of
+// one, so that a comment just before it belongs to the block's body
+func closesBlock(tok token.Token) bool {
+	switch tok.Type {
+	case token.ELSE, token.ELSEIF, token.ENDIF, token.OTHERWISE, token.ENDCASE,
+		token.NEXT, token.ENDWHILE, token.UNTIL, token.ENDPROCEDURE,
+		token.ENDFUNCTION, token.ENDCLASS, token.ENDTYPE:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatIsIdempotent(t *testing.T) {
+	input := `declare Count : integer
+DECLARE Grid : ARRAY[1:3, 1:3] OF REAL
+constant Max = 10
+TYPE Point
+DECLARE X : INTEGER
+DECLARE Y : INTEGER
+ENDTYPE
+TYPE Season = (Spring, Summer)
+TYPE Digits = SET OF INTEGER
+DEFINE Small (1, 2, 3) : Digits
+Count <- 1 + 2 * 3
+Count <- (Count + 1) * 2
+IF Count > 5 AND NOT false THEN
+FOR i <- 1 TO Max STEP 2
+WHILE Count < 100
+Count <- Count * 2
+ENDWHILE
+NEXT i
+ELSE
+OUTPUT "small", Count
+ENDIF
+CASE OF Count
+1 : OUTPUT "one"
+2 TO 5 : OUTPUT "few"
+OTHERWISE : OUTPUT "many"
+ENDCASE
+REPEAT
+Count <- Count - 1
+UNTIL Count <= 0
+FUNCTION Square(BYREF N : INTEGER) RETURNS INTEGER
+RETURN N * N
+ENDFUNCTION
+CLASS Pet INHERITS Animal
+PRIVATE Name : STRING
+public PROCEDURE NEW(GivenName : STRING)
+Name <- GivenName
+ENDPROCEDURE
+ENDCLASS
+MyPet <- NEW Pet("Rex")
+CALL MyPet.Speak(Grid[1, 2], 3 IN {1, 2})
+OPENFILE "data.txt" FOR READ
+READFILE "data.txt", Line
+CLOSEFILE "data.txt"
+`
+
+	first, err := Format(input)
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	second, err := Format(first)
+	if err != nil {
+		t.Fatalf("formatted output does not parse: %v\n%s", err, first)
+	}
+	if first != second {
+		t.Errorf("formatting is not idempotent.\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+
+	for _, want := range []string{
+		"DECLARE Count : INTEGER\n",
+		"CONSTANT Max = 10\n",
+		"TYPE Season = (Spring, Summer)\n",
+		"Count <- 1 + 2 * 3\nCount <- (Count + 1) * 2\n",
+		"IF Count > 5 AND NOT FALSE THEN\n  FOR i <- 1 TO Max STEP 2\n    WHILE Count < 100\n",
+		"  PUBLIC PROCEDURE NEW(GivenName : STRING)\n    Name <- GivenName\n  ENDPROCEDURE\n",
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("expected formatted output to contain %q, got:\n%s", want, first)
+		}
+	}
+}
+
+func TestFormatKeepsCommentsAndBlankLines(t *testing.T) {
+	input := `// Grades
+
+
+declare Score : integer   // out of 100
+if Score > 50 then
+    // passed
+    output "Pass"
+    // end of the THEN branch
+else
+    /* failed,
+       try again */
+    output "Fail"
+endif
+`
+	expected := `// Grades
+
+DECLARE Score : INTEGER // out of 100
+IF Score > 50 THEN
+  // passed
+  OUTPUT "Pass"
+  // end of the THEN branch
+ELSE
+  /* failed,
+       try again */
+  OUTPUT "Fail"
+ENDIF
+`
+
+	formatted, err := Format(input)
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	if formatted != expected {
+		t.Errorf("wrong output.\nexpected:\n%s\ngot:\n%s", expected, formatted)
+	}
+	if again, _ := Format(formatted); again != formatted {
+		t.Errorf("formatting is not idempotent, got:\n%s", again)
+	}
+}
+
+func TestFormatRejectsErrorsAndMisplacedComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"IF THEN\n", "parse error"},
+		{"x <- /* one */ 1\n", "line 1: cannot keep a comment in the middle of a line"},
+		{"IF TRUE THEN OUTPUT 1 ENDIF // note\n", "cannot keep comments unless every statement starts on its own line"},
+	}
+
+	for _, tt := range tests {
+		_, err := Format(tt.input)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("Format(%q): expected error %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestFormatWithoutCommentsSplitsStatements(t *testing.T) {
+	formatted, err := Format("IF TRUE THEN OUTPUT 1 ENDIF\n\nOUTPUT 2\n")
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	if formatted != "IF TRUE THEN\n  OUTPUT 1\nENDIF\nOUTPUT 2\n" {
+		t.Errorf("wrong output %q", formatted)
+	}
+}
@@ -1,12 +1,17 @@
 package interpreter
 
+import "github.com/andrinoff/cambridge-lang/pkg/ast"
+
 // Environment stores variable bindings
 type Environment struct {
 	store     map[string]Object
 	constants map[string]bool
+	declared  map[string]ast.DataType // types given by DECLARE and parameter lists
 	outer     *Environment
 	types     map[string]Object // For TYPE declarations
 	instance  *Instance         // For method execution context
+	class     *Class            // Class defining the method being executed
+	isolated  bool              // assignments to unknown names stop here instead of reaching outer scopes
 }
 
 // NewEnvironment creates a new environment
@@ -14,7 +19,8 @@ func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 	c := make(map[string]bool)
 	t := make(map[string]Object)
-	return &Environment{store: s, constants: c, outer: nil, types: t}
+	d := make(map[string]ast.DataType)
+	return &Environment{store: s, constants: c, declared: d, outer: nil, types: t}
 }
 
 // NewEnclosedEnvironment creates a new environment with an outer scope
@@ -52,9 +58,38 @@ func (e *Environment) Set(name string, val Object) Object {
 // Declare declares a new variable in the current scope
 func (e *Environment) Declare(name string, val Object) Object {
 	e.store[name] = val
+	delete(e.declared, name)
 	return val
 }
 
+// DeclareWithType declares a new variable in the current scope and records
+// the type it was declared with
+func (e *Environment) DeclareWithType(name string, dataType ast.DataType, val Object) Object {
+	e.Declare(name, val)
+	e.declared[name] = dataType
+	return val
+}
+
+// DeclaredType returns the type a variable was declared with, resolving the
+// name the same way as Get. Instance fields report the type from their class.
+func (e *Environment) DeclaredType(name string) (ast.DataType, bool) {
+	if _, ok := e.store[name]; ok {
+		dt, ok := e.declared[name]
+		return dt, ok
+	}
+	if e.instance != nil {
+		for class := e.instance.Class; class != nil; class = class.Parent {
+			if dt, ok := class.Fields[name]; ok {
+				return dt, true
+			}
+		}
+	}
+	if e.outer != nil {
+		return e.outer.DeclaredType(name)
+	}
+	return nil, false
+}
+
 // DeclareConstant declares a constant
 func (e *Environment) DeclareConstant(name string, val Object) Object {
 	e.store[name] = val
@@ -73,7 +108,9 @@ func (e *Environment) isConstant(name string) bool {
 	return false
 }
 
-// SetInPlace updates a variable in its original scope
+// SetInPlace updates a variable in its original scope. An isolated scope,
+// the body of a call under strict scoping, is the outermost scope searched:
+// assigning to a variable from further out creates a local there instead.
 func (e *Environment) SetInPlace(name string, val Object) Object {
 	if _, ok := e.store[name]; ok {
 		if e.constants[name] {
@@ -89,7 +126,10 @@ func (e *Environment) SetInPlace(name string, val Object) Object {
 			return val
 		}
 	}
-	if e.outer != nil {
+	if e.isolated && e.outer != nil && e.outer.isConstant(name) {
+		return &Error{Message: "cannot modify constant: " + name}
+	}
+	if e.outer != nil && !e.isolated {
 		return e.outer.SetInPlace(name, val)
 	}
 	// Variable not found, create it in current scope
@@ -97,6 +137,17 @@ func (e *Environment) SetInPlace(name string, val Object) Object {
 	return val
 }
 
+// methodClass returns the class whose method is executing in this scope, or
+// nil outside of methods
+func (e *Environment) methodClass() *Class {
+	for env := e; env != nil; env = env.outer {
+		if env.class != nil {
+			return env.class
+		}
+	}
+	return nil
+}
+
 // DefineType defines a type
 func (e *Environment) DefineType(name string, typ Object) {
 	e.types[name] = typ
@@ -4,36 +4,82 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/andrinoff/cambridge-lang/pkg/ast"
+	"github.com/andrinoff/cambridge-lang/pkg/token"
 )
 
 // Interpreter evaluates the AST
 type Interpreter struct {
-	env      *Environment
-	builtins map[string]*Builtin
-	files    map[string]*fileState
-	input    io.Reader
-	output   io.Writer
+	env       *Environment
+	builtins  map[string]*Builtin
+	files     map[string]*fileState
+	input     *bufio.Reader // shared by every INPUT so buffered lines are not lost
+	output    io.Writer
+	errOutput io.Writer // EPRINT
+	args      []string  // program arguments for ARG and ARGCOUNT
+	warnings  []Warning
+
+	warnUnmatchedCase bool
+	strictCase        bool
+	charArithmetic    bool
+	strictScoping     bool
+	detectStuckLoops  bool
+	maxIterations     int
+	maxCallDepth      int
+	callDepth         int
+	trace             TraceFunc
+	statementCounts   map[string]int // nil unless counting is enabled
 }
 
+// DefaultMaxCallDepth is the default limit on nested procedure and function
+// calls, low enough to report runaway recursion before the Go stack overflows
+const DefaultMaxCallDepth = 10000
+
+// TraceFunc is called with each statement and its environment just before
+// the statement is executed
+type TraceFunc func(stmt ast.Statement, env *Environment)
+
 type fileState struct {
 	file    *os.File
 	mode    string
 	scanner *bufio.Scanner
 	atEOF   bool
+	record  int64 // next record read or written in a RANDOM file, from 1
+}
+
+// FileInfo describes a file opened with OPENFILE
+type FileInfo struct {
+	Name string
+	Mode string // "READ", "WRITE", "APPEND" or "RANDOM"
+	EOF  bool
+}
+
+// Binding is a name defined at the top level of a program
+type Binding struct {
+	Name     string
+	Value    Object
+	Constant bool
 }
 
 // New creates a new interpreter
 func New() *Interpreter {
 	return &Interpreter{
-		env:      NewEnvironment(),
-		builtins: make(map[string]*Builtin),
-		files:    make(map[string]*fileState),
-		input:    os.Stdin,
-		output:   os.Stdout,
+		env:       NewEnvironment(),
+		builtins:  make(map[string]*Builtin),
+		files:     make(map[string]*fileState),
+		input:     bufio.NewReader(os.Stdin),
+		output:    os.Stdout,
+		errOutput: os.Stderr,
+
+		maxCallDepth: DefaultMaxCallDepth,
 	}
 }
 
@@ -44,7 +90,13 @@ func (i *Interpreter) SetBuiltins(builtins map[string]*Builtin) {
 
 // SetInput sets the input reader
 func (i *Interpreter) SetInput(r io.Reader) {
-	i.input = r
+	i.input = bufio.NewReader(r)
+}
+
+// SetArgs sets the command-line arguments passed to the program, which
+// builtins such as ARG read
+func (i *Interpreter) SetArgs(args []string) {
+	i.args = args
 }
 
 // SetOutput sets the output writer
@@ -52,6 +104,100 @@ func (i *Interpreter) SetOutput(w io.Writer) {
 	i.output = w
 }
 
+// SetErrorOutput sets the writer used by EPRINT, standard error by default
+func (i *Interpreter) SetErrorOutput(w io.Writer) {
+	i.errOutput = w
+}
+
+// SetWarnUnmatchedCase enables a warning when a CASE has no matching clause
+// and no OTHERWISE. By default such a CASE silently does nothing.
+func (i *Interpreter) SetWarnUnmatchedCase(enabled bool) {
+	i.warnUnmatchedCase = enabled
+}
+
+// SetStrictCase makes a CASE with no matching clause and no OTHERWISE an
+// error instead of doing nothing. It is off by default.
+func (i *Interpreter) SetStrictCase(enabled bool) {
+	i.strictCase = enabled
+}
+
+// SetCharArithmetic allows + and - on CHAR values, treating a CHAR as its
+// code point: CHAR + INTEGER, INTEGER + CHAR and CHAR - INTEGER give a CHAR,
+// and CHAR - CHAR gives the INTEGER distance between them. Any other
+// arithmetic on a CHAR is still a type mismatch. It is off by default.
+func (i *Interpreter) SetCharArithmetic(enabled bool) {
+	i.charArithmetic = enabled
+}
+
+// SetStrictScoping makes procedures, functions and methods treat variables
+// from enclosing scopes as read-only: assigning to one that is not a
+// parameter, local or field creates a local variable of the same name
+// instead of changing the outer one. Changes made through array elements and
+// record fields still reach the shared value. It is off by default, so a
+// procedure can assign to global variables.
+func (i *Interpreter) SetStrictScoping(enabled bool) {
+	i.strictScoping = enabled
+}
+
+// SetDetectStuckLoops enables a heuristic that warns when a WHILE or REPEAT
+// iteration leaves every variable read by the loop condition unchanged
+func (i *Interpreter) SetDetectStuckLoops(enabled bool) {
+	i.detectStuckLoops = enabled
+}
+
+// SetMaxIterations limits how many times a single WHILE or REPEAT loop may
+// iterate before it is stopped with an error. Zero, the default, means no
+// limit.
+func (i *Interpreter) SetMaxIterations(n int) {
+	i.maxIterations = n
+}
+
+// SetMaxCallDepth limits how deeply procedure and function calls may nest.
+// Zero means no limit.
+func (i *Interpreter) SetMaxCallDepth(n int) {
+	i.maxCallDepth = n
+}
+
+// SetTrace installs a hook called before every statement is executed. Pass
+// nil to disable tracing.
+func (i *Interpreter) SetTrace(fn TraceFunc) {
+	i.trace = fn
+}
+
+// SetCountStatements enables counting how many statements of each kind are
+// executed, reported by StatementCounts
+func (i *Interpreter) SetCountStatements(enabled bool) {
+	if enabled {
+		i.statementCounts = make(map[string]int)
+	} else {
+		i.statementCounts = nil
+	}
+}
+
+// StatementCounts returns how many times each kind of statement has been
+// executed, keyed by AST type name such as "ForStatement". It is empty unless
+// counting was enabled with SetCountStatements.
+func (i *Interpreter) StatementCounts() map[string]int {
+	counts := make(map[string]int, len(i.statementCounts))
+	for kind, n := range i.statementCounts {
+		counts[kind] = n
+	}
+	return counts
+}
+
+// Warnings returns the runtime warnings collected so far
+func (i *Interpreter) Warnings() []Warning {
+	return i.warnings
+}
+
+func (i *Interpreter) warn(tok token.Token, format string, args ...interface{}) {
+	i.warnings = append(i.warnings, Warning{
+		Message: fmt.Sprintf(format, args...),
+		Line:    tok.Line,
+		Column:  tok.Column,
+	})
+}
+
 // Eval evaluates a program
 func (i *Interpreter) Eval(program *ast.Program) Object {
 	var result Object
@@ -70,7 +216,39 @@ func (i *Interpreter) Eval(program *ast.Program) Object {
 	return result
 }
 
+// EvalWith declares the seed bindings in the top-level environment and then
+// evaluates the program. Values wrapped in Constant are declared immutable.
+func (i *Interpreter) EvalWith(program *ast.Program, seed map[string]Object) Object {
+	for name, val := range seed {
+		if c, ok := val.(*Constant); ok {
+			i.env.DeclareConstant(name, c.Value)
+		} else {
+			i.env.Declare(name, val)
+		}
+	}
+
+	return i.Eval(program)
+}
+
 func (i *Interpreter) evalStatement(stmt ast.Statement, env *Environment) Object {
+	if i.trace != nil {
+		i.trace(stmt, env)
+	}
+	if i.statementCounts != nil {
+		i.statementCounts[reflect.TypeOf(stmt).Elem().Name()]++
+	}
+
+	result := i.execStatement(stmt, env)
+
+	// Errors take the position of the innermost statement that raised them
+	if err, ok := result.(*Error); ok && err.Line == 0 {
+		tok := ast.StatementToken(stmt)
+		err.Line, err.Column = tok.Line, tok.Column
+	}
+	return result
+}
+
+func (i *Interpreter) execStatement(stmt ast.Statement, env *Environment) Object {
 	switch stmt := stmt.(type) {
 	case *ast.DeclareStatement:
 		return i.evalDeclareStatement(stmt, env)
@@ -108,72 +286,197 @@ func (i *Interpreter) evalStatement(stmt ast.Statement, env *Environment) Object
 		return i.evalReadFileStatement(stmt, env)
 	case *ast.WriteFileStatement:
 		return i.evalWriteFileStatement(stmt, env)
+	case *ast.SeekStatement:
+		return i.evalSeekStatement(stmt, env)
+	case *ast.GetRecordStatement:
+		return i.evalGetRecordStatement(stmt, env)
+	case *ast.PutRecordStatement:
+		return i.evalPutRecordStatement(stmt, env)
 	case *ast.TypeStatement:
 		return i.evalTypeStatement(stmt, env)
+	case *ast.DefineStatement:
+		return i.evalDefineStatement(stmt, env)
 	case *ast.ClassStatement:
 		return i.evalClassStatement(stmt, env)
 	case *ast.ExpressionStatement:
-		return i.evalExpression(stmt.Expression, env)
+		// A bare call may be a procedure call written without CALL
+		if call, ok := stmt.Expression.(*ast.CallExpression); ok {
+			return discardNoValue(i.evalCallExpression(call, env))
+		}
+		result := i.evalExpression(stmt.Expression, env)
+		// A built-in named without parentheses, as in ASSERT x = 1, would
+		// otherwise do nothing at all
+		if builtin, ok := result.(*Builtin); ok {
+			return &Error{Message: fmt.Sprintf("%s is a built-in function and must be called with parentheses, as in %s(...)", builtin.Name, builtin.Name)}
+		}
+		return result
 	default:
 		return &Error{Message: fmt.Sprintf("unknown statement type: %T", stmt)}
 	}
 }
 
 func (i *Interpreter) evalDeclareStatement(stmt *ast.DeclareStatement, env *Environment) Object {
+	if stmt.Value != nil {
+		return i.evalInitializedDeclaration(stmt, env)
+	}
+
+	// Each variable gets its own value so that arrays and records declared
+	// together are not shared
 	var value Object
+	for _, name := range stmt.Names {
+		value = i.newValue(stmt.DataType, env)
+		if isError(value) {
+			return value
+		}
+		env.DeclareWithType(name.Value, stmt.DataType, value)
+	}
+	return value
+}
 
-	switch dt := stmt.DataType.(type) {
+// evalInitializedDeclaration declares variables with the value given in
+// DECLARE x : type <- value, which must be of that type, or in DECLARE x <-
+// value, where the variable takes the type of the value
+func (i *Interpreter) evalInitializedDeclaration(stmt *ast.DeclareStatement, env *Environment) Object {
+	value := i.evalExpression(stmt.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	first := stmt.Names[0].Value
+	dataType := stmt.DataType
+	if dataType == nil {
+		switch value.(type) {
+		case *Integer, *Real, *String, *Char, *Boolean, *Date:
+			dataType = &ast.PrimitiveType{Name: string(value.Type())}
+		default:
+			return &Error{Message: fmt.Sprintf("cannot infer the type of %s from %s; declare it with a type", first, value.Type())}
+		}
+	}
+
+	converted, ok := ConvertValue(dataType, value)
+	if !ok {
+		return &Error{Message: fmt.Sprintf("cannot initialize %s : %s with %s", first, dataType, value.Type())}
+	}
+	for _, name := range stmt.Names {
+		env.DeclareWithType(name.Value, dataType, CopyObject(converted))
+	}
+	return converted
+}
+
+// ConvertValue returns value as a value of the given primitive type,
+// widening INTEGER to REAL. It reports false if value is of another type.
+// Values for composite types are returned unchecked.
+func ConvertValue(dataType ast.DataType, value Object) (Object, bool) {
+	prim, ok := dataType.(*ast.PrimitiveType)
+	if !ok || string(value.Type()) == prim.Name {
+		return value, true
+	}
+	if n, ok := value.(*Integer); ok && prim.Name == "REAL" {
+		return &Real{Value: float64(n.Value)}, true
+	}
+	return value, false
+}
+
+// newValue returns the initial value of a newly declared variable of the
+// given type
+func (i *Interpreter) newValue(dataType ast.DataType, env *Environment) Object {
+	switch dt := dataType.(type) {
 	case *ast.PrimitiveType:
 		switch dt.Name {
 		case "INTEGER":
-			value = &Integer{Value: 0}
+			return &Integer{Value: 0}
 		case "REAL":
-			value = &Real{Value: 0.0}
+			return &Real{Value: 0.0}
 		case "STRING":
-			value = &String{Value: ""}
+			return &String{Value: ""}
 		case "CHAR":
-			value = &Char{Value: ' '}
+			return &Char{Value: ' '}
 		case "BOOLEAN":
-			value = &Boolean{Value: false}
+			return &Boolean{Value: false}
 		case "DATE":
-			value = &Date{Day: 1, Month: 1, Year: 1970}
-		default:
-			value = &Null{}
+			return &Date{Day: 1, Month: 1, Year: 1970}
 		}
 	case *ast.ArrayType:
+		dims, err := i.resolveDimensions(dt.Dimensions, env)
+		if err != nil {
+			return err
+		}
 		arr := &Array{
-			Elements:   make(map[string]Object),
-			Dimensions: dt.Dimensions,
+			Elements:    make(map[string]Object),
+			Dimensions:  dims,
+			ElementType: dt.ElementType,
+		}
+		if custom, ok := dt.ElementType.(*ast.CustomType); ok {
+			typ, _ := env.GetType(custom.Name)
+			_, arr.RecordElements = typ.(*Record)
+		}
+		return arr
+	case *ast.DictionaryType:
+		return &Dictionary{
+			Entries:   make(map[string]Object),
+			KeyType:   dt.KeyType,
+			ValueType: dt.ValueType,
 		}
-		value = arr
 	case *ast.CustomType:
 		// Check if it's a defined type
 		if typ, ok := env.GetType(dt.Name); ok {
-			switch t := typ.(type) {
-			case *Record:
+			if t, ok := typ.(*Record); ok {
 				// Create a new record instance
 				rec := &Record{
-					TypeName: dt.Name,
-					Fields:   make(map[string]Object),
+					TypeName:   dt.Name,
+					Fields:     make(map[string]Object),
+					FieldTypes: t.FieldTypes,
 				}
 				for name := range t.Fields {
 					rec.Fields[name] = &Null{}
 				}
-				value = rec
-			default:
-				value = &Null{}
+				return rec
 			}
-		} else {
-			value = &Null{}
 		}
-	default:
-		value = &Null{}
 	}
+	return &Null{}
+}
 
-	return env.Declare(stmt.Name.Value, value)
+// resolveDimensions evaluates array bounds given as expressions, such as
+// constants, returning dimensions with integer bounds
+func (i *Interpreter) resolveDimensions(dims []ast.ArrayDimension, env *Environment) ([]ast.ArrayDimension, Object) {
+	resolved := make([]ast.ArrayDimension, len(dims))
+	for idx, dim := range dims {
+		lower, err := i.evalArrayBound(dim.LowerExpr, dim.Lower, env)
+		if err != nil {
+			return nil, err
+		}
+		upper, err := i.evalArrayBound(dim.UpperExpr, dim.Upper, env)
+		if err != nil {
+			return nil, err
+		}
+		resolved[idx] = ast.ArrayDimension{Lower: lower, Upper: upper}
+	}
+	return resolved, nil
+}
+
+// evalArrayBound returns literal when the bound is an integer literal (expr
+// is nil), and otherwise evaluates expr, which must give an INTEGER
+func (i *Interpreter) evalArrayBound(expr ast.Expression, literal int, env *Environment) (int, Object) {
+	if expr == nil {
+		return literal, nil
+	}
+
+	val := i.evalExpression(expr, env)
+	if isError(val) {
+		return 0, val
+	}
+	n, ok := val.(*Integer)
+	if !ok {
+		return 0, &Error{Message: fmt.Sprintf("array bound %s must be an INTEGER, got %s", expr.String(), val.Type())}
+	}
+	return int(n.Value), nil
 }
 
 func (i *Interpreter) evalConstantStatement(stmt *ast.ConstantStatement, env *Environment) Object {
+	if !isConstantExpression(stmt.Value, env) {
+		return &Error{Message: "constant must be defined from literals or constants"}
+	}
 	value := i.evalExpression(stmt.Value, env)
 	if isError(value) {
 		return value
@@ -181,30 +484,107 @@ func (i *Interpreter) evalConstantStatement(stmt *ast.ConstantStatement, env *En
 	return env.DeclareConstant(stmt.Name.Value, value)
 }
 
+// isConstantExpression reports whether expr is built only from literals and
+// constants already declared in env, such as A * 2 + 1
+func isConstantExpression(expr ast.Expression, env *Environment) bool {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteral, *ast.RealLiteral, *ast.StringLiteral, *ast.CharLiteral, *ast.BooleanLiteral:
+		return true
+	case *ast.Identifier:
+		return env.isConstant(e.Value)
+	case *ast.PrefixExpression:
+		return isConstantExpression(e.Right, env)
+	case *ast.InfixExpression:
+		return isConstantExpression(e.Left, env) && isConstantExpression(e.Right, env)
+	}
+	return false
+}
+
 func (i *Interpreter) evalAssignmentStatement(stmt *ast.AssignmentStatement, env *Environment) Object {
 	value := i.evalExpression(stmt.Value, env)
 	if isError(value) {
 		return value
 	}
 
-	switch target := stmt.Name.(type) {
+	return i.assign(stmt.Name, CopyObject(value), env)
+}
+
+// CopyObject returns a deep copy of a record or array, so that assigning one
+// to a variable gives it its own value, as records and arrays are value
+// types. Other objects are returned unchanged.
+func CopyObject(obj Object) Object {
+	switch o := obj.(type) {
+	case *Record:
+		rec := &Record{TypeName: o.TypeName, Fields: make(map[string]Object, len(o.Fields)), FieldTypes: o.FieldTypes}
+		for name, field := range o.Fields {
+			rec.Fields[name] = CopyObject(field)
+		}
+		return rec
+	case *Array:
+		arr := &Array{Elements: make(map[string]Object, len(o.Elements)), Dimensions: o.Dimensions, ElementType: o.ElementType, RecordElements: o.RecordElements}
+		for key, elem := range o.Elements {
+			arr.Elements[key] = CopyObject(elem)
+		}
+		return arr
+	}
+	return obj
+}
+
+// assign stores value in a variable, array element or member
+func (i *Interpreter) assign(target ast.Expression, value Object, env *Environment) Object {
+	switch t := target.(type) {
 	case *ast.Identifier:
-		return env.SetInPlace(target.Value, value)
+		return env.SetInPlace(t.Value, value)
 	case *ast.ArrayAccess:
-		return i.evalArrayAssignment(target, value, env)
+		return i.evalArrayAssignment(t, value, env)
 	case *ast.MemberAccess:
-		return i.evalMemberAssignment(target, value, env)
+		return i.evalMemberAssignment(t, value, env)
 	default:
 		return &Error{Message: "invalid assignment target"}
 	}
 }
 
+// evalTarget evaluates the container an assignment stores into. Unlike a
+// plain read, it creates unassigned ARRAY, DICTIONARY and record fields on
+// the way, so that obj.Items[3].Name can be assigned without assigning
+// obj.Items first.
+func (i *Interpreter) evalTarget(expr ast.Expression, env *Environment) Object {
+	switch e := expr.(type) {
+	case *ast.MemberAccess:
+		obj := i.evalTarget(e.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return i.memberValue(e, obj, true, env)
+	case *ast.ArrayAccess:
+		arr := i.evalTarget(e.Array, env)
+		if isError(arr) {
+			return arr
+		}
+		return i.elementValue(e, arr, env)
+	}
+	return i.evalExpression(expr, env)
+}
+
 func (i *Interpreter) evalArrayAssignment(access *ast.ArrayAccess, value Object, env *Environment) Object {
-	arr := i.evalExpression(access.Array, env)
+	arr := i.evalTarget(access.Array, env)
 	if isError(arr) {
 		return arr
 	}
 
+	if str, ok := arr.(*String); ok {
+		return i.evalStringIndexAssignment(access, str, value, env)
+	}
+
+	if dict, ok := arr.(*Dictionary); ok {
+		key, err := i.dictionaryKey(dict, access.Indices, env)
+		if err != nil {
+			return err
+		}
+		dict.Entries[key] = value
+		return value
+	}
+
 	array, ok := arr.(*Array)
 	if !ok {
 		return &Error{Message: "not an array"}
@@ -228,8 +608,60 @@ func (i *Interpreter) evalArrayAssignment(access *ast.ArrayAccess, value Object,
 	return value
 }
 
+// evalStringIndexAssignment replaces one character of a string. Strings are
+// immutable values, so the updated string is assigned back to the variable,
+// element or member the string came from.
+func (i *Interpreter) evalStringIndexAssignment(access *ast.ArrayAccess, str *String, value Object, env *Environment) Object {
+	runes := []rune(str.Value)
+	pos, err := i.stringIndex(runes, access.Indices, env)
+	if err != nil {
+		return err
+	}
+
+	var ch rune
+	switch v := value.(type) {
+	case *Char:
+		ch = v.Value
+	case *String:
+		if utf8.RuneCountInString(v.Value) != 1 {
+			return &Error{Message: fmt.Sprintf("cannot assign STRING of length %d to a string index", utf8.RuneCountInString(v.Value))}
+		}
+		ch, _ = utf8.DecodeRuneInString(v.Value)
+	default:
+		return &Error{Message: fmt.Sprintf("cannot assign %s to a string index", value.Type())}
+	}
+
+	runes[pos] = ch
+	if result := i.assign(access.Array, &String{Value: string(runes)}, env); isError(result) {
+		return result
+	}
+	return value
+}
+
+// stringIndex evaluates a 1-based string index and returns the 0-based
+// position of the character it refers to
+func (i *Interpreter) stringIndex(runes []rune, indices []ast.Expression, env *Environment) (int, Object) {
+	if len(indices) != 1 {
+		return 0, &Error{Message: "string index must be a single integer"}
+	}
+
+	idxVal := i.evalExpression(indices[0], env)
+	if isError(idxVal) {
+		return 0, idxVal
+	}
+	idx, ok := idxVal.(*Integer)
+	if !ok {
+		return 0, &Error{Message: "string index must be an integer"}
+	}
+
+	if idx.Value < 1 || idx.Value > int64(len(runes)) {
+		return 0, &Error{Message: fmt.Sprintf("string index %d out of range 1 to %d", idx.Value, len(runes))}
+	}
+	return int(idx.Value - 1), nil
+}
+
 func (i *Interpreter) evalMemberAssignment(access *ast.MemberAccess, value Object, env *Environment) Object {
-	obj := i.evalExpression(access.Object, env)
+	obj := i.evalTarget(access.Object, env)
 	if isError(obj) {
 		return obj
 	}
@@ -239,6 +671,9 @@ func (i *Interpreter) evalMemberAssignment(access *ast.MemberAccess, value Objec
 		o.Fields[access.Member] = value
 		return value
 	case *Instance:
+		if err := i.checkAccess(o, access.Member, env); err != nil {
+			return err
+		}
 		o.Fields[access.Member] = value
 		return value
 	default:
@@ -279,6 +714,13 @@ func (i *Interpreter) evalCaseStatement(stmt *ast.CaseStatement, env *Environmen
 		return i.evalStatements(stmt.Otherwise, env)
 	}
 
+	if i.strictCase {
+		return &Error{Message: fmt.Sprintf("no matching case for value %s", value.Inspect())}
+	}
+	if i.warnUnmatchedCase {
+		i.warn(stmt.Token, "no CASE clause matched value %s", value.Inspect())
+	}
+
 	return &Null{}
 }
 
@@ -290,7 +732,7 @@ func (i *Interpreter) matchesCaseValue(value Object, caseValue ast.Expression, e
 		return i.valueInRange(value, start, end)
 	default:
 		evalValue := i.evalExpression(caseValue, env)
-		return i.objectsEqual(value, evalValue)
+		return ObjectsEqual(value, evalValue)
 	}
 }
 
@@ -312,7 +754,8 @@ func (i *Interpreter) valueInRange(value, start, end Object) bool {
 	return false
 }
 
-func (i *Interpreter) objectsEqual(a, b Object) bool {
+// ObjectsEqual reports whether two values are equal, as compared by = and IN
+func ObjectsEqual(a, b Object) bool {
 	switch av := a.(type) {
 	case *Integer:
 		if bv, ok := b.(*Integer); ok {
@@ -334,40 +777,60 @@ func (i *Interpreter) objectsEqual(a, b Object) bool {
 		if bv, ok := b.(*Boolean); ok {
 			return av.Value == bv.Value
 		}
+	case *Date:
+		if bv, ok := b.(*Date); ok {
+			return *av == *bv
+		}
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Instance:
+		// objects are references, equal only to themselves
+		return a == b
 	}
 	return false
 }
 
+// evalForStatement runs a count-controlled loop. The start, end and step
+// expressions are evaluated once on entry, and the counter is kept separately
+// from the loop variable, so assigning to the variable or to a variable used
+// in the bounds inside the body does not change the number of iterations.
 func (i *Interpreter) evalForStatement(stmt *ast.ForStatement, env *Environment) Object {
 	start := i.evalExpression(stmt.Start, env)
 	if isError(start) {
 		return start
 	}
+	if !isNumber(start) {
+		return &Error{Message: "FOR loop start must be a number"}
+	}
 
 	end := i.evalExpression(stmt.End, env)
 	if isError(end) {
 		return end
 	}
+	if !isNumber(end) {
+		return &Error{Message: "FOR loop end must be a number"}
+	}
 
-	step := int64(1)
+	var step Object = &Integer{Value: 1}
 	if stmt.Step != nil {
-		stepVal := i.evalExpression(stmt.Step, env)
-		if isError(stepVal) {
-			return stepVal
+		step = i.evalExpression(stmt.Step, env)
+		if isError(step) {
+			return step
 		}
-		if s, ok := stepVal.(*Integer); ok {
-			step = s.Value
+		if !isNumber(step) {
+			return &Error{Message: "FOR loop STEP must be a number"}
+		}
+		if numberValue(step) == 0 {
+			return &Error{Message: "FOR loop STEP cannot be zero"}
 		}
 	}
 
-	startInt, ok := start.(*Integer)
-	if !ok {
-		return &Error{Message: "FOR loop start must be an integer"}
-	}
-
-	endInt, ok := end.(*Integer)
-	if !ok {
-		return &Error{Message: "FOR loop end must be an integer"}
+	startInt, startOk := start.(*Integer)
+	endInt, endOk := end.(*Integer)
+	stepInt, stepOk := step.(*Integer)
+	if !startOk || !endOk || !stepOk {
+		return i.evalRealForStatement(stmt, numberValue(start), numberValue(end), numberValue(step), env)
 	}
 
 	loopEnv := NewEnclosedEnvironment(env)
@@ -376,10 +839,10 @@ func (i *Interpreter) evalForStatement(stmt *ast.ForStatement, env *Environment)
 	var result Object
 	for current := startInt.Value; ; {
 		// Check loop condition
-		if step > 0 && current > endInt.Value {
+		if stepInt.Value > 0 && current > endInt.Value {
 			break
 		}
-		if step < 0 && current < endInt.Value {
+		if stepInt.Value < 0 && current < endInt.Value {
 			break
 		}
 
@@ -393,16 +856,71 @@ func (i *Interpreter) evalForStatement(stmt *ast.ForStatement, env *Environment)
 			return result
 		}
 
-		current += step
+		current += stepInt.Value
+	}
+
+	return result
+}
+
+// evalRealForStatement runs a FOR loop whose start, end or step is REAL.
+// Each value is computed from the start and the number of steps taken, so
+// rounding errors do not build up, and the end is allowed a small tolerance
+// so that FOR x <- 0 TO 1 STEP 0.1 still reaches 1.
+func (i *Interpreter) evalRealForStatement(stmt *ast.ForStatement, start, end, step float64, env *Environment) Object {
+	const tolerance = 1e-9
+
+	loopEnv := NewEnclosedEnvironment(env)
+	loopEnv.Declare(stmt.Variable.Value, &Real{Value: start})
+
+	var result Object
+	for n := 0; ; n++ {
+		current := start + float64(n)*step
+		if step > 0 && current > end+tolerance*math.Max(1, math.Abs(end)) {
+			break
+		}
+		if step < 0 && current < end-tolerance*math.Max(1, math.Abs(end)) {
+			break
+		}
+
+		loopEnv.SetInPlace(stmt.Variable.Value, &Real{Value: current})
+		result = i.evalStatements(stmt.Body, loopEnv)
+
+		if isError(result) {
+			return result
+		}
+		if _, ok := result.(*ReturnValue); ok {
+			return result
+		}
 	}
 
 	return result
 }
 
+// isNumber reports whether obj is an INTEGER or a REAL
+func isNumber(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *Real:
+		return true
+	}
+	return false
+}
+
+// numberValue returns an INTEGER or REAL as a float64
+func numberValue(obj Object) float64 {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value)
+	case *Real:
+		return obj.Value
+	}
+	return 0
+}
+
 func (i *Interpreter) evalWhileStatement(stmt *ast.WhileStatement, env *Environment) Object {
 	var result Object
+	watch := i.newLoopWatch(stmt.Condition, env)
 
-	for {
+	for iterations := 1; ; iterations++ {
 		condition := i.evalExpression(stmt.Condition, env)
 		if isError(condition) {
 			return condition
@@ -412,6 +930,11 @@ func (i *Interpreter) evalWhileStatement(stmt *ast.WhileStatement, env *Environm
 			break
 		}
 
+		if err := i.checkIterations(iterations); err != nil {
+			return err
+		}
+
+		watch.snapshot()
 		result = i.evalStatements(stmt.Body, env)
 		if isError(result) {
 			return result
@@ -419,15 +942,24 @@ func (i *Interpreter) evalWhileStatement(stmt *ast.WhileStatement, env *Environm
 		if _, ok := result.(*ReturnValue); ok {
 			return result
 		}
+		watch.check(stmt.Token)
 	}
 
 	return result
 }
 
+// evalRepeatStatement runs the body and then tests the UNTIL condition in the
+// same environment, so the condition always sees variables the body changed
 func (i *Interpreter) evalRepeatStatement(stmt *ast.RepeatStatement, env *Environment) Object {
 	var result Object
+	watch := i.newLoopWatch(stmt.Condition, env)
 
-	for {
+	for iterations := 1; ; iterations++ {
+		if err := i.checkIterations(iterations); err != nil {
+			return err
+		}
+
+		watch.snapshot()
 		result = i.evalStatements(stmt.Body, env)
 		if isError(result) {
 			return result
@@ -435,6 +967,7 @@ func (i *Interpreter) evalRepeatStatement(stmt *ast.RepeatStatement, env *Enviro
 		if _, ok := result.(*ReturnValue); ok {
 			return result
 		}
+		watch.check(stmt.Token)
 
 		condition := i.evalExpression(stmt.Condition, env)
 		if isError(condition) {
@@ -449,6 +982,104 @@ func (i *Interpreter) evalRepeatStatement(stmt *ast.RepeatStatement, env *Enviro
 	return result
 }
 
+// checkIterations returns an error once a loop is about to run more
+// iterations than the configured maximum
+func (i *Interpreter) checkIterations(iterations int) Object {
+	if i.maxIterations > 0 && iterations > i.maxIterations {
+		return &Error{Message: fmt.Sprintf("loop exceeded %d iterations (possible infinite loop)", i.maxIterations)}
+	}
+	return nil
+}
+
+// loopWatch implements the stuck-loop heuristic for a single loop execution.
+// It remembers the values of the variables read by the loop condition at
+// the start of each iteration and warns if none of them changed.
+type loopWatch struct {
+	interp *Interpreter
+	env    *Environment
+	names  []string
+	before []Object
+	done   bool
+}
+
+// newLoopWatch returns nil when the heuristic is disabled or the condition
+// could change without an assignment (e.g. it calls a function)
+func (i *Interpreter) newLoopWatch(condition ast.Expression, env *Environment) *loopWatch {
+	if !i.detectStuckLoops {
+		return nil
+	}
+	names, ok := conditionVariables(condition)
+	if !ok || len(names) == 0 {
+		return nil
+	}
+	return &loopWatch{interp: i, env: env, names: names}
+}
+
+func (w *loopWatch) snapshot() {
+	if w == nil || w.done {
+		return
+	}
+	w.before = w.before[:0]
+	for _, name := range w.names {
+		val, ok := w.env.Get(name)
+		if !ok || !isScalar(val) {
+			// Containers can be mutated in place, so we can't tell
+			w.done = true
+			return
+		}
+		w.before = append(w.before, val)
+	}
+}
+
+func (w *loopWatch) check(tok token.Token) {
+	if w == nil || w.done {
+		return
+	}
+	for idx, name := range w.names {
+		val, _ := w.env.Get(name)
+		if !ObjectsEqual(w.before[idx], val) {
+			return
+		}
+	}
+	w.interp.warn(tok, "loop condition variables unchanged — possible infinite loop")
+	w.done = true
+}
+
+// conditionVariables returns the variables read by a loop condition. It
+// reports false if the condition contains anything other than literals,
+// variables and operators, since calls and element accesses may change
+// value without the named variables being reassigned.
+func conditionVariables(expr ast.Expression) ([]string, bool) {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteral, *ast.RealLiteral, *ast.StringLiteral, *ast.CharLiteral, *ast.BooleanLiteral, *ast.NullLiteral:
+		return nil, true
+	case *ast.Identifier:
+		return []string{e.Value}, true
+	case *ast.PrefixExpression:
+		return conditionVariables(e.Right)
+	case *ast.InfixExpression:
+		left, ok := conditionVariables(e.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := conditionVariables(e.Right)
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	default:
+		return nil, false
+	}
+}
+
+func isScalar(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *Real, *String, *Char, *Boolean:
+		return true
+	}
+	return false
+}
+
 func (i *Interpreter) evalProcedureStatement(stmt *ast.ProcedureStatement, env *Environment) Object {
 	proc := &Procedure{
 		Name:       stmt.Name,
@@ -477,7 +1108,16 @@ func (i *Interpreter) evalCallStatement(stmt *ast.CallStatement, env *Environmen
 		Function:  stmt.Name,
 		Arguments: stmt.Arguments,
 	}
-	return i.evalCallExpression(call, env)
+	return discardNoValue(i.evalCallExpression(call, env))
+}
+
+// discardNoValue turns the result of a procedure call used as a statement
+// into Null
+func discardNoValue(obj Object) Object {
+	if _, ok := obj.(*NoValue); ok {
+		return &Null{}
+	}
+	return obj
 }
 
 func (i *Interpreter) evalReturnStatement(stmt *ast.ReturnStatement, env *Environment) Object {
@@ -493,23 +1133,90 @@ func (i *Interpreter) evalReturnStatement(stmt *ast.ReturnStatement, env *Enviro
 	return &ReturnValue{Value: value}
 }
 
+// evalInputStatement reads one line of input for each variable in turn
 func (i *Interpreter) evalInputStatement(stmt *ast.InputStatement, env *Environment) Object {
-	reader := bufio.NewReader(i.input)
-	line, err := reader.ReadString('\n')
-	if err != nil && err != io.EOF {
-		return &Error{Message: fmt.Sprintf("input error: %v", err)}
+	for _, target := range stmt.Variables {
+		line, err := i.input.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return &Error{Message: fmt.Sprintf("input error: %v", err)}
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		value := coerceInput(line, i.inputType(target, env))
+		if isError(value) {
+			return value
+		}
+
+		if result := i.assign(target, value, env); isError(result) {
+			return result
+		}
 	}
 
-	line = strings.TrimRight(line, "\r\n")
+	return &Null{}
+}
 
-	switch target := stmt.Variable.(type) {
+// inputType returns the type that INPUT should convert a line to for the
+// given target: the declared type of a variable or array element, otherwise
+// the type of the variable's or field's current value. It returns an empty
+// string if the type is unknown, in which case the line is kept as a STRING.
+func (i *Interpreter) inputType(target ast.Expression, env *Environment) string {
+	switch t := target.(type) {
 	case *ast.Identifier:
-		env.SetInPlace(target.Value, &String{Value: line})
+		if dt, ok := env.DeclaredType(t.Value); ok {
+			return primitiveName(dt)
+		}
 	case *ast.ArrayAccess:
-		return i.evalArrayAssignment(target, &String{Value: line}, env)
+		switch arr := i.evalExpression(t.Array, env).(type) {
+		case *Array:
+			return primitiveName(arr.ElementType)
+		case *String:
+			return "CHAR"
+		}
+		return ""
 	}
 
-	return &Null{}
+	switch current := i.evalExpression(target, env).(type) {
+	case *Integer, *Real, *Boolean, *Char:
+		return string(current.Type())
+	}
+	return ""
+}
+
+// coerceInput converts a line of input to the given type
+func coerceInput(line, typ string) Object {
+	text := strings.TrimSpace(line)
+	switch typ {
+	case "INTEGER":
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &Integer{Value: n}
+		}
+	case "REAL":
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return &Real{Value: f}
+		}
+	case "BOOLEAN":
+		if strings.EqualFold(text, "TRUE") || strings.EqualFold(text, "FALSE") {
+			return &Boolean{Value: strings.EqualFold(text, "TRUE")}
+		}
+	case "CHAR":
+		if utf8.RuneCountInString(line) == 1 {
+			r, _ := utf8.DecodeRuneInString(line)
+			return &Char{Value: r}
+		}
+	default:
+		return &String{Value: line}
+	}
+	return &Error{Message: fmt.Sprintf("expected %s, got '%s'", typ, line)}
+}
+
+// primitiveName returns the name of a primitive data type, or an empty
+// string for arrays, records and other composite types
+func primitiveName(dt ast.DataType) string {
+	if p, ok := dt.(*ast.PrimitiveType); ok {
+		return p.Name
+	}
+	return ""
 }
 
 func (i *Interpreter) evalOutputStatement(stmt *ast.OutputStatement, env *Environment) Object {
@@ -523,7 +1230,11 @@ func (i *Interpreter) evalOutputStatement(stmt *ast.OutputStatement, env *Enviro
 		parts = append(parts, value.Inspect())
 	}
 
-	fmt.Fprintln(i.output, strings.Join(parts, ""))
+	w := i.output
+	if stmt.ToError {
+		w = i.errOutput
+	}
+	fmt.Fprintln(w, strings.Join(parts, ""))
 	return &Null{}
 }
 
@@ -538,6 +1249,11 @@ func (i *Interpreter) evalOpenFileStatement(stmt *ast.OpenFileStatement, env *En
 		return &Error{Message: "filename must be a string"}
 	}
 
+	// Checked before opening, as opening FOR WRITE would truncate the file
+	if _, open := i.files[filenameStr.Value]; open {
+		return &Error{Message: fmt.Sprintf("file %s is already open", filenameStr.Value)}
+	}
+
 	var file *os.File
 	var err error
 
@@ -548,6 +1264,8 @@ func (i *Interpreter) evalOpenFileStatement(stmt *ast.OpenFileStatement, env *En
 		file, err = os.Create(filenameStr.Value)
 	case "APPEND":
 		file, err = os.OpenFile(filenameStr.Value, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	case "RANDOM":
+		file, err = os.OpenFile(filenameStr.Value, os.O_CREATE|os.O_RDWR, 0644)
 	}
 
 	if err != nil {
@@ -555,8 +1273,9 @@ func (i *Interpreter) evalOpenFileStatement(stmt *ast.OpenFileStatement, env *En
 	}
 
 	fs := &fileState{
-		file: file,
-		mode: stmt.Mode,
+		file:   file,
+		mode:   stmt.Mode,
+		record: 1,
 	}
 	if stmt.Mode == "READ" {
 		fs.scanner = bufio.NewScanner(file)
@@ -641,13 +1360,21 @@ func (i *Interpreter) evalWriteFileStatement(stmt *ast.WriteFileStatement, env *
 	if fs.mode != "WRITE" && fs.mode != "APPEND" {
 		return &Error{Message: "file not open for writing"}
 	}
-
-	data := i.evalExpression(stmt.Data, env)
-	if isError(data) {
-		return data
+
+	// Scalars are written unquoted, the same way concatenation renders them
+	var line strings.Builder
+	for _, expr := range stmt.Values {
+		data := i.evalExpression(expr, env)
+		if isError(data) {
+			return data
+		}
+		line.WriteString(i.objectToString(data))
+	}
+	if !stmt.NoNewline {
+		line.WriteString("\n")
 	}
 
-	_, err := fmt.Fprintln(fs.file, data.Inspect())
+	_, err := io.WriteString(fs.file, line.String())
 	if err != nil {
 		return &Error{Message: fmt.Sprintf("write error: %v", err)}
 	}
@@ -659,11 +1386,13 @@ func (i *Interpreter) evalTypeStatement(stmt *ast.TypeStatement, env *Environmen
 	switch def := stmt.Definition.(type) {
 	case *ast.RecordType:
 		rec := &Record{
-			TypeName: stmt.Name,
-			Fields:   make(map[string]Object),
+			TypeName:   stmt.Name,
+			Fields:     make(map[string]Object),
+			FieldTypes: make(map[string]ast.DataType),
 		}
 		for _, field := range def.Fields {
 			rec.Fields[field.Name] = &Null{}
+			rec.FieldTypes[field.Name] = field.DataType
 		}
 		env.DefineType(stmt.Name, rec)
 	case *ast.EnumType:
@@ -671,15 +1400,58 @@ func (i *Interpreter) evalTypeStatement(stmt *ast.TypeStatement, env *Environmen
 		for idx, val := range def.Values {
 			env.Declare(val, &Integer{Value: int64(idx)})
 		}
+	case *ast.SetType:
+		env.DefineType(stmt.Name, &Set{TypeName: stmt.Name, ElementType: def.ElementType})
 	}
 	return &Null{}
 }
 
+func (i *Interpreter) evalDefineStatement(stmt *ast.DefineStatement, env *Environment) Object {
+	typ, ok := env.GetType(stmt.TypeName)
+	if !ok {
+		return &Error{Message: fmt.Sprintf("undefined type: %s", stmt.TypeName)}
+	}
+
+	setType, ok := typ.(*Set)
+	if !ok {
+		return &Error{Message: fmt.Sprintf("%s is not a SET type", stmt.TypeName)}
+	}
+
+	set := &Set{TypeName: stmt.TypeName, ElementType: setType.ElementType}
+	for _, expr := range stmt.Values {
+		value := i.evalExpression(expr, env)
+		if isError(value) {
+			return value
+		}
+
+		if prim, ok := setType.ElementType.(*ast.PrimitiveType); ok && string(value.Type()) != prim.Name {
+			return &Error{Message: fmt.Sprintf("cannot add %s to SET OF %s", value.Type(), prim.Name)}
+		}
+
+		if !i.setContains(set, value) {
+			set.Elements = append(set.Elements, value)
+		}
+	}
+
+	return env.DeclareConstant(stmt.Name.Value, set)
+}
+
+// setContains reports whether value is a member of set
+func (i *Interpreter) setContains(set *Set, value Object) bool {
+	for _, elem := range set.Elements {
+		if ObjectsEqual(elem, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func (i *Interpreter) evalClassStatement(stmt *ast.ClassStatement, env *Environment) Object {
 	class := &Class{
 		Name:    stmt.Name,
 		Methods: make(map[string]Object),
 		Fields:  make(map[string]ast.DataType),
+		Private: make(map[string]bool),
 	}
 
 	if stmt.Parent != "" {
@@ -695,8 +1467,19 @@ func (i *Interpreter) evalClassStatement(stmt *ast.ClassStatement, env *Environm
 	for _, member := range stmt.Members {
 		switch m := member.(type) {
 		case *ast.DeclareStatement:
-			class.Fields[m.Name.Value] = m.DataType
+			if m.Value != nil {
+				return &Error{Message: fmt.Sprintf("field %s of class %s cannot be given a value in its declaration; assign it in NEW", m.Names[0].Value, stmt.Name)}
+			}
+			for _, name := range m.Names {
+				class.Fields[name.Value] = m.DataType
+				if m.Access == "PRIVATE" {
+					class.Private[name.Value] = true
+				}
+			}
 		case *ast.ProcedureStatement:
+			if m.Access == "PRIVATE" {
+				class.Private[m.Name] = true
+			}
 			proc := &Procedure{
 				Name:       m.Name,
 				Parameters: m.Parameters,
@@ -705,6 +1488,9 @@ func (i *Interpreter) evalClassStatement(stmt *ast.ClassStatement, env *Environm
 			}
 			class.Methods[m.Name] = proc
 		case *ast.FunctionStatement:
+			if m.Access == "PRIVATE" {
+				class.Private[m.Name] = true
+			}
 			fn := &Function{
 				Name:       m.Name,
 				Parameters: m.Parameters,
@@ -744,12 +1530,14 @@ func (i *Interpreter) evalExpression(expr ast.Expression, env *Environment) Obje
 	case *ast.StringLiteral:
 		return &String{Value: expr.Value}
 	case *ast.CharLiteral:
-		if len(expr.Value) > 0 {
-			return &Char{Value: rune(expr.Value[0])}
+		if r, size := utf8.DecodeRuneInString(expr.Value); size > 0 {
+			return &Char{Value: r}
 		}
 		return &Char{Value: ' '}
 	case *ast.BooleanLiteral:
 		return &Boolean{Value: expr.Value}
+	case *ast.NullLiteral:
+		return &Null{}
 	case *ast.Identifier:
 		return i.evalIdentifier(expr, env)
 	case *ast.PrefixExpression:
@@ -758,14 +1546,26 @@ func (i *Interpreter) evalExpression(expr ast.Expression, env *Environment) Obje
 		return i.evalInfixExpression(expr, env)
 	case *ast.ArrayAccess:
 		return i.evalArrayAccess(expr, env)
+	case *ast.SliceExpression:
+		slice, err := i.evalSlice(expr, env)
+		if err != nil {
+			return err
+		}
+		return slice.Sub
 	case *ast.MemberAccess:
 		return i.evalMemberAccess(expr, env)
 	case *ast.CallExpression:
-		return i.evalCallExpression(expr, env)
+		result := i.evalCallExpression(expr, env)
+		if nv, ok := result.(*NoValue); ok {
+			return &Error{Message: fmt.Sprintf("procedure %s does not return a value", nv.Procedure)}
+		}
+		return result
 	case *ast.NewExpression:
 		return i.evalNewExpression(expr, env)
 	case *ast.SuperExpression:
 		return i.evalSuperExpression(expr, env)
+	case *ast.SetLiteral:
+		return i.evalSetLiteral(expr, env)
 	default:
 		return &Error{Message: fmt.Sprintf("unknown expression type: %T", expr)}
 	}
@@ -825,32 +1625,205 @@ func (i *Interpreter) evalInfixExpression(expr *ast.InfixExpression, env *Enviro
 		return left
 	}
 
+	// AND and OR only evaluate the right operand when the left one does not
+	// already decide the result
+	if b, ok := left.(*Boolean); ok {
+		if (expr.Operator == "AND" && !b.Value) || (expr.Operator == "OR" && b.Value) {
+			return &Boolean{Value: b.Value}
+		}
+	}
+
 	right := i.evalExpression(expr.Right, env)
 	if isError(right) {
 		return right
 	}
 
+	if isComparison(expr.Operator) {
+		if l, r, ok := charOperands(left, right); ok {
+			left, right = l, r
+		}
+	}
+
 	switch {
+	case expr.Operator == "IN":
+		return i.evalMembership(left, right)
+	case isOrdering(expr.Operator) && isChainedComparison(left, right):
+		// 1 < x < 10 parses as (1 < x) < 10
+		return &Error{Message: fmt.Sprintf("cannot compare %s with %s — did you mean to use AND?", left.Type(), right.Type())}
 	case left.Type() == INTEGER_OBJ && right.Type() == INTEGER_OBJ:
 		return i.evalIntegerInfixExpression(expr.Operator, left, right)
+	case i.charArithmetic && (expr.Operator == "+" || expr.Operator == "-") &&
+		(left.Type() == CHAR_OBJ || right.Type() == CHAR_OBJ):
+		return evalCharArithmetic(expr.Operator, left, right)
 	case left.Type() == REAL_OBJ || right.Type() == REAL_OBJ:
 		return i.evalRealInfixExpression(expr.Operator, left, right)
 	case left.Type() == STRING_OBJ && right.Type() == STRING_OBJ:
 		return i.evalStringInfixExpression(expr.Operator, left, right)
 	case left.Type() == BOOLEAN_OBJ && right.Type() == BOOLEAN_OBJ:
 		return i.evalBooleanInfixExpression(expr.Operator, left, right)
+	case left.Type() == DATE_OBJ && right.Type() == DATE_OBJ && isComparison(expr.Operator):
+		return evalDateComparison(expr.Operator, left.(*Date), right.(*Date))
 	case expr.Operator == "&":
 		// String concatenation - convert operands to strings
 		return i.evalConcatenation(left, right)
 	case expr.Operator == "=":
-		return &Boolean{Value: i.objectsEqual(left, right)}
+		return &Boolean{Value: ObjectsEqual(left, right)}
 	case expr.Operator == "<>":
-		return &Boolean{Value: !i.objectsEqual(left, right)}
+		return &Boolean{Value: !ObjectsEqual(left, right)}
 	default:
 		return &Error{Message: fmt.Sprintf("type mismatch: %s %s %s", left.Type(), expr.Operator, right.Type())}
 	}
 }
 
+// intPow computes base ^ exp for a non-negative exponent by squaring. It
+// reports false if the result does not fit in an INTEGER.
+func intPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	ok := true
+	for exp > 0 {
+		if exp&1 == 1 {
+			if result, ok = mulInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// mulInt64 multiplies a and b, reporting false if the product overflows
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// isOrdering reports whether op is one of the ordering comparisons
+func isOrdering(op string) bool {
+	switch op {
+	case "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+// isComparison reports whether op compares its operands
+func isComparison(op string) bool {
+	return op == "=" || op == "<>" || isOrdering(op)
+}
+
+// charOperands returns both operands of a comparison as strings when one is a
+// CHAR and the other a CHAR or a STRING of exactly one character, so that
+// ch = "A" compares the characters rather than failing on the differing types
+func charOperands(left, right Object) (*String, *String, bool) {
+	if left.Type() != CHAR_OBJ && right.Type() != CHAR_OBJ {
+		return nil, nil, false
+	}
+	l, lok := charString(left)
+	r, rok := charString(right)
+	return l, r, lok && rok
+}
+
+func charString(obj Object) (*String, bool) {
+	switch o := obj.(type) {
+	case *Char:
+		return &String{Value: string(o.Value)}, true
+	case *String:
+		return o, utf8.RuneCountInString(o.Value) == 1
+	}
+	return nil, false
+}
+
+// isChainedComparison reports whether a comparison has a BOOLEAN on one side
+// and a number on the other, which is what a chained comparison evaluates to
+func isChainedComparison(left, right Object) bool {
+	isNumber := func(obj Object) bool {
+		return obj.Type() == INTEGER_OBJ || obj.Type() == REAL_OBJ
+	}
+	return (left.Type() == BOOLEAN_OBJ && isNumber(right)) || (isNumber(left) && right.Type() == BOOLEAN_OBJ)
+}
+
+// evalMembership evaluates value IN collection, where collection is a set
+// or an array
+func (i *Interpreter) evalMembership(value, collection Object) Object {
+	switch c := collection.(type) {
+	case *Set:
+		return &Boolean{Value: i.setContains(c, value)}
+	case *Array:
+		for _, elem := range c.Elements {
+			if ObjectsEqual(elem, value) {
+				return &Boolean{Value: true}
+			}
+		}
+		return &Boolean{Value: false}
+	default:
+		return &Error{Message: fmt.Sprintf("right operand of IN must be a SET or ARRAY, got %s", collection.Type())}
+	}
+}
+
+func (i *Interpreter) evalSetLiteral(expr *ast.SetLiteral, env *Environment) Object {
+	set := &Set{}
+	for _, e := range expr.Elements {
+		value := i.evalExpression(e, env)
+		if isError(value) {
+			return value
+		}
+		if !i.setContains(set, value) {
+			set.Elements = append(set.Elements, value)
+		}
+	}
+	return set
+}
+
+// evalCharArithmetic adds an INTEGER to a CHAR or subtracts one from it,
+// giving a CHAR, or subtracts two CHARs, giving an INTEGER
+func evalCharArithmetic(op string, left, right Object) Object {
+	mismatch := &Error{Message: fmt.Sprintf("type mismatch: %s %s %s", left.Type(), op, right.Type())}
+
+	var code int64
+	switch l := left.(type) {
+	case *Char:
+		switch r := right.(type) {
+		case *Integer:
+			if op == "+" {
+				code = int64(l.Value) + r.Value
+			} else {
+				code = int64(l.Value) - r.Value
+			}
+		case *Char:
+			if op != "-" {
+				return mismatch
+			}
+			return &Integer{Value: int64(l.Value) - int64(r.Value)}
+		default:
+			return mismatch
+		}
+	case *Integer:
+		r, ok := right.(*Char)
+		if !ok || op != "+" {
+			return mismatch
+		}
+		code = l.Value + int64(r.Value)
+	default:
+		return mismatch
+	}
+
+	if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		return &Error{Message: fmt.Sprintf("character code %d is out of range", code)}
+	}
+	return &Char{Value: rune(code)}
+}
+
 func (i *Interpreter) evalIntegerInfixExpression(op string, left, right Object) Object {
 	leftVal := left.(*Integer).Value
 	rightVal := right.(*Integer).Value
@@ -877,6 +1850,15 @@ func (i *Interpreter) evalIntegerInfixExpression(op string, left, right Object)
 			return &Error{Message: "division by zero"}
 		}
 		return &Integer{Value: leftVal % rightVal}
+	case "^":
+		if rightVal < 0 {
+			return &Real{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		result, ok := intPow(leftVal, rightVal)
+		if !ok {
+			return &Error{Message: fmt.Sprintf("integer overflow: %d ^ %d", leftVal, rightVal)}
+		}
+		return &Integer{Value: result}
 	case "<":
 		return &Boolean{Value: leftVal < rightVal}
 	case ">":
@@ -923,6 +1905,10 @@ func (i *Interpreter) evalRealInfixExpression(op string, left, right Object) Obj
 			return &Error{Message: "division by zero"}
 		}
 		return &Real{Value: leftVal / rightVal}
+	case "^":
+		return &Real{Value: math.Pow(leftVal, rightVal)}
+	case "DIV", "MOD":
+		return &Error{Message: "DIV/MOD require INTEGER operands"}
 	case "<":
 		return &Boolean{Value: leftVal < rightVal}
 	case ">":
@@ -964,6 +1950,39 @@ func (i *Interpreter) evalStringInfixExpression(op string, left, right Object) O
 	}
 }
 
+// evalDateComparison compares two dates, earlier dates ordering first
+func evalDateComparison(op string, left, right *Date) Object {
+	l := [3]int{left.Year, left.Month, left.Day}
+	r := [3]int{right.Year, right.Month, right.Day}
+
+	cmp := 0
+	for idx := range l {
+		if l[idx] != r[idx] {
+			if l[idx] < r[idx] {
+				cmp = -1
+			} else {
+				cmp = 1
+			}
+			break
+		}
+	}
+
+	switch op {
+	case "=":
+		return &Boolean{Value: cmp == 0}
+	case "<>":
+		return &Boolean{Value: cmp != 0}
+	case "<":
+		return &Boolean{Value: cmp < 0}
+	case ">":
+		return &Boolean{Value: cmp > 0}
+	case "<=":
+		return &Boolean{Value: cmp <= 0}
+	default: // ">="
+		return &Boolean{Value: cmp >= 0}
+	}
+}
+
 func (i *Interpreter) evalBooleanInfixExpression(op string, left, right Object) Object {
 	leftVal := left.(*Boolean).Value
 	rightVal := right.(*Boolean).Value
@@ -1013,6 +2032,31 @@ func (i *Interpreter) evalArrayAccess(expr *ast.ArrayAccess, env *Environment) O
 	if isError(arr) {
 		return arr
 	}
+	return i.elementValue(expr, arr, env)
+}
+
+// elementValue returns the element of arr, a string, dictionary or array,
+// selected by the indices of expr
+func (i *Interpreter) elementValue(expr *ast.ArrayAccess, arr Object, env *Environment) Object {
+	if str, ok := arr.(*String); ok {
+		runes := []rune(str.Value)
+		pos, err := i.stringIndex(runes, expr.Indices, env)
+		if err != nil {
+			return err
+		}
+		return &Char{Value: runes[pos]}
+	}
+
+	if dict, ok := arr.(*Dictionary); ok {
+		key, err := i.dictionaryKey(dict, expr.Indices, env)
+		if err != nil {
+			return err
+		}
+		if val, ok := dict.Entries[key]; ok {
+			return val
+		}
+		return &Error{Message: fmt.Sprintf("key %s not found in dictionary", key)}
+	}
 
 	array, ok := arr.(*Array)
 	if !ok {
@@ -1037,28 +2081,123 @@ func (i *Interpreter) evalArrayAccess(expr *ast.ArrayAccess, env *Environment) O
 		return val
 	}
 
+	// Record elements are created on first access so that arr[i].field can
+	// be assigned without assigning arr[i] first
+	if _, ok := array.ElementType.(*ast.CustomType); ok {
+		if !array.InBounds(indices...) {
+			return &Error{Message: fmt.Sprintf("array index [%s] out of bounds", key)}
+		}
+		if rec, ok := i.newValue(array.ElementType, env).(*Record); ok {
+			array.Elements[key] = rec
+			return rec
+		}
+	}
+
 	return &Null{}
 }
 
+// dictionaryKey evaluates the single key of a dictionary access and returns
+// the form it is stored under
+func (i *Interpreter) dictionaryKey(dict *Dictionary, indices []ast.Expression, env *Environment) (string, Object) {
+	if len(indices) != 1 {
+		return "", &Error{Message: fmt.Sprintf("dictionary access takes 1 key, got %d", len(indices))}
+	}
+
+	key := i.evalExpression(indices[0], env)
+	if isError(key) {
+		return "", key
+	}
+	stored, ok := dict.Key(key)
+	if !ok {
+		return "", &Error{Message: fmt.Sprintf("dictionary key must be %s, got %s", dict.KeyType, key.Type())}
+	}
+	return stored, nil
+}
+
+// evalSlice copies elements start to end of a one-dimensional array into a
+// new array indexed from 1
+func (i *Interpreter) evalSlice(expr *ast.SliceExpression, env *Environment) (*arraySlice, Object) {
+	arr := i.evalExpression(expr.Array, env)
+	if isError(arr) {
+		return nil, arr
+	}
+
+	array, ok := arr.(*Array)
+	if !ok {
+		return nil, &Error{Message: fmt.Sprintf("cannot slice %s", arr.Type())}
+	}
+	if len(array.Dimensions) != 1 {
+		return nil, &Error{Message: "only one-dimensional arrays can be sliced"}
+	}
+
+	var bounds [2]int64
+	for n, e := range []ast.Expression{expr.Start, expr.End} {
+		val := i.evalExpression(e, env)
+		if isError(val) {
+			return nil, val
+		}
+		intVal, ok := val.(*Integer)
+		if !ok {
+			return nil, &Error{Message: "array slice bounds must be integers"}
+		}
+		bounds[n] = intVal.Value
+	}
+
+	start, end := bounds[0], bounds[1]
+	dim := array.Dimensions[0]
+	if start > end || start < int64(dim.Lower) || end > int64(dim.Upper) {
+		return nil, &Error{Message: fmt.Sprintf("array slice %d..%d out of bounds %d:%d", start, end, dim.Lower, dim.Upper)}
+	}
+
+	length := end - start + 1
+	sub := &Array{
+		Elements:       make(map[string]Object),
+		Dimensions:     []ast.ArrayDimension{{Lower: 1, Upper: int(length)}},
+		ElementType:    array.ElementType,
+		RecordElements: array.RecordElements,
+	}
+	for n := int64(1); n <= length; n++ {
+		if val, ok := array.Elements[array.GetIndex(start+n-1)]; ok {
+			sub.Elements[sub.GetIndex(n)] = val
+		}
+	}
+
+	return &arraySlice{Source: array, Start: start, Length: length, Sub: sub}, nil
+}
+
 func (i *Interpreter) evalMemberAccess(expr *ast.MemberAccess, env *Environment) Object {
 	obj := i.evalExpression(expr.Object, env)
 	if isError(obj) {
 		return obj
 	}
+	return i.memberValue(expr, obj, false, env)
+}
 
+// memberValue returns the member of obj named by expr. With create set, an
+// unassigned field is first given its initial value by createField.
+func (i *Interpreter) memberValue(expr *ast.MemberAccess, obj Object, create bool, env *Environment) Object {
 	switch o := obj.(type) {
 	case *Record:
 		if val, ok := o.Fields[expr.Member]; ok {
+			if create {
+				return i.createField(o.Fields, expr.Member, o.FieldTypes[expr.Member], env)
+			}
 			return val
 		}
 		return &Error{Message: fmt.Sprintf("field not found: %s", expr.Member)}
 	case *Instance:
+		if err := i.checkAccess(o, expr.Member, env); err != nil {
+			return err
+		}
 		if val, ok := o.Fields[expr.Member]; ok {
+			if create {
+				return i.createField(o.Fields, expr.Member, o.Class.fieldType(expr.Member), env)
+			}
 			return val
 		}
 		// Look up method in class hierarchy
-		if method := i.lookupMethod(o.Class, expr.Member); method != nil {
-			return &BoundMethod{Instance: o, Method: method}
+		if method, owner := i.lookupMethod(o.Class, expr.Member); method != nil {
+			return &BoundMethod{Instance: o, Method: method, Class: owner}
 		}
 		return &Error{Message: fmt.Sprintf("member not found: %s", expr.Member)}
 	case *Super:
@@ -1066,8 +2205,8 @@ func (i *Interpreter) evalMemberAccess(expr *ast.MemberAccess, env *Environment)
 		if o.Class == nil {
 			return &Error{Message: "no parent class"}
 		}
-		if method := i.lookupMethod(o.Class, expr.Member); method != nil {
-			return &BoundMethod{Instance: o.Instance, Method: method}
+		if method, owner := i.lookupMethod(o.Class, expr.Member); method != nil {
+			return &BoundMethod{Instance: o.Instance, Method: method, Class: owner}
 		}
 		return &Error{Message: fmt.Sprintf("method not found in parent class: %s", expr.Member)}
 	default:
@@ -1075,14 +2214,47 @@ func (i *Interpreter) evalMemberAccess(expr *ast.MemberAccess, env *Environment)
 	}
 }
 
-// lookupMethod searches for a method in the class hierarchy
-func (i *Interpreter) lookupMethod(class *Class, name string) Object {
+// createField returns the value of a field, first storing a new, empty value
+// in it if it is unassigned and declared as an ARRAY, DICTIONARY or record
+func (i *Interpreter) createField(fields map[string]Object, name string, dataType ast.DataType, env *Environment) Object {
+	val := fields[name]
+	if _, ok := val.(*Null); !ok || dataType == nil {
+		return val
+	}
+
+	switch dataType.(type) {
+	case *ast.ArrayType, *ast.DictionaryType, *ast.CustomType:
+		created := i.newValue(dataType, env)
+		if isError(created) {
+			return created
+		}
+		if _, ok := created.(*Null); !ok {
+			fields[name] = created
+			return created
+		}
+	}
+	return val
+}
+
+// checkAccess reports an error if member is PRIVATE and env is not inside a
+// method of the class declaring it. Subclasses have no access.
+func (i *Interpreter) checkAccess(instance *Instance, member string, env *Environment) Object {
+	owner := instance.Class.privateOwner(member)
+	if owner == nil || env.methodClass() == owner {
+		return nil
+	}
+	return &Error{Message: fmt.Sprintf("cannot access private member %s", member)}
+}
+
+// lookupMethod searches for a method in the class hierarchy, returning it
+// with the class that defines it
+func (i *Interpreter) lookupMethod(class *Class, name string) (Object, *Class) {
 	for c := class; c != nil; c = c.Parent {
 		if method, ok := c.Methods[name]; ok {
-			return method
+			return method, c
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 func (i *Interpreter) evalCallExpression(expr *ast.CallExpression, env *Environment) Object {
@@ -1091,12 +2263,115 @@ func (i *Interpreter) evalCallExpression(expr *ast.CallExpression, env *Environm
 		return fn
 	}
 
-	args := i.evalExpressions(expr.Arguments, env)
+	args, slices := i.evalArguments(expr.Arguments, env)
 	if len(args) == 1 && isError(args[0]) {
 		return args[0]
 	}
 
-	return i.applyFunction(fn, args, env)
+	passed := append([]Object(nil), args...)
+	result := i.applyFunction(fn, args, env)
+	if !isError(result) {
+		copyBackSlices(fn, slices)
+		if err := i.copyBackByRef(fn, expr.Arguments, passed, args, env); err != nil {
+			return err
+		}
+	}
+	return result
+}
+
+// evalArguments evaluates call arguments like evalExpressions, also
+// returning the source of each argument that is an array slice (nil for
+// other arguments) so BYREF slices can be copied back after the call
+func (i *Interpreter) evalArguments(exprs []ast.Expression, env *Environment) ([]Object, []*arraySlice) {
+	args := make([]Object, 0, len(exprs))
+	slices := make([]*arraySlice, len(exprs))
+
+	for idx, e := range exprs {
+		if se, ok := e.(*ast.SliceExpression); ok {
+			slice, err := i.evalSlice(se, env)
+			if err != nil {
+				return []Object{err}, nil
+			}
+			slices[idx] = slice
+			args = append(args, slice.Sub)
+			continue
+		}
+
+		evaluated := i.evalExpression(e, env)
+		if isError(evaluated) {
+			return []Object{evaluated}, nil
+		}
+		args = append(args, evaluated)
+	}
+
+	return args, slices
+}
+
+// parametersOf returns the parameter list of a function, procedure or
+// method, or nil for anything else
+func parametersOf(fn Object) []ast.Parameter {
+	switch f := fn.(type) {
+	case *Function:
+		return f.Parameters
+	case *Procedure:
+		return f.Parameters
+	case *BoundMethod:
+		return parametersOf(f.Method)
+	}
+	return nil
+}
+
+// copyBackSlices writes the elements of slices passed to BYREF parameters
+// back into the arrays they were taken from. Slices passed by value are
+// independent copies and are discarded.
+func copyBackSlices(fn Object, slices []*arraySlice) {
+	params := parametersOf(fn)
+	for idx, slice := range slices {
+		if slice == nil || idx >= len(params) || !params[idx].ByRef {
+			continue
+		}
+		for n := int64(1); n <= slice.Length; n++ {
+			key := slice.Source.GetIndex(slice.Start + n - 1)
+			if val, ok := slice.Sub.Elements[slice.Sub.GetIndex(n)]; ok {
+				slice.Source.Elements[key] = val
+			} else {
+				delete(slice.Source.Elements, key)
+			}
+		}
+	}
+}
+
+// copyBackByRef assigns the value a BYREF parameter was given during the
+// call back to the variable, element or field passed for it. passed holds
+// the arguments as they were before the call and args as storeByRef left
+// them; parameters the call never assigned are skipped.
+func (i *Interpreter) copyBackByRef(fn Object, exprs []ast.Expression, passed, args []Object, env *Environment) Object {
+	params := parametersOf(fn)
+	for idx, expr := range exprs {
+		if idx >= len(params) || idx >= len(args) || !params[idx].ByRef || args[idx] == passed[idx] {
+			continue
+		}
+		switch expr.(type) {
+		case *ast.Identifier, *ast.ArrayAccess, *ast.MemberAccess:
+			if result := i.assign(expr, args[idx], env); isError(result) {
+				return result
+			}
+		}
+	}
+	return nil
+}
+
+// storeByRef replaces each argument passed to a BYREF parameter with the
+// value of the parameter in env when the call returns
+func storeByRef(params []ast.Parameter, args []Object, env *Environment) {
+	for idx, param := range params {
+		if idx >= len(args) || !param.ByRef {
+			continue
+		}
+		if val, ok := env.store[param.Name]; ok {
+			args[idx] = val
+		}
+	}
 }
 
 func (i *Interpreter) evalExpressions(exprs []ast.Expression, env *Environment) []Object {
@@ -1114,21 +2389,34 @@ func (i *Interpreter) evalExpressions(exprs []ast.Expression, env *Environment)
 }
 
 func (i *Interpreter) applyFunction(fn Object, args []Object, callerEnv *Environment) Object {
+	if _, ok := fn.(*Builtin); !ok {
+		if i.maxCallDepth > 0 && i.callDepth >= i.maxCallDepth {
+			return &Error{Message: "maximum recursion depth exceeded"}
+		}
+		i.callDepth++
+		defer func() { i.callDepth-- }()
+	}
+
 	switch fn := fn.(type) {
 	case *Function:
 		extendedEnv := i.extendFunctionEnv(fn, args, fn.Parameters, callerEnv)
 		evaluated := i.evalStatements(fn.Body, extendedEnv)
-		return i.unwrapReturnValue(evaluated)
+		storeByRef(fn.Parameters, args, extendedEnv)
+		return i.functionResult(fn, evaluated)
 
 	case *Procedure:
 		extendedEnv := i.extendFunctionEnv(&Function{Env: fn.Env}, args, fn.Parameters, callerEnv)
 		evaluated := i.evalStatements(fn.Body, extendedEnv)
-		return i.unwrapReturnValue(evaluated)
+		storeByRef(fn.Parameters, args, extendedEnv)
+		return i.procedureResult(fn, evaluated)
 
 	case *BoundMethod:
 		return i.applyBoundMethod(fn, args, callerEnv)
 
 	case *Builtin:
+		if fn.ProgramFn != nil {
+			return fn.ProgramFn(i.args, args...)
+		}
 		return fn.Fn(args...)
 
 	default:
@@ -1137,39 +2425,39 @@ func (i *Interpreter) applyFunction(fn Object, args []Object, callerEnv *Environ
 }
 
 func (i *Interpreter) applyBoundMethod(bm *BoundMethod, args []Object, callerEnv *Environment) Object {
-	// Create a method environment that has access to instance fields and methods
-	methodEnv := i.createMethodEnv(bm.Instance, callerEnv)
-
 	switch method := bm.Method.(type) {
 	case *Function:
-		// Add parameters to the environment
-		for idx, param := range method.Parameters {
-			if idx < len(args) {
-				methodEnv.Declare(param.Name, args[idx])
-			}
-		}
+		// Create a method environment that has access to instance fields and methods
+		methodEnv := i.createMethodEnv(bm, method.Env)
+
+		bindParameters(methodEnv, method.Parameters, args)
 		evaluated := i.evalStatements(method.Body, methodEnv)
-		return i.unwrapReturnValue(evaluated)
+		storeByRef(method.Parameters, args, methodEnv)
+		return i.functionResult(method, evaluated)
 
 	case *Procedure:
-		// Add parameters to the environment
-		for idx, param := range method.Parameters {
-			if idx < len(args) {
-				methodEnv.Declare(param.Name, args[idx])
-			}
-		}
+		methodEnv := i.createMethodEnv(bm, method.Env)
+
+		bindParameters(methodEnv, method.Parameters, args)
 		evaluated := i.evalStatements(method.Body, methodEnv)
-		return i.unwrapReturnValue(evaluated)
+		storeByRef(method.Parameters, args, methodEnv)
+		return i.procedureResult(method, evaluated)
 
 	default:
 		return &Error{Message: "invalid method type"}
 	}
 }
 
-// createMethodEnv creates an environment for method execution with access to instance fields and class methods
-func (i *Interpreter) createMethodEnv(instance *Instance, callerEnv *Environment) *Environment {
-	// Create a new environment enclosed by the caller's environment
-	env := NewEnclosedEnvironment(callerEnv)
+// createMethodEnv creates an environment for method execution with access to instance fields and class methods.
+// Names resolve to parameters and local declarations first, then to fields of
+// the instance, then to the scope the class was defined in; the locals of the
+// calling code are never visible. this.Name always refers to the field.
+func (i *Interpreter) createMethodEnv(bm *BoundMethod, definitionEnv *Environment) *Environment {
+	instance := bm.Instance
+
+	// Create a new environment enclosed by the class's environment
+	env := NewEnclosedEnvironment(definitionEnv)
+	env.isolated = i.strictScoping
 
 	// Set instance reference so field access/assignment goes through the instance
 	env.instance = instance
@@ -1177,14 +2465,20 @@ func (i *Interpreter) createMethodEnv(instance *Instance, callerEnv *Environment
 	// Bind "this" to the instance for explicit self-reference
 	env.Declare("this", instance)
 
-	// Bind SUPER if there's a parent class
-	if instance.Class.Parent != nil {
-		env.Declare("SUPER", &Super{Instance: instance, Class: instance.Class.Parent})
+	// Bind SUPER to the parent of the class defining the method, so that
+	// SUPER.NEW in each constructor of a hierarchy moves one level up
+	owner := bm.Class
+	if owner == nil {
+		owner = instance.Class
+	}
+	env.class = owner
+	if owner.Parent != nil {
+		env.Declare("SUPER", &Super{Instance: instance, Class: owner.Parent})
 	}
 
 	// Bind class methods as bound methods (so GetName() works without this. prefix)
 	for name, method := range instance.Class.Methods {
-		env.Declare(name, &BoundMethod{Instance: instance, Method: method})
+		env.Declare(name, &BoundMethod{Instance: instance, Method: method, Class: instance.Class})
 	}
 
 	// Also bind inherited methods
@@ -1192,7 +2486,7 @@ func (i *Interpreter) createMethodEnv(instance *Instance, callerEnv *Environment
 		for name, method := range parent.Methods {
 			// Don't override if already defined (child methods take precedence)
 			if _, exists := env.store[name]; !exists {
-				env.Declare(name, &BoundMethod{Instance: instance, Method: method})
+				env.Declare(name, &BoundMethod{Instance: instance, Method: method, Class: parent})
 			}
 		}
 	}
@@ -1202,27 +2496,52 @@ func (i *Interpreter) createMethodEnv(instance *Instance, callerEnv *Environment
 
 func (i *Interpreter) extendFunctionEnv(fn *Function, args []Object, params []ast.Parameter, callerEnv *Environment) *Environment {
 	env := NewEnclosedEnvironment(fn.Env)
+	env.isolated = i.strictScoping
+	bindParameters(env, params, args)
+	return env
+}
 
+// bindParameters declares each parameter in env with its argument. BYVAL
+// records and arrays get their own copy; BYREF arguments are shared, and
+// copied back by copyBackByRef if the call assigns the parameter.
+func bindParameters(env *Environment, params []ast.Parameter, args []Object) {
 	for idx, param := range params {
-		if idx < len(args) {
-			if param.ByRef {
-				// For BYREF, we need to create a reference
-				// This is a simplified implementation
-				env.Declare(param.Name, args[idx])
-			} else {
-				env.Declare(param.Name, args[idx])
-			}
+		if idx >= len(args) {
+			continue
 		}
+		arg := args[idx]
+		if !param.ByRef {
+			arg = CopyObject(arg)
+		}
+		env.DeclareWithType(param.Name, param.DataType, arg)
+	}
+}
+
+// functionResult extracts the value returned by a function body and checks it
+// against the declared return type, widening INTEGER to REAL
+func (i *Interpreter) functionResult(fn *Function, evaluated Object) Object {
+	if isError(evaluated) {
+		return evaluated
 	}
 
-	return env
+	rv, ok := evaluated.(*ReturnValue)
+	if !ok {
+		return &Error{Message: fmt.Sprintf("function %s reached end without RETURN", fn.Name)}
+	}
+
+	if value, ok := ConvertValue(fn.ReturnType, rv.Value); ok {
+		return value
+	}
+	return &Error{Message: fmt.Sprintf("function %s must return %s, got %s", fn.Name, fn.ReturnType, rv.Value.Type())}
 }
 
-func (i *Interpreter) unwrapReturnValue(obj Object) Object {
-	if rv, ok := obj.(*ReturnValue); ok {
-		return rv.Value
+// procedureResult passes errors from a procedure body through and otherwise
+// returns NoValue, since procedures do not produce a value
+func (i *Interpreter) procedureResult(proc *Procedure, evaluated Object) Object {
+	if isError(evaluated) {
+		return evaluated
 	}
-	return obj
+	return &NoValue{Procedure: proc.Name}
 }
 
 func (i *Interpreter) evalNewExpression(expr *ast.NewExpression, env *Environment) Object {
@@ -1244,23 +2563,16 @@ func (i *Interpreter) evalNewExpression(expr *ast.NewExpression, env *Environmen
 	// Initialize fields from entire class hierarchy
 	i.initializeInstanceFields(instance, class)
 
-	// Call constructor (NEW procedure) if exists
-	if constructor, ok := class.Methods["NEW"]; ok {
+	// Call the constructor (NEW procedure) if the class or an ancestor has one
+	if constructor, owner := i.lookupMethod(class, "NEW"); constructor != nil {
 		args := i.evalExpressions(expr.Arguments, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
 
-		// Create method environment with proper instance context
-		ctorEnv := i.createMethodEnv(instance, env)
-
-		if proc, ok := constructor.(*Procedure); ok {
-			for idx, param := range proc.Parameters {
-				if idx < len(args) {
-					ctorEnv.Declare(param.Name, args[idx])
-				}
-			}
-			i.evalStatements(proc.Body, ctorEnv)
+		result := i.applyFunction(&BoundMethod{Instance: instance, Method: constructor, Class: owner}, args, env)
+		if isError(result) {
+			return result
 		}
 	}
 
@@ -1287,6 +2599,27 @@ func (i *Interpreter) evalSuperExpression(expr *ast.SuperExpression, env *Enviro
 	return &Error{Message: "SUPER can only be used within a class method"}
 }
 
+// OpenFiles returns the files that are currently open, sorted by name
+func (i *Interpreter) OpenFiles() []FileInfo {
+	infos := make([]FileInfo, 0, len(i.files))
+	for name, fs := range i.files {
+		infos = append(infos, FileInfo{Name: name, Mode: fs.mode, EOF: fs.atEOF})
+	}
+	sort.Slice(infos, func(a, b int) bool { return infos[a].Name < infos[b].Name })
+	return infos
+}
+
+// Globals returns the variables, constants, procedures, functions and
+// classes defined at the top level, sorted by name
+func (i *Interpreter) Globals() []Binding {
+	bindings := make([]Binding, 0, len(i.env.store))
+	for name, val := range i.env.store {
+		bindings = append(bindings, Binding{Name: name, Value: val, Constant: i.env.constants[name]})
+	}
+	sort.Slice(bindings, func(a, b int) bool { return bindings[a].Name < bindings[b].Name })
+	return bindings
+}
+
 // IsEOF checks if file is at EOF
 func (i *Interpreter) IsEOF(filename string) bool {
 	fs, ok := i.files[filename]
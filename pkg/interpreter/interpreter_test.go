@@ -2,9 +2,14 @@ package interpreter
 
 import (
 	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
 	"github.com/andrinoff/cambridge-lang/pkg/lexer"
 	"github.com/andrinoff/cambridge-lang/pkg/parser"
 )
@@ -24,6 +29,15 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"DECLARE x : INTEGER\nx <- 10 MOD 3", 1},
 		{"DECLARE x : INTEGER\nx <- 2 + 3 * 4", 14},
 		{"DECLARE x : INTEGER\nx <- (2 + 3) * 4", 20},
+		{"DECLARE x : INTEGER\nx <- 2 ^ 8", 256},
+		{"DECLARE x : INTEGER\nx <- 2 ^ 3 ^ 2", 512},
+		{"DECLARE x : INTEGER\nx <- 3 * 2 ^ 2", 12},
+		{"DECLARE x : INTEGER\nx <- -2 ^ 2", -4},
+		{"DECLARE x : INTEGER\nx <- 7 ^ 0", 1},
+		{"DECLARE x : INTEGER\nx <- 2 ^ 62", 1 << 62},
+		{"DECLARE x : INTEGER\nx <- (-2) ^ 63", math.MinInt64},
+		{"DECLARE x : INTEGER\nx <- 3 ^ 39", 4052555153018976267},
+		{"DECLARE x : INTEGER\nx <- 1 ^ 1000000", 1},
 	}
 
 	for _, tt := range tests {
@@ -41,6 +55,9 @@ func TestEvalRealExpression(t *testing.T) {
 		{"DECLARE x : REAL\nx <- 2.5 + 2.5", 5.0},
 		{"DECLARE x : REAL\nx <- 10.0 / 4.0", 2.5},
 		{"DECLARE x : REAL\nx <- 5 / 2", 2.5}, // Integer division returns real
+		{"DECLARE x : REAL\nx <- 2 ^ -1", 0.5},
+		{"DECLARE x : REAL\nx <- 2.0 ^ 3", 8.0},
+		{"DECLARE x : REAL\nx <- 9 ^ 0.5", 3.0},
 	}
 
 	for _, tt := range tests {
@@ -76,6 +93,32 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestShortCircuitEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		// The right operands would fail with a division by zero or an
+		// out of range index if they were evaluated
+		{"DECLARE x : INTEGER\nx <- 0\nx <> 0 AND 10 DIV x > 1", false},
+		{"DECLARE x : INTEGER\nx <- 0\nx = 0 OR 10 DIV x > 1", true},
+		{`DECLARE arr : ARRAY[1:3] OF INTEGER
+DECLARE i : INTEGER
+i <- 4
+i <= 3 AND arr[i] = 0`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+
+	evaluated := testEval("DECLARE x : INTEGER\nx <- 0\nx = 0 AND 10 DIV x > 1")
+	if _, ok := evaluated.(*Error); !ok {
+		t.Errorf("expected the right operand to be evaluated, got %T (%+v)", evaluated, evaluated)
+	}
+}
+
 func TestEvalStringExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -158,6 +201,72 @@ PI <- 3.0`
 	}
 }
 
+func TestConstantFromConstants(t *testing.T) {
+	input := `CONSTANT A = 5
+CONSTANT B = A * 2 + 1
+CONSTANT C = -B
+C`
+
+	testIntegerObject(t, testEval(input), -11)
+}
+
+func TestConstantFromNonConstant(t *testing.T) {
+	tests := []string{
+		"DECLARE x : INTEGER\nx <- 3\nCONSTANT B = x * 2",
+		"CONSTANT B = Missing + 1",
+		"CONSTANT B = LENGTH(\"abc\")",
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("%q: expected error, got %T (%+v)", input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != "constant must be defined from literals or constants" {
+			t.Errorf("%q: wrong error message: %s", input, errObj.Message)
+		}
+	}
+}
+
+func TestEvalWithSeed(t *testing.T) {
+	input := `DECLARE Total : INTEGER
+Total <- 0
+FOR Index <- 1 TO Size
+    Total <- Total + Scores[Index]
+NEXT Index
+Total`
+
+	scores := &Array{
+		Elements:   map[string]Object{},
+		Dimensions: []ast.ArrayDimension{{Lower: 1, Upper: 3}},
+	}
+	scores.Elements["1"] = &Integer{Value: 4}
+	scores.Elements["2"] = &Integer{Value: 5}
+	scores.Elements["3"] = &Integer{Value: 6}
+
+	i := New()
+	program := parser.New(lexer.New(input)).ParseProgram()
+	evaluated := i.EvalWith(program, map[string]Object{
+		"Scores": scores,
+		"Size":   &Constant{Value: &Integer{Value: 3}},
+	})
+
+	testIntegerObject(t, evaluated, 15)
+}
+
+func TestEvalWithSeedConstantImmutable(t *testing.T) {
+	program := parser.New(lexer.New("Size <- 10")).ParseProgram()
+	evaluated := New().EvalWith(program, map[string]Object{
+		"Size": &Constant{Value: &Integer{Value: 3}},
+	})
+
+	if _, ok := evaluated.(*Error); !ok {
+		t.Errorf("expected error when modifying seeded constant, got %T", evaluated)
+	}
+}
+
 func TestIfStatement(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -262,6 +371,100 @@ ENDCASE`, 0},
 	}
 }
 
+func TestStrictCase(t *testing.T) {
+	input := `DECLARE grade : INTEGER
+grade <- 7
+CASE OF grade
+    1 : OUTPUT "one"
+    2 : OUTPUT "two"
+ENDCASE
+OUTPUT "after"`
+
+	run := func(strict bool) (Object, string) {
+		var out bytes.Buffer
+		i := New()
+		i.SetOutput(&out)
+		i.SetStrictCase(strict)
+		return i.Eval(parser.New(lexer.New(input)).ParseProgram()), out.String()
+	}
+
+	result, output := run(false)
+	if isError(result) {
+		t.Fatalf("unexpected error without strict case: %s", result.Inspect())
+	}
+	if output != "after\n" {
+		t.Errorf("expected the program to continue, got %q", output)
+	}
+
+	result, output = run(true)
+	errObj, ok := result.(*Error)
+	if !ok {
+		t.Fatalf("expected error with strict case, got %T (%+v)", result, result)
+	}
+	if errObj.Message != "no matching case for value 7" {
+		t.Errorf("wrong error message: %s", errObj.Message)
+	}
+	if output != "" {
+		t.Errorf("expected the program to stop at the CASE, got %q", output)
+	}
+
+	// OTHERWISE still catches unmatched values
+	i := New()
+	i.SetOutput(&bytes.Buffer{})
+	i.SetStrictCase(true)
+	result = i.Eval(parser.New(lexer.New("CASE OF 7\n    1 : OUTPUT 1\n    OTHERWISE : OUTPUT 0\nENDCASE")).ParseProgram())
+	if isError(result) {
+		t.Errorf("unexpected error with OTHERWISE: %s", result.Inspect())
+	}
+}
+
+func TestCaseUnmatchedWarning(t *testing.T) {
+	input := `DECLARE grade : INTEGER
+grade <- 7
+CASE OF grade
+    1 : OUTPUT "one"
+    2 : OUTPUT "two"
+ENDCASE`
+
+	i := New()
+	i.SetOutput(&bytes.Buffer{})
+	i.SetWarnUnmatchedCase(true)
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	result := i.Eval(p.ParseProgram())
+
+	if _, ok := result.(*Null); !ok {
+		t.Fatalf("expected Null result, got %T (%+v)", result, result)
+	}
+
+	warnings := i.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(warnings))
+	}
+
+	if warnings[0].Message != "no CASE clause matched value 7" {
+		t.Errorf("unexpected warning message: %q", warnings[0].Message)
+	}
+	if warnings[0].Line != 3 {
+		t.Errorf("expected warning on line 3, got %d", warnings[0].Line)
+	}
+}
+
+func TestCaseUnmatchedSilentByDefault(t *testing.T) {
+	input := `DECLARE grade : INTEGER
+grade <- 7
+CASE OF grade
+    1 : grade <- 0
+ENDCASE`
+
+	i := setupInterpreter(input)
+
+	if len(i.Warnings()) != 0 {
+		t.Errorf("expected no warnings, got %v", i.Warnings())
+	}
+}
+
 func TestForStatement(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -290,6 +493,118 @@ NEXT i`, 15},
 	}
 }
 
+func TestForBoundsEvaluatedOnce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`DECLARE Limit : INTEGER
+DECLARE Count : INTEGER
+Limit <- 3
+Count <- 0
+FOR i <- 1 TO Limit
+    Limit <- Limit + 1
+    Count <- Count + 1
+NEXT i
+Count`, 3},
+		{`DECLARE Increment : INTEGER
+DECLARE Count : INTEGER
+Increment <- 1
+Count <- 0
+FOR i <- 1 TO 4 STEP Increment
+    Increment <- 10
+    Count <- Count + 1
+NEXT i
+Count`, 4},
+		{`DECLARE Count : INTEGER
+Count <- 0
+FOR i <- 1 TO 5
+    i <- 5
+    Count <- Count + 1
+NEXT i
+Count`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestRealForStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{`DECLARE sum : REAL
+sum <- 0
+FOR x <- 0 TO 1 STEP 0.25
+    sum <- sum + x
+NEXT x`, 2.5}, // 0, 0.25, 0.5, 0.75, 1
+		{`DECLARE sum : REAL
+sum <- 0
+FOR x <- 0 TO 1 STEP 0.1
+    sum <- sum + 1.0
+NEXT x`, 11},
+		{`DECLARE sum : REAL
+sum <- 0
+FOR x <- 2 TO 1 STEP -0.5
+    sum <- sum + x
+NEXT x`, 4.5}, // 2, 1.5, 1
+		{`DECLARE sum : REAL
+sum <- 0
+FOR x <- 0.5 TO 3
+    sum <- sum + x
+NEXT x`, 4.5}, // 0.5, 1.5, 2.5
+	}
+
+	for _, tt := range tests {
+		i := setupInterpreter(tt.input)
+		obj, ok := i.env.Get("sum")
+		if !ok {
+			t.Fatal("variable sum not found")
+		}
+		testRealObject(t, obj, tt.expected)
+	}
+}
+
+func TestForStepErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FOR i <- 1 TO 5 STEP 0
+    OUTPUT i
+NEXT i`, "FOR loop STEP cannot be zero"},
+		{`DECLARE s : INTEGER
+s <- 0
+FOR i <- 1 TO 5 STEP s
+    OUTPUT i
+NEXT i`, "FOR loop STEP cannot be zero"},
+		{`FOR i <- 1 TO 5 STEP 0.0
+    OUTPUT i
+NEXT i`, "FOR loop STEP cannot be zero"},
+		{`FOR i <- 1 TO 5 STEP "a"
+    OUTPUT i
+NEXT i`, "FOR loop STEP must be a number"},
+		{`FOR i <- "a" TO 5
+    OUTPUT i
+NEXT i`, "FOR loop start must be a number"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error, got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
 func TestWhileStatement(t *testing.T) {
 	input := `DECLARE x : INTEGER
 DECLARE sum : INTEGER
@@ -326,6 +641,184 @@ UNTIL x > 5`
 	testIntegerObject(t, obj, 15)
 }
 
+func TestRepeatConditionSeesBodyChanges(t *testing.T) {
+	input := `PROCEDURE CountUp()
+    DECLARE Count : INTEGER
+    Count <- 0
+    REPEAT
+        IF Count < 10 THEN
+            Count <- Count + 1
+        ENDIF
+        Total <- Count
+    UNTIL Count = 3
+ENDPROCEDURE
+DECLARE Total : INTEGER
+CALL CountUp()
+Total`
+
+	evaluated := testEval(input)
+	testIntegerObject(t, evaluated, 3)
+}
+
+func TestStuckRepeatLoopWarning(t *testing.T) {
+	// The condition only reads done, which the body never assigns. The
+	// RETURN is there so the test terminates.
+	input := `FUNCTION Spin() RETURNS INTEGER
+    DECLARE done : BOOLEAN
+    DECLARE count : INTEGER
+    done <- FALSE
+    REPEAT
+        count <- count + 1
+        IF count = 3 THEN
+            RETURN count
+        ENDIF
+    UNTIL done
+ENDFUNCTION
+
+DECLARE result : INTEGER
+result <- Spin()`
+
+	i := New()
+	i.SetDetectStuckLoops(true)
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	testIntegerObject(t, i.Eval(p.ParseProgram()), 3)
+
+	warnings := i.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d: %v", len(warnings), warnings)
+	}
+
+	expected := "loop condition variables unchanged — possible infinite loop"
+	if warnings[0].Message != expected {
+		t.Errorf("expected %q, got %q", expected, warnings[0].Message)
+	}
+	if warnings[0].Line != 5 {
+		t.Errorf("expected warning on line 5, got %d", warnings[0].Line)
+	}
+}
+
+func TestProgressingLoopNotWarned(t *testing.T) {
+	input := `DECLARE x : INTEGER
+x <- 0
+WHILE x < 5
+    x <- x + 1
+ENDWHILE
+REPEAT
+    x <- x - 1
+UNTIL x = 0`
+
+	i := New()
+	i.SetDetectStuckLoops(true)
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	i.Eval(p.ParseProgram())
+
+	if len(i.Warnings()) != 0 {
+		t.Errorf("expected no warnings, got %v", i.Warnings())
+	}
+}
+
+func TestMaxIterations(t *testing.T) {
+	tests := []struct {
+		input string
+		runs  int64
+	}{
+		{`DECLARE Count : INTEGER
+WHILE TRUE
+    Count <- Count + 1
+ENDWHILE`, 100},
+		{`DECLARE Count : INTEGER
+REPEAT
+    Count <- Count + 1
+UNTIL FALSE`, 100},
+	}
+
+	for _, tt := range tests {
+		i := New()
+		i.SetMaxIterations(100)
+
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		result := i.Eval(p.ParseProgram())
+
+		errObj, ok := result.(*Error)
+		if !ok {
+			t.Fatalf("expected error, got %T (%+v)", result, result)
+		}
+		expected := "loop exceeded 100 iterations (possible infinite loop)"
+		if errObj.Message != expected {
+			t.Errorf("expected %q, got %q", expected, errObj.Message)
+		}
+
+		count, _ := i.env.Get("Count")
+		testIntegerObject(t, count, tt.runs)
+	}
+}
+
+func TestMaxIterationsAllowsFiniteLoops(t *testing.T) {
+	input := `DECLARE Count : INTEGER
+WHILE Count < 100
+    Count <- Count + 1
+ENDWHILE
+REPEAT
+    Count <- Count - 1
+UNTIL Count = 0
+Count`
+
+	i := New()
+	i.SetMaxIterations(100)
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	testIntegerObject(t, i.Eval(p.ParseProgram()), 0)
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	input := `FUNCTION Forever(N : INTEGER) RETURNS INTEGER
+    RETURN Forever(N + 1)
+ENDFUNCTION
+DECLARE x : INTEGER
+x <- Forever(1)`
+
+	for _, limit := range []int{0, 50} {
+		i := New()
+		if limit > 0 {
+			i.SetMaxCallDepth(limit)
+		}
+
+		l := lexer.New(input)
+		p := parser.New(l)
+		result := i.Eval(p.ParseProgram())
+
+		errObj, ok := result.(*Error)
+		if !ok {
+			t.Fatalf("expected error, got %T (%+v)", result, result)
+		}
+		if errObj.Message != "maximum recursion depth exceeded" {
+			t.Errorf("unexpected error message: %q", errObj.Message)
+		}
+		if i.callDepth != 0 {
+			t.Errorf("call depth not unwound, got %d", i.callDepth)
+		}
+	}
+}
+
+func TestMaxCallDepthAllowsDeepRecursion(t *testing.T) {
+	input := `FUNCTION Sum(N : INTEGER) RETURNS INTEGER
+    IF N = 0 THEN
+        RETURN 0
+    ENDIF
+    RETURN N + Sum(N - 1)
+ENDFUNCTION
+DECLARE x : INTEGER
+x <- Sum(1000)`
+
+	testIntegerObject(t, testEval(input), 500500)
+}
+
 func TestProcedure(t *testing.T) {
 	input := `DECLARE result : INTEGER
 result <- 0
@@ -352,6 +845,104 @@ CALL SetValue()`
 	}
 }
 
+func TestStrictScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Assigning a global inside a procedure makes a local copy
+		{`DECLARE Total : INTEGER
+Total <- 1
+PROCEDURE Change()
+    Total <- Total + 41
+    OUTPUT Total
+ENDPROCEDURE
+CALL Change()
+OUTPUT Total`, "42\n1\n"},
+		// The same applies to functions and FOR loop variables
+		{`DECLARE i : INTEGER
+i <- 7
+FUNCTION SumTo(n : INTEGER) RETURNS INTEGER
+    DECLARE s : INTEGER
+    s <- 0
+    FOR i <- 1 TO n
+        s <- s + i
+    NEXT i
+    RETURN s
+ENDFUNCTION
+OUTPUT SumTo(3), " ", i`, "6 7\n"},
+		// Array elements are still changed through the shared array
+		{`DECLARE Scores : ARRAY[1:2] OF INTEGER
+PROCEDURE Fill()
+    Scores[1] <- 5
+ENDPROCEDURE
+CALL Fill()
+OUTPUT Scores[1]`, "5\n"},
+		// BYREF parameters change the caller's variable
+		{`DECLARE g : INTEGER
+g <- 1
+PROCEDURE Q(BYREF x : INTEGER)
+    x <- 7
+ENDPROCEDURE
+CALL Q(g)
+OUTPUT g`, "7\n"},
+		{`DECLARE Scores : ARRAY[1:2] OF INTEGER
+PROCEDURE Swap(BYREF a : INTEGER, BYREF b : INTEGER)
+    DECLARE t : INTEGER
+    t <- a
+    a <- b
+    b <- t
+ENDPROCEDURE
+Scores[1] <- 1
+Scores[2] <- 2
+CALL Swap(Scores[1], Scores[2])
+OUTPUT Scores[1], Scores[2]`, "21\n"},
+		// Methods still assign their own fields
+		{`CLASS Counter
+    PRIVATE Count : INTEGER
+    PUBLIC PROCEDURE NEW()
+        Count <- 0
+    ENDPROCEDURE
+    PUBLIC PROCEDURE Increment()
+        Count <- Count + 1
+    ENDPROCEDURE
+    PUBLIC FUNCTION GetCount() RETURNS INTEGER
+        RETURN Count
+    ENDFUNCTION
+ENDCLASS
+DECLARE c : Counter
+c <- NEW Counter()
+CALL c.Increment()
+CALL c.Increment()
+OUTPUT c.GetCount()`, "2\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		i := New()
+		i.SetOutput(&buf)
+		i.SetStrictScoping(true)
+
+		result := i.Eval(parser.New(lexer.New(tt.input)).ParseProgram())
+		if isError(result) {
+			t.Errorf("unexpected error: %s", result.Inspect())
+			continue
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, buf.String())
+		}
+	}
+
+	// Constants stay protected
+	i := New()
+	i.SetStrictScoping(true)
+	result := i.Eval(parser.New(lexer.New("CONSTANT Max = 10\nPROCEDURE Bump()\n    Max <- 11\nENDPROCEDURE\nCALL Bump()")).ParseProgram())
+	errObj, ok := result.(*Error)
+	if !ok || errObj.Message != "cannot modify constant: Max" {
+		t.Errorf("expected constant error, got %T (%+v)", result, result)
+	}
+}
+
 func TestProcedureWithParameters(t *testing.T) {
 	input := `DECLARE result : INTEGER
 result <- 0
@@ -398,6 +989,37 @@ result <- Factorial(5)`
 	testIntegerObject(t, evaluated, 120)
 }
 
+func TestNestedCallables(t *testing.T) {
+	input := `FUNCTION Outer(n : INTEGER) RETURNS INTEGER
+    DECLARE Base : INTEGER
+    Base <- 10
+    FUNCTION Inner(k : INTEGER) RETURNS INTEGER
+        RETURN Base + n + k
+    ENDFUNCTION
+    PROCEDURE Bump()
+        Base <- Base + 1
+    ENDPROCEDURE
+    CALL Bump()
+    RETURN Inner(1)
+ENDFUNCTION
+
+DECLARE result : INTEGER
+result <- Outer(5) * 100 + Outer(2)`
+
+	// Each call of Outer gets a fresh Base, which Bump changes and Inner reads
+	evaluated := testEval(input)
+	testIntegerObject(t, evaluated, 1714)
+
+	evaluated = testEval(input + "\nresult <- Inner(1)")
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("expected nested function to be hidden outside Outer, got %T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: Inner" {
+		t.Errorf("wrong error message: %s", errObj.Message)
+	}
+}
+
 func TestArrayOperations(t *testing.T) {
 	input := `DECLARE arr : ARRAY[1:5] OF INTEGER
 arr[1] <- 10
@@ -423,18 +1045,682 @@ result <- matrix[1, 1] + matrix[2, 2]`
 	testIntegerObject(t, evaluated, 5)
 }
 
-func TestRecordType(t *testing.T) {
-	input := `TYPE Person
-    DECLARE name : STRING
-    DECLARE age : INTEGER
-ENDTYPE
-
-DECLARE p : Person
-p.name <- "John"
-p.age <- 30`
+func TestDeclareWithValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"DECLARE Count : INTEGER <- 5\nCount", int64(5)},
+		{"DECLARE Total : REAL <- 3\nTotal", 3.0},
+		{"DECLARE Count <- 2 * 21\nCount", int64(42)},
+		{"DECLARE Name <- \"Ada\"\nName", "Ada"},
+		{"DECLARE Count : INTEGER\nCount", int64(0)},
+	}
 
-	i := setupInterpreter(input)
-	obj, ok := i.env.Get("p")
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case float64:
+			testRealObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+
+	// An inferred type is enforced like a declared one on INPUT
+	i := New()
+	i.SetInput(strings.NewReader("abc\n"))
+	evaluated := i.Eval(parser.New(lexer.New("DECLARE Count <- 1\nINPUT Count")).ParseProgram())
+	errObj, ok := evaluated.(*Error)
+	if !ok || errObj.Message != "expected INTEGER, got 'abc'" {
+		t.Errorf("expected INPUT to enforce the inferred INTEGER type, got %T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestDeclareMultipleNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"DECLARE a, b, c : INTEGER\na <- 1\nb <- 2\nc <- 3\na + b + c", 6},
+		{"DECLARE a, b, c : INTEGER\nb", 0},
+		{"DECLARE Low, High : INTEGER <- 7\nLow + High", 14},
+		// Arrays declared together are separate arrays
+		{"DECLARE Row, Col : ARRAY[1:3] OF INTEGER\nRow[1] <- 5\nCol[1] <- 9\nRow[1]", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestCharStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`'A' = "A"`, true},
+		{`"A" = 'A'`, true},
+		{`'A' = "B"`, false},
+		{`'A' <> "B"`, true},
+		{`'A' <> "A"`, false},
+		{`'A' < "B"`, true},
+		{`"Z" <= 'A'`, false},
+		{`'b' >= 'a'`, true},
+		{`'A' = "AB"`, false},
+		{`'A' <> ""`, true},
+		{"DECLARE Letter : CHAR\nLetter <- 'Q'\nLetter = \"Q\"", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval(`'A' < "AB"`)
+	errObj, ok := evaluated.(*Error)
+	if !ok || errObj.Message != "type mismatch: CHAR < STRING" {
+		t.Errorf("expected type mismatch ordering a CHAR against a longer STRING, got %T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestCharArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"'A' + 1", 'B'},
+		{"2 + 'a'", 'c'},
+		{"'z' - 25", 'a'},
+		{"'D' - 'A'", int64(3)},
+		{"'a' - 'b'", int64(-1)},
+		{"DECLARE ch : CHAR\nch <- 'x'\nch <- ch + 1\nch", 'y'},
+	}
+
+	for _, tt := range tests {
+		i := New()
+		i.SetCharArithmetic(true)
+		evaluated := i.Eval(parser.New(lexer.New(tt.input)).ParseProgram())
+
+		switch expected := tt.expected.(type) {
+		case rune:
+			char, ok := evaluated.(*Char)
+			if !ok || char.Value != expected {
+				t.Errorf("%q: expected CHAR %q, got %T (%+v)", tt.input, expected, evaluated, evaluated)
+			}
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"OUTPUT 1 DIV 0", 1, 1},
+		{"DECLARE x : INTEGER\n  OUTPUT x DIV 0", 2, 3},
+		// Errors inside a body are reported at the statement that raised them
+		{"PROCEDURE Fail()\n    OUTPUT 1 DIV 0\nENDPROCEDURE\nCALL Fail()", 2, 5},
+		{"FOR i <- 1 TO 3\n    OUTPUT Missing\nNEXT i", 2, 5},
+		// Assignments and bare calls are reported at their first token
+		{"DECLARE x : INTEGER\n  x <- 1 DIV 0", 2, 3},
+		{"PROCEDURE Fail(n : INTEGER)\n    OUTPUT n\nENDPROCEDURE\n  Fail(1 DIV 0)", 4, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("%q: expected error, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Line != tt.line || errObj.Column != tt.column {
+			t.Errorf("%q: expected error at line %d, column %d, got line %d, column %d",
+				tt.input, tt.line, tt.column, errObj.Line, errObj.Column)
+		}
+	}
+}
+
+func TestCharArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"'A' + 'B'", "type mismatch: CHAR + CHAR"},
+		{"1 - 'A'", "type mismatch: INTEGER - CHAR"},
+		{"'A' * 2", "type mismatch: CHAR * INTEGER"},
+		{"'A' + 1.5", "type mismatch: CHAR + REAL"},
+		{"'A' - 100", "character code -35 is out of range"},
+	}
+
+	for _, tt := range tests {
+		i := New()
+		i.SetCharArithmetic(true)
+		evaluated := i.Eval(parser.New(lexer.New(tt.input)).ParseProgram())
+
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("%q: expected error, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+
+	// Without the option CHAR arithmetic is a type mismatch
+	evaluated := testEval("'A' + 1")
+	errObj, ok := evaluated.(*Error)
+	if !ok || errObj.Message != "type mismatch: CHAR + INTEGER" {
+		t.Errorf("expected type mismatch by default, got %T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestDeclareWithValueErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`DECLARE Count : INTEGER <- "five"`, "cannot initialize Count : INTEGER with STRING"},
+		{`DECLARE Count : INTEGER <- 2.5`, "cannot initialize Count : INTEGER with REAL"},
+		{`DECLARE Count <- NULL`, "cannot infer the type of Count from NULL; declare it with a type"},
+		{`CLASS Pet
+    PRIVATE DECLARE Name : STRING <- "Rex"
+ENDCLASS`, "field Name of class Pet cannot be given a value in its declaration; assign it in NEW"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestArrayConstantBounds(t *testing.T) {
+	input := `CONSTANT Size = 5
+DECLARE arr : ARRAY[1:Size] OF INTEGER
+arr[Size] <- 42
+arr`
+
+	evaluated := testEval(input)
+	arr, ok := evaluated.(*Array)
+	if !ok {
+		t.Fatalf("expected *Array, got %T (%+v)", evaluated, evaluated)
+	}
+	if arr.Dimensions[0].Lower != 1 || arr.Dimensions[0].Upper != 5 {
+		t.Errorf("dimension wrong. expected 1:5, got %d:%d",
+			arr.Dimensions[0].Lower, arr.Dimensions[0].Upper)
+	}
+
+	evaluated = testEval("CONSTANT Size = \"5\"\nDECLARE arr : ARRAY[1:Size] OF INTEGER")
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("expected error, got %T (%+v)", evaluated, evaluated)
+	}
+	expected := "array bound Size must be an INTEGER, got STRING"
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	input := `DECLARE Stock : DICTIONARY OF STRING TO INTEGER
+Stock["apple"] <- 3
+Stock["pear"] <- Stock["apple"] + 4
+Stock["apple"] <- 5
+Stock`
+
+	evaluated := testEval(input)
+	dict, ok := evaluated.(*Dictionary)
+	if !ok {
+		t.Fatalf("expected *Dictionary, got %T (%+v)", evaluated, evaluated)
+	}
+	if len(dict.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(dict.Entries))
+	}
+	testIntegerObject(t, dict.Entries[`"apple"`], 5)
+	testIntegerObject(t, dict.Entries[`"pear"`], 7)
+
+	evaluated = testEval(`DECLARE Names : DICTIONARY OF INTEGER TO STRING
+Names[7] <- "seven"
+Names[7]`)
+	testStringObject(t, evaluated, "seven")
+}
+
+func TestDictionaryErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"DECLARE d : DICTIONARY OF STRING TO INTEGER\nd[\"kiwi\"]",
+			`key "kiwi" not found in dictionary`,
+		},
+		{
+			"DECLARE d : DICTIONARY OF STRING TO INTEGER\nd[1] <- 2",
+			"dictionary key must be STRING, got INTEGER",
+		},
+		{
+			"DECLARE d : DICTIONARY OF STRING TO INTEGER\nd[\"a\", \"b\"] <- 2",
+			"dictionary access takes 1 key, got 2",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestStringIndexing(t *testing.T) {
+	input := `DECLARE Name : STRING
+Name <- "Hello"
+Name[2]`
+
+	evaluated := testEval(input)
+	ch, ok := evaluated.(*Char)
+	if !ok {
+		t.Fatalf("expected Char, got %T (%+v)", evaluated, evaluated)
+	}
+	if ch.Value != 'e' {
+		t.Errorf("expected 'e', got %q", ch.Value)
+	}
+}
+
+func TestStringIndexAssignment(t *testing.T) {
+	input := `DECLARE Name : STRING
+DECLARE Original : STRING
+Name <- "Hello"
+Original <- Name
+Name[1] <- 'J'
+Name[5] <- "y"
+Name & " " & Original`
+
+	evaluated := testEval(input)
+	testStringObject(t, evaluated, "Jelly Hello")
+}
+
+func TestStringIndexErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`DECLARE s : STRING
+s <- "abc"
+s[0]`, "string index 0 out of range 1 to 3"},
+		{`DECLARE s : STRING
+s <- "abc"
+s[4]`, "string index 4 out of range 1 to 3"},
+		{`DECLARE s : STRING
+s <- "abc"
+s[4] <- 'x'`, "string index 4 out of range 1 to 3"},
+		{`DECLARE s : STRING
+s <- "abc"
+s[1] <- 5`, "cannot assign INTEGER to a string index"},
+		{`DECLARE s : STRING
+s <- "abc"
+s[1, 2]`, "string index must be a single integer"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestDefineSet(t *testing.T) {
+	input := `TYPE LetterSet = SET OF CHAR
+DEFINE Vowels ('A', 'E', 'I', 'O', 'U', 'A') : LetterSet`
+
+	i := setupInterpreter(input)
+	obj, ok := i.env.Get("Vowels")
+	if !ok {
+		t.Fatal("set Vowels not found")
+	}
+
+	set, ok := obj.(*Set)
+	if !ok {
+		t.Fatalf("expected Set, got %T", obj)
+	}
+
+	if len(set.Elements) != 5 {
+		t.Errorf("expected 5 distinct elements, got %d", len(set.Elements))
+	}
+
+	if !i.setContains(set, &Char{Value: 'E'}) {
+		t.Error("expected set to contain 'E'")
+	}
+	if i.setContains(set, &Char{Value: 'B'}) {
+		t.Error("expected set not to contain 'B'")
+	}
+
+	if !i.env.isConstant("Vowels") {
+		t.Error("expected Vowels to be a constant")
+	}
+}
+
+func TestDefineSetErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`DEFINE Vowels ('A') : LetterSet`, "undefined type: LetterSet"},
+		{`TYPE Digits = SET OF INTEGER
+DEFINE Small ('A') : Digits`, "cannot add CHAR to SET OF INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error, got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestInOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"DECLARE b : BOOLEAN\nb <- 2 IN {1, 2, 3}", true},
+		{"DECLARE b : BOOLEAN\nb <- 5 IN {1, 2, 3}", false},
+		{"DECLARE b : BOOLEAN\nb <- \"cat\" IN {\"cat\", \"dog\"}", true},
+		{"DECLARE b : BOOLEAN\nb <- NOT (5 IN {1, 2, 3})", true},
+		{"DECLARE b : BOOLEAN\nb <- 1 + 1 IN {2} AND TRUE", true},
+		{`TYPE LetterSet = SET OF CHAR
+DEFINE Vowels ('A', 'E', 'I', 'O', 'U') : LetterSet
+DECLARE b : BOOLEAN
+b <- 'E' IN Vowels`, true},
+		{`TYPE LetterSet = SET OF CHAR
+DEFINE Vowels ('A', 'E', 'I', 'O', 'U') : LetterSet
+DECLARE b : BOOLEAN
+b <- 'B' IN Vowels`, false},
+		{`DECLARE arr : ARRAY[1:3] OF INTEGER
+arr[2] <- 7
+DECLARE b : BOOLEAN
+b <- 7 IN arr`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestInOperatorRequiresCollection(t *testing.T) {
+	evaluated := testEval("DECLARE b : BOOLEAN\nb <- 1 IN 5")
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("expected error, got %T (%+v)", evaluated, evaluated)
+	}
+	expected := "right operand of IN must be a SET or ARRAY, got INTEGER"
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
+func TestChainedComparisonError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"DECLARE x : INTEGER\nx <- 5\n1 < x < 10", "cannot compare BOOLEAN with INTEGER — did you mean to use AND?"},
+		{"DECLARE x : REAL\nx <- 5.5\n10.0 >= x >= 1.5", "cannot compare BOOLEAN with REAL — did you mean to use AND?"},
+		{"3 > (2 > 1)", "cannot compare INTEGER with BOOLEAN — did you mean to use AND?"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "a_input.txt")
+	output := filepath.Join(dir, "b_output.txt")
+	if err := os.WriteFile(input, []byte("line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i := setupInterpreter(fmt.Sprintf(`OPENFILE %q FOR READ
+OPENFILE %q FOR WRITE`, input, output))
+	defer func() {
+		for _, fs := range i.files {
+			fs.file.Close()
+		}
+	}()
+
+	files := i.OpenFiles()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 open files, got %d: %v", len(files), files)
+	}
+
+	expected := []FileInfo{
+		{Name: input, Mode: "READ"},
+		{Name: output, Mode: "WRITE"},
+	}
+	for idx, want := range expected {
+		if files[idx] != want {
+			t.Errorf("files[%d] wrong. expected=%+v, got=%+v", idx, want, files[idx])
+		}
+	}
+}
+
+func TestOpenFileAlreadyOpen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "log.txt")
+	if err := os.WriteFile(filename, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i := New()
+	program := parser.New(lexer.New(fmt.Sprintf(`OPENFILE %q FOR APPEND
+WRITEFILE %q, "second"
+OPENFILE %q FOR WRITE`, filename, filename, filename))).ParseProgram()
+	evaluated := i.Eval(program)
+	for _, fs := range i.files {
+		fs.file.Close()
+	}
+
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("expected error, got %T (%+v)", evaluated, evaluated)
+	}
+	expected := fmt.Sprintf("file %s is already open", filename)
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+
+	// The second OPENFILE must not have truncated the file
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "first\nsecond\n" {
+		t.Errorf("expected file to be left intact, got %q", string(content))
+	}
+}
+
+func TestWriteFileList(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "people.csv")
+	input := fmt.Sprintf(`DECLARE Age : INTEGER
+Age <- 36
+OPENFILE %[1]q FOR WRITE
+WRITEFILE %[1]q, "Ada", ",", Age
+WRITEFILE %[1]q, "Alan",
+WRITEFILE %[1]q, ",", 41
+CLOSEFILE %[1]q`, filename)
+
+	evaluated := testEval(input)
+	if isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.Inspect())
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Ada,36\nAlan,41\n"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, string(content))
+	}
+}
+
+func TestRandomFileRecords(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stock.dat")
+	input := fmt.Sprintf(`TYPE Item
+    DECLARE Name : STRING
+    DECLARE Qty : INTEGER
+    DECLARE InStock : BOOLEAN
+ENDTYPE
+DECLARE Bolt : Item
+DECLARE Found : Item
+OPENFILE %[1]q FOR RANDOM
+Bolt.Name <- "Bolt"
+Bolt.Qty <- 40
+Bolt.InStock <- TRUE
+SEEK %[1]q, 3
+PUTRECORD %[1]q, Bolt
+PUTRECORD %[1]q, 2.5
+SEEK %[1]q, 3
+GETRECORD %[1]q, Found
+CLOSEFILE %[1]q
+Found`, filename)
+
+	evaluated := testEval(input)
+	rec, ok := evaluated.(*Record)
+	if !ok {
+		t.Fatalf("expected *Record, got %T (%+v)", evaluated, evaluated)
+	}
+	if rec.TypeName != "Item" {
+		t.Errorf("expected record of type Item, got %s", rec.TypeName)
+	}
+	testStringObject(t, rec.Fields["Name"], "Bolt")
+	testIntegerObject(t, rec.Fields["Qty"], 40)
+	testBooleanObject(t, rec.Fields["InStock"], true)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 4*RecordSize {
+		t.Errorf("expected file of 4 records (%d bytes), got %d bytes", 4*RecordSize, info.Size())
+	}
+}
+
+func TestRandomFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"OPENFILE %[1]q FOR RANDOM\nDECLARE x : INTEGER\nSEEK %[1]q, 2\nGETRECORD %[1]q, x",
+			"no record at position 2",
+		},
+		{
+			"OPENFILE %[1]q FOR RANDOM\nSEEK %[1]q, 0",
+			"SEEK address must be a positive INTEGER, got 0",
+		},
+		{
+			"OPENFILE %[1]q FOR WRITE\nPUTRECORD %[1]q, 1",
+			"file not open for random access",
+		},
+		{
+			"OPENFILE %[1]q FOR RANDOM\nDECLARE a : ARRAY[1:2] OF INTEGER\nPUTRECORD %[1]q, a",
+			"cannot store ARRAY in a random file",
+		},
+	}
+
+	for idx, tt := range tests {
+		filename := filepath.Join(dir, fmt.Sprintf("test%d.dat", idx))
+		i := New()
+		evaluated := i.Eval(parser.New(lexer.New(fmt.Sprintf(tt.input, filename))).ParseProgram())
+		for _, fs := range i.files {
+			fs.file.Close()
+		}
+
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestArrayOfRecordsMarksRecordElements(t *testing.T) {
+	input := `TYPE P
+    DECLARE name : STRING
+ENDTYPE
+
+DECLARE ps : ARRAY[1:2] OF P
+DECLARE ns : ARRAY[1:2] OF INTEGER`
+
+	i := setupInterpreter(input)
+	for name, expected := range map[string]bool{"ps": true, "ns": false} {
+		obj, _ := i.env.Get(name)
+		arr, ok := obj.(*Array)
+		if !ok {
+			t.Fatalf("expected %s to be an Array, got %T", name, obj)
+		}
+		if arr.RecordElements != expected {
+			t.Errorf("%s: expected RecordElements %v, got %v", name, expected, arr.RecordElements)
+		}
+	}
+}
+
+func TestRecordType(t *testing.T) {
+	input := `TYPE Person
+    DECLARE name : STRING
+    DECLARE age : INTEGER
+ENDTYPE
+
+DECLARE p : Person
+p.name <- "John"
+p.age <- 30`
+
+	i := setupInterpreter(input)
+	obj, ok := i.env.Get("p")
 	if !ok {
 		t.Fatal("variable p not found")
 	}
@@ -459,6 +1745,89 @@ p.age <- 30`
 	}
 }
 
+func TestUnassignedRecordFieldStaysNull(t *testing.T) {
+	input := `TYPE Node
+    DECLARE Value : INTEGER
+    DECLARE Link : Node
+ENDTYPE
+DECLARE a : Node
+DECLARE WasNull : BOOLEAN
+WasNull <- a.Link = NULL
+a.Link.Value <- 2
+WasNull AND a.Link <> NULL AND a.Link.Link = NULL`
+
+	// Reading a field must not create it; assigning through it does
+	testBooleanObject(t, testEval(input), true)
+}
+
+func TestRecordAndArrayAssignmentCopies(t *testing.T) {
+	records := `TYPE Person
+    DECLARE Name : STRING
+    DECLARE Scores : ARRAY[1:2] OF INTEGER
+ENDTYPE
+DECLARE p1, p2 : Person
+p1.Name <- "Ann"
+p1.Scores[1] <- 5
+p2 <- p1
+p2.Name <- "Bob"
+p2.Scores[1] <- 9
+`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{records + `p1.Name & " " & p2.Name`, "Ann Bob"},
+		{records + `p1.Scores[1] * 10 + p2.Scores[1]`, "59"},
+		{`DECLARE a, b : ARRAY[1:3] OF INTEGER
+a[1] <- 1
+b <- a
+b[1] <- 2
+a[1] * 10 + b[1]`, "12"},
+		{`DECLARE a : ARRAY[1:3] OF INTEGER
+a[1] <- 1
+DECLARE b : ARRAY[1:3] OF INTEGER <- a
+b[1] <- 2
+a[1] * 10 + b[1]`, "12"},
+		// BYVAL parameters get their own copy, BYREF parameters share
+		{`TYPE P
+    DECLARE N : INTEGER
+ENDTYPE
+DECLARE r : P
+PROCEDURE Change(BYVAL x : P)
+    x.N <- 99
+ENDPROCEDURE
+r.N <- 1
+CALL Change(r)
+r.N`, "1"},
+		{`DECLARE a : ARRAY[1:3] OF INTEGER
+PROCEDURE Change(BYVAL x : ARRAY[1:3] OF INTEGER)
+    x[1] <- 99
+ENDPROCEDURE
+a[1] <- 1
+CALL Change(a)
+a[1]`, "1"},
+		{`DECLARE a : ARRAY[1:3] OF INTEGER
+PROCEDURE Change(BYREF x : ARRAY[1:3] OF INTEGER)
+    x[1] <- 99
+ENDPROCEDURE
+a[1] <- 1
+CALL Change(a)
+a[1]`, "99"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if isError(evaluated) {
+			t.Errorf("unexpected error: %s", evaluated.Inspect())
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestClass(t *testing.T) {
 	// Test simple class definition without instantiation to avoid potential issues
 	input := `CLASS Counter
@@ -485,6 +1854,161 @@ ENDCLASS`
 	}
 }
 
+func TestMethodLocalsShadowFields(t *testing.T) {
+	class := `CLASS Counter
+    PRIVATE DECLARE Count : INTEGER
+    PUBLIC PROCEDURE NEW()
+        Count <- 10
+    ENDPROCEDURE
+    PUBLIC FUNCTION Bump() RETURNS INTEGER
+        DECLARE Count : INTEGER
+        Count <- 1
+        this.Count <- this.Count + 5
+        RETURN Count * 100 + this.Count
+    ENDFUNCTION
+    PUBLIC FUNCTION Get() RETURNS INTEGER
+        RETURN Count
+    ENDFUNCTION
+    PUBLIC FUNCTION Peek() RETURNS INTEGER
+        DECLARE Hidden : INTEGER
+        Hidden <- 99
+        RETURN Leak()
+    ENDFUNCTION
+    PUBLIC FUNCTION Leak() RETURNS INTEGER
+        RETURN Hidden
+    ENDFUNCTION
+ENDCLASS
+DECLARE c : Counter
+c <- NEW Counter()
+`
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		// the local Count is separate from the field
+		{class + "c.Bump()", 115},
+		{class + "DECLARE x : INTEGER\nx <- c.Bump()\nc.Get()", 15},
+		// a global with the same name as a field does not hide it
+		{class + "DECLARE Count : INTEGER\nCount <- 3\nc.Get()", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+
+	// the locals of a calling method are not visible to the method it calls
+	evaluated := testEval(class + "c.Peek()")
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("expected error, got %T (%+v)", evaluated, evaluated)
+	}
+	if !strings.Contains(errObj.Message, "Hidden") {
+		t.Errorf("expected error about Hidden, got %q", errObj.Message)
+	}
+}
+
+func TestPrivateMembers(t *testing.T) {
+	class := `CLASS Account
+    PRIVATE DECLARE Balance : INTEGER
+    PUBLIC DECLARE Owner : STRING
+    PUBLIC PROCEDURE NEW(Amount : INTEGER)
+        this.Balance <- Amount
+    ENDPROCEDURE
+    PRIVATE FUNCTION Fee() RETURNS INTEGER
+        RETURN 1
+    ENDFUNCTION
+    PUBLIC FUNCTION Total() RETURNS INTEGER
+        RETURN this.Balance - this.Fee()
+    ENDFUNCTION
+    PUBLIC FUNCTION Richer(Other : Account) RETURNS BOOLEAN
+        RETURN Balance > Other.Balance
+    ENDFUNCTION
+ENDCLASS
+CLASS Savings INHERITS Account
+    PUBLIC FUNCTION Doubled() RETURNS INTEGER
+        RETURN this.Balance * 2
+    ENDFUNCTION
+ENDCLASS
+DECLARE A : Account
+A <- NEW Account(10)
+`
+	allowed := []struct {
+		input    string
+		expected interface{}
+	}{
+		{class + "A.Total()", int64(9)},
+		{class + "A.Owner <- \"Ann\"\nA.Owner", "Ann"},
+		{class + "DECLARE B : Account\nB <- NEW Account(5)\nA.Richer(B)", true},
+		{class + "DECLARE S : Savings\nS <- NEW Savings(4)\nS.Total()", int64(3)},
+	}
+
+	for _, tt := range allowed {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+
+	denied := []struct {
+		input    string
+		expected string
+	}{
+		{class + "A.Balance", "cannot access private member Balance"},
+		{class + "A.Balance <- 1000", "cannot access private member Balance"},
+		{class + "A.Fee()", "cannot access private member Fee"},
+		{class + "DECLARE S : Savings\nS <- NEW Savings(4)\nS.Doubled()", "cannot access private member Balance"},
+	}
+
+	for _, tt := range denied {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %T (%+v)", tt.expected, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestNullComparisons(t *testing.T) {
+	class := `CLASS Node
+    PUBLIC DECLARE Value : INTEGER
+    PUBLIC PROCEDURE NEW(v : INTEGER)
+        Value <- v
+    ENDPROCEDURE
+ENDCLASS
+DECLARE Head : Node
+DECLARE Other : Node
+DECLARE b : BOOLEAN
+`
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"NULL = NULL", true},
+		{"NULL <> NULL", false},
+		{"5 = NULL", false},
+		{class + "b <- Head = NULL", true},
+		{class + "Head <- NEW Node(1)\nb <- Head <> NULL", true},
+		{class + "Head <- NEW Node(1)\nOther <- Head\nb <- Other = Head", true},
+		{class + "Head <- NEW Node(1)\nOther <- NEW Node(1)\nb <- Other = Head", false},
+		{class + "b <- Head <> NULL AND Head.Value > 0", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
 func TestOutputStatement(t *testing.T) {
 	input := `OUTPUT "Hello, World!"`
 
@@ -504,6 +2028,77 @@ func TestOutputStatement(t *testing.T) {
 	}
 }
 
+func TestOutputDate(t *testing.T) {
+	input := `DECLARE d : DATE
+OUTPUT d
+OUTPUT "Due: ", Due`
+
+	var buf bytes.Buffer
+	i := New()
+	i.SetOutput(&buf)
+
+	program := parser.New(lexer.New(input)).ParseProgram()
+	result := i.EvalWith(program, map[string]Object{"Due": &Date{Day: 4, Month: 10, Year: 2003}})
+	if isError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+
+	expected := "01/01/1970\nDue: 04/10/2003\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestDateComparison(t *testing.T) {
+	seed := map[string]Object{
+		"Early": &Date{Day: 31, Month: 12, Year: 2023},
+		"Late":  &Date{Day: 1, Month: 1, Year: 2024},
+		"Same":  &Date{Day: 1, Month: 1, Year: 2024},
+	}
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"Late = Same", true},
+		{"Late <> Same", false},
+		{"Early = Late", false},
+		{"Early < Late", true},
+		{"Early > Late", false},
+		{"Late <= Same", true},
+		{"Late >= Early", true},
+		{"Late = 1", false},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		testBooleanObject(t, New().EvalWith(program, seed), tt.expected)
+	}
+
+	if !ObjectsEqual(seed["Late"], seed["Same"]) {
+		t.Error("expected equal dates to be ObjectsEqual")
+	}
+}
+
+func TestErrorOutput(t *testing.T) {
+	input := `OUTPUT "result"
+EPRINT "warning: ", 42
+EPRINT`
+
+	var out, errOut bytes.Buffer
+	i := New()
+	i.SetOutput(&out)
+	i.SetErrorOutput(&errOut)
+
+	i.Eval(parser.New(lexer.New(input)).ParseProgram())
+
+	if out.String() != "result\n" {
+		t.Errorf("expected output %q, got %q", "result\n", out.String())
+	}
+	if errOut.String() != "warning: 42\n\n" {
+		t.Errorf("expected error output %q, got %q", "warning: 42\n\n", errOut.String())
+	}
+}
+
 func TestOutputMultipleValues(t *testing.T) {
 	input := `OUTPUT "Value: ", 42`
 
@@ -523,6 +2118,27 @@ func TestOutputMultipleValues(t *testing.T) {
 	}
 }
 
+func TestOutputBlankLine(t *testing.T) {
+	input := `OUTPUT "a"
+OUTPUT
+OUTPUT "b"`
+
+	var buf bytes.Buffer
+	i := New()
+	i.SetOutput(&buf)
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	i.Eval(program)
+
+	output := buf.String()
+	expected := "a\n\nb\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
 func TestInputStatement(t *testing.T) {
 	input := `DECLARE name : STRING
 INPUT name`
@@ -550,6 +2166,96 @@ INPUT name`
 	}
 }
 
+func TestInputConvertsToDeclaredType(t *testing.T) {
+	tests := []struct {
+		program  string
+		input    string
+		expected interface{}
+	}{
+		{"DECLARE Age : INTEGER\nINPUT Age\nAge + 1", " 16 \n", int64(17)},
+		{"DECLARE Price : REAL\nINPUT Price\nPrice", "2.5\n", 2.5},
+		{"DECLARE Ok : BOOLEAN\nINPUT Ok\nOk", "true\n", true},
+		{"DECLARE Initial : CHAR\nINPUT Initial\nInitial", "J\n", 'J'},
+		{"DECLARE Name : STRING\nINPUT Name\nName", " Ann \n", " Ann "},
+		{"DECLARE Scores : ARRAY[1:2] OF INTEGER\nINPUT Scores[2]\nScores[2] * 2", "21\n", int64(42)},
+		{"FUNCTION Twice(n : REAL) RETURNS REAL\nINPUT n\nRETURN n * 2\nENDFUNCTION\nTwice(0)", "1.5\n", 3.0},
+		// every INPUT reads from the same buffered input
+		{"DECLARE a : INTEGER\nDECLARE b : INTEGER\nINPUT a\nINPUT b\na * b", "6\n7\n", int64(42)},
+	}
+
+	for _, tt := range tests {
+		i := New()
+		i.SetInput(strings.NewReader(tt.input))
+		i.SetOutput(&bytes.Buffer{})
+		evaluated := i.Eval(parser.New(lexer.New(tt.program)).ParseProgram())
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case float64:
+			testRealObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case rune:
+			ch, ok := evaluated.(*Char)
+			if !ok || ch.Value != expected {
+				t.Errorf("expected CHAR %q, got %T (%+v)", expected, evaluated, evaluated)
+			}
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestInputMultipleVariables(t *testing.T) {
+	input := `DECLARE Name : STRING
+DECLARE Age : INTEGER
+DECLARE Height : REAL
+INPUT Name, Age, Height
+OUTPUT Name, " ", Age + 1, " ", Height`
+
+	var buf bytes.Buffer
+	i := New()
+	i.SetInput(strings.NewReader("Ann Lee\n16\n1.7\n"))
+	i.SetOutput(&buf)
+	result := i.Eval(parser.New(lexer.New(input)).ParseProgram())
+	if isError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+
+	if buf.String() != "Ann Lee 17 1.7\n" {
+		t.Errorf("expected %q, got %q", "Ann Lee 17 1.7\n", buf.String())
+	}
+}
+
+func TestInputRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		program  string
+		input    string
+		expected string
+	}{
+		{"DECLARE Age : INTEGER\nINPUT Age", "abc\n", "expected INTEGER, got 'abc'"},
+		{"DECLARE Price : REAL\nINPUT Price", "\n", "expected REAL, got ''"},
+		{"DECLARE Ok : BOOLEAN\nINPUT Ok", "yes\n", "expected BOOLEAN, got 'yes'"},
+		{"DECLARE Initial : CHAR\nINPUT Initial", "Jo\n", "expected CHAR, got 'Jo'"},
+	}
+
+	for _, tt := range tests {
+		i := New()
+		i.SetInput(strings.NewReader(tt.input))
+		evaluated := i.Eval(parser.New(lexer.New(tt.program)).ParseProgram())
+
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error %q, got %T (%+v)", tt.expected, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
 func TestDivisionByZero(t *testing.T) {
 	tests := []string{
 		"DECLARE x : INTEGER\nx <- 5 DIV 0",
@@ -565,6 +2271,48 @@ func TestDivisionByZero(t *testing.T) {
 	}
 }
 
+func TestIntegerPowerOverflow(t *testing.T) {
+	tests := []string{
+		"2 ^ 63",
+		"2 ^ 64",
+		"3 ^ 50",
+		"(-3) ^ 41",
+		"10 ^ 19",
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("%q: expected error, got %T (%+v)", input, evaluated, evaluated)
+			continue
+		}
+		if !strings.HasPrefix(errObj.Message, "integer overflow") {
+			t.Errorf("%q: wrong error message: %s", input, errObj.Message)
+		}
+	}
+}
+
+func TestDivModRealOperands(t *testing.T) {
+	tests := []string{
+		"5.0 DIV 2",
+		"5 MOD 2.0",
+		"7.5 MOD 2.5",
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("%q: expected error, got %T (%+v)", input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != "DIV/MOD require INTEGER operands" {
+			t.Errorf("%q: wrong error message: %s", input, errObj.Message)
+		}
+	}
+}
+
 func TestUndefinedVariable(t *testing.T) {
 	input := `x <- 5`
 
@@ -677,6 +2425,117 @@ c <- 'A'`
 	}
 }
 
+func TestCharLiteralEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected rune
+	}{
+		{`'\n'`, '\n'},
+		{`'\t'`, '\t'},
+		{`'\''`, '\''},
+		{`'é'`, 'é'},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		charObj, ok := evaluated.(*Char)
+		if !ok {
+			t.Fatalf("%s: expected Char, got %T (%+v)", tt.input, evaluated, evaluated)
+		}
+		if charObj.Value != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, charObj.Value)
+		}
+	}
+}
+
+func TestFunctionReturnTypeChecked(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FUNCTION Foo() RETURNS INTEGER
+    RETURN "ten"
+ENDFUNCTION
+OUTPUT Foo()`, "function Foo must return INTEGER, got STRING"},
+		{`FUNCTION Foo(n : INTEGER) RETURNS INTEGER
+    IF n > 0 THEN
+        RETURN n
+    ENDIF
+ENDFUNCTION
+OUTPUT Foo(-1)`, "function Foo reached end without RETURN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error, got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestFunctionReturnWidensIntegerToReal(t *testing.T) {
+	input := `FUNCTION Half() RETURNS REAL
+    RETURN 2
+ENDFUNCTION
+DECLARE x : REAL
+x <- Half()`
+
+	evaluated := testEval(input)
+	testRealObject(t, evaluated, 2.0)
+}
+
+func TestProcedureUsedAsValue(t *testing.T) {
+	tests := []string{
+		`PROCEDURE Greet(Name : STRING)
+    OUTPUT "Hello, ", Name
+ENDPROCEDURE
+DECLARE x : STRING
+x <- Greet("World")`,
+		`PROCEDURE Greet(Name : STRING)
+    OUTPUT "Hello, ", Name
+ENDPROCEDURE
+OUTPUT "Result: " & Greet("World")`,
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("expected error, got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+		expected := "procedure Greet does not return a value"
+		if errObj.Message != expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+		}
+	}
+}
+
+func TestProcedureCalledAsStatement(t *testing.T) {
+	input := `PROCEDURE Greet(Name : STRING)
+    OUTPUT "Hello, ", Name
+ENDPROCEDURE
+Greet("World")
+CALL Greet("Again")`
+
+	var out bytes.Buffer
+	i := New()
+	i.SetOutput(&out)
+	result := i.Eval(parser.New(lexer.New(input)).ParseProgram())
+
+	if isError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+	if out.String() != "Hello, World\nHello, Again\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
 func TestReturnInFunction(t *testing.T) {
 	input := `FUNCTION Test() RETURNS INTEGER
     RETURN 10
@@ -707,6 +2566,32 @@ result <- FindFirst()`
 	testIntegerObject(t, evaluated, 5)
 }
 
+func TestStatementCounts(t *testing.T) {
+	input := `DECLARE Total : INTEGER
+FOR i <- 1 TO 3
+    Total <- Total + i
+NEXT i`
+
+	i := New()
+	i.SetCountStatements(true)
+	i.Eval(parser.New(lexer.New(input)).ParseProgram())
+
+	counts := i.StatementCounts()
+	expected := map[string]int{"DeclareStatement": 1, "ForStatement": 1, "AssignmentStatement": 3}
+	if len(counts) != len(expected) {
+		t.Fatalf("expected %d kinds of statement, got %v", len(expected), counts)
+	}
+	for kind, n := range expected {
+		if counts[kind] != n {
+			t.Errorf("expected %s to run %d times, got %d", kind, n, counts[kind])
+		}
+	}
+
+	if counts := setupInterpreter(input).StatementCounts(); len(counts) != 0 {
+		t.Errorf("expected no counts when counting is off, got %v", counts)
+	}
+}
+
 // Helper functions
 
 func testEval(input string) Object {
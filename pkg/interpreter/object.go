@@ -4,6 +4,7 @@ package interpreter
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/andrinoff/cambridge-lang/pkg/ast"
@@ -32,6 +33,9 @@ const (
 	FILE_OBJ         ObjectType = "FILE"
 	BOUND_METHOD_OBJ ObjectType = "BOUND_METHOD"
 	SUPER_OBJ        ObjectType = "SUPER"
+	SET_OBJ          ObjectType = "SET"
+	DICTIONARY_OBJ   ObjectType = "DICTIONARY"
+	NO_VALUE_OBJ     ObjectType = "NO_VALUE"
 )
 
 // Object is the interface all values implement
@@ -124,6 +128,20 @@ func (e *Error) Inspect() string {
 	return fmt.Sprintf("ERROR: %s", e.Message)
 }
 
+// Warning represents a non-fatal runtime diagnostic
+type Warning struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("WARNING at line %d, column %d: %s", w.Line, w.Column, w.Message)
+	}
+	return fmt.Sprintf("WARNING: %s", w.Message)
+}
+
 // Function represents a user-defined function
 type Function struct {
 	Name       string
@@ -148,6 +166,15 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// NoValue is the result of calling a procedure. It is discarded when the
+// call is used as a statement and rejected anywhere its value would be used.
+type NoValue struct {
+	Procedure string
+}
+
+func (nv *NoValue) Type() ObjectType { return NO_VALUE_OBJ }
+func (nv *NoValue) Inspect() string  { return "" }
+
 // Procedure represents a user-defined procedure
 type Procedure struct {
 	Name       string
@@ -174,9 +201,16 @@ func (p *Procedure) Inspect() string {
 // BuiltinFunction represents a built-in function
 type BuiltinFunction func(args ...Object) Object
 
+// ProgramBuiltinFunction is a built-in function that also receives the
+// program arguments given to Interpreter.SetArgs
+type ProgramBuiltinFunction func(programArgs []string, args ...Object) Object
+
 type Builtin struct {
-	Name string
-	Fn   BuiltinFunction
+	Name        string
+	Fn          BuiltinFunction
+	ProgramFn   ProgramBuiltinFunction // called instead of Fn when set
+	Signature   string                 // e.g. "LEFT(s: STRING, n: INTEGER) RETURNS STRING", optional
+	Description string                 // one-line summary for help and tooling, optional
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -184,8 +218,11 @@ func (b *Builtin) Inspect() string  { return "builtin function: " + b.Name }
 
 // Array represents an array
 type Array struct {
-	Elements   map[string]Object // key is index as string, e.g., "1" or "1,2"
-	Dimensions []ast.ArrayDimension
+	Elements    map[string]Object // key is index as string, e.g., "1" or "1,2"
+	Dimensions  []ast.ArrayDimension
+	ElementType ast.DataType // nil if unknown
+	// RecordElements is set when ElementType names a record type
+	RecordElements bool
 }
 
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
@@ -201,10 +238,93 @@ func (a *Array) GetIndex(indices ...int64) string {
 	return strings.Join(parts, ",")
 }
 
+// InBounds reports whether indices name an element within the declared
+// dimensions. An array with no recorded dimensions accepts any indices.
+func (a *Array) InBounds(indices ...int64) bool {
+	if len(a.Dimensions) == 0 {
+		return true
+	}
+	if len(indices) != len(a.Dimensions) {
+		return false
+	}
+	for i, idx := range indices {
+		dim := a.Dimensions[i]
+		if idx < int64(dim.Lower) || idx > int64(dim.Upper) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dictionary represents an associative array declared with
+// DICTIONARY OF keyType TO valueType
+type Dictionary struct {
+	Entries   map[string]Object // keyed by Key
+	KeyType   ast.DataType
+	ValueType ast.DataType
+}
+
+func (d *Dictionary) Type() ObjectType { return DICTIONARY_OBJ }
+func (d *Dictionary) Inspect() string {
+	return fmt.Sprintf("DICTIONARY[%d entries]", len(d.Entries))
+}
+
+// Key returns the form key is stored under in Entries, e.g. "apple" for a
+// STRING key and 3 for an INTEGER key. A CHAR is accepted as a STRING key.
+// It reports false if key is not of the dictionary's key type.
+func (d *Dictionary) Key(key Object) (string, bool) {
+	want, _ := d.KeyType.(*ast.PrimitiveType)
+	switch k := key.(type) {
+	case *String:
+		return strconv.Quote(k.Value), want == nil || want.Name == "STRING"
+	case *Char:
+		if want != nil && want.Name == "STRING" {
+			return strconv.Quote(string(k.Value)), true
+		}
+	}
+	return key.Inspect(), want == nil || want.Name == string(key.Type())
+}
+
+// arraySlice records where a slice passed as an argument was copied from,
+// so that it can be copied back after the call when passed BYREF
+type arraySlice struct {
+	Source *Array
+	Start  int64 // index in Source of the first element
+	Length int64
+	Sub    *Array // the copy, indexed from 1
+}
+
+// Constant marks a value passed to EvalWith as immutable
+type Constant struct {
+	Value Object
+}
+
+func (c *Constant) Type() ObjectType { return c.Value.Type() }
+func (c *Constant) Inspect() string  { return c.Value.Inspect() }
+
+// Set represents a set of values created with DEFINE. A Set with no
+// elements is also stored in the environment as the definition of a
+// TYPE name = SET OF type declaration.
+type Set struct {
+	TypeName    string
+	ElementType ast.DataType
+	Elements    []Object
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+func (s *Set) Inspect() string {
+	var elems []string
+	for _, e := range s.Elements {
+		elems = append(elems, e.Inspect())
+	}
+	return "{" + strings.Join(elems, ", ") + "}"
+}
+
 // Record represents a record instance
 type Record struct {
-	TypeName string
-	Fields   map[string]Object
+	TypeName   string
+	Fields     map[string]Object
+	FieldTypes map[string]ast.DataType // declared field types, if known
 }
 
 func (r *Record) Type() ObjectType { return RECORD_OBJ }
@@ -218,11 +338,39 @@ type Class struct {
 	Parent  *Class
 	Methods map[string]Object // Function or Procedure
 	Fields  map[string]ast.DataType
+	Private map[string]bool // names of PRIVATE fields and methods
 }
 
 func (c *Class) Type() ObjectType { return CLASS_OBJ }
 func (c *Class) Inspect() string  { return fmt.Sprintf("CLASS %s", c.Name) }
 
+// privateOwner returns the class declaring the member name if that member is
+// PRIVATE, or nil if it is public or not declared in the hierarchy
+func (c *Class) privateOwner(name string) *Class {
+	for class := c; class != nil; class = class.Parent {
+		_, isField := class.Fields[name]
+		_, isMethod := class.Methods[name]
+		if isField || isMethod {
+			if class.Private[name] {
+				return class
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// fieldType returns the declared type of the field name, searching the class
+// hierarchy, or nil if there is no such field
+func (c *Class) fieldType(name string) ast.DataType {
+	for class := c; class != nil; class = class.Parent {
+		if dt, ok := class.Fields[name]; ok {
+			return dt
+		}
+	}
+	return nil
+}
+
 // Instance represents an instance of a class
 type Instance struct {
 	Class  *Class
@@ -236,6 +384,7 @@ func (i *Instance) Inspect() string  { return fmt.Sprintf("<%s instance>", i.Cla
 type BoundMethod struct {
 	Instance *Instance
 	Method   Object // Function or Procedure
+	Class    *Class // the class that defines Method, whose parent SUPER refers to
 }
 
 func (bm *BoundMethod) Type() ObjectType { return BOUND_METHOD_OBJ }
@@ -0,0 +1,222 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/andrinoff/cambridge-lang/pkg/ast"
+)
+
+// RecordSize is the number of bytes each record takes up in a file opened
+// FOR RANDOM. Record n starts at byte (n-1) * RecordSize, so any record can
+// be read or written without touching the others.
+const RecordSize = 1024
+
+// storedValue is the form a value is serialized in within a random file.
+// Values carry their type so that GETRECORD can rebuild them without
+// knowing the declared type of the target variable.
+type storedValue struct {
+	Type   string                 `json:"type"`
+	Name   string                 `json:"name,omitempty"` // record type name
+	Value  json.RawMessage        `json:"value,omitempty"`
+	Fields map[string]storedValue `json:"fields,omitempty"`
+}
+
+func (i *Interpreter) evalSeekStatement(stmt *ast.SeekStatement, env *Environment) Object {
+	fs, err := i.randomFile(stmt.Filename, env)
+	if err != nil {
+		return err
+	}
+
+	address := i.evalExpression(stmt.Address, env)
+	if isError(address) {
+		return address
+	}
+	n, ok := address.(*Integer)
+	if !ok || n.Value < 1 {
+		return &Error{Message: fmt.Sprintf("SEEK address must be a positive INTEGER, got %s", address.Inspect())}
+	}
+
+	fs.record = n.Value
+	return &Null{}
+}
+
+// evalGetRecordStatement reads the record at the current position into a
+// variable and moves on to the next record
+func (i *Interpreter) evalGetRecordStatement(stmt *ast.GetRecordStatement, env *Environment) Object {
+	fs, err := i.randomFile(stmt.Filename, env)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, RecordSize)
+	n, readErr := fs.file.ReadAt(buf, (fs.record-1)*RecordSize)
+	if readErr != nil && readErr != io.EOF {
+		return &Error{Message: fmt.Sprintf("read error: %v", readErr)}
+	}
+
+	data := bytes.TrimRight(buf[:n], " \x00")
+	if len(data) == 0 {
+		return &Error{Message: fmt.Sprintf("no record at position %d", fs.record)}
+	}
+
+	var stored storedValue
+	if jsonErr := json.Unmarshal(data, &stored); jsonErr != nil {
+		return &Error{Message: fmt.Sprintf("record at position %d is corrupt: %v", fs.record, jsonErr)}
+	}
+	value := decodeValue(stored)
+	if isError(value) {
+		return value
+	}
+
+	if result := i.assign(stmt.Variable, value, env); isError(result) {
+		return result
+	}
+	fs.record++
+	return &Null{}
+}
+
+// evalPutRecordStatement writes a value as the record at the current
+// position and moves on to the next record
+func (i *Interpreter) evalPutRecordStatement(stmt *ast.PutRecordStatement, env *Environment) Object {
+	fs, err := i.randomFile(stmt.Filename, env)
+	if err != nil {
+		return err
+	}
+
+	value := i.evalExpression(stmt.Variable, env)
+	if isError(value) {
+		return value
+	}
+
+	stored, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	data, jsonErr := json.Marshal(stored)
+	if jsonErr != nil {
+		return &Error{Message: fmt.Sprintf("write error: %v", jsonErr)}
+	}
+	if len(data) > RecordSize {
+		return &Error{Message: fmt.Sprintf("record is %d bytes, more than the %d allowed in a random file", len(data), RecordSize)}
+	}
+
+	slot := bytes.Repeat([]byte{' '}, RecordSize)
+	copy(slot, data)
+	if _, writeErr := fs.file.WriteAt(slot, (fs.record-1)*RecordSize); writeErr != nil {
+		return &Error{Message: fmt.Sprintf("write error: %v", writeErr)}
+	}
+	fs.record++
+	return &Null{}
+}
+
+// randomFile returns the state of a file opened FOR RANDOM
+func (i *Interpreter) randomFile(filename ast.Expression, env *Environment) (*fileState, Object) {
+	name := i.evalExpression(filename, env)
+	if isError(name) {
+		return nil, name
+	}
+
+	nameStr, ok := name.(*String)
+	if !ok {
+		return nil, &Error{Message: "filename must be a string"}
+	}
+
+	fs, ok := i.files[nameStr.Value]
+	if !ok {
+		return nil, &Error{Message: "file not open"}
+	}
+	if fs.mode != "RANDOM" {
+		return nil, &Error{Message: "file not open for random access"}
+	}
+	return fs, nil
+}
+
+func encodeValue(value Object) (storedValue, Object) {
+	stored := storedValue{Type: string(value.Type())}
+
+	var raw interface{}
+	switch v := value.(type) {
+	case *Integer:
+		raw = v.Value
+	case *Real:
+		raw = v.Value
+	case *String:
+		raw = v.Value
+	case *Char:
+		raw = string(v.Value)
+	case *Boolean:
+		raw = v.Value
+	case *Date:
+		raw = [3]int{v.Day, v.Month, v.Year}
+	case *Null:
+		return stored, nil
+	case *Record:
+		stored.Name = v.TypeName
+		stored.Fields = make(map[string]storedValue, len(v.Fields))
+		for name, field := range v.Fields {
+			fieldValue, err := encodeValue(field)
+			if err != nil {
+				return stored, err
+			}
+			stored.Fields[name] = fieldValue
+		}
+		return stored, nil
+	default:
+		return stored, &Error{Message: fmt.Sprintf("cannot store %s in a random file", value.Type())}
+	}
+
+	stored.Value, _ = json.Marshal(raw)
+	return stored, nil
+}
+
+func decodeValue(stored storedValue) Object {
+	var err error
+	switch ObjectType(stored.Type) {
+	case INTEGER_OBJ:
+		var n int64
+		if err = json.Unmarshal(stored.Value, &n); err == nil {
+			return &Integer{Value: n}
+		}
+	case REAL_OBJ:
+		var f float64
+		if err = json.Unmarshal(stored.Value, &f); err == nil {
+			return &Real{Value: f}
+		}
+	case STRING_OBJ:
+		var s string
+		if err = json.Unmarshal(stored.Value, &s); err == nil {
+			return &String{Value: s}
+		}
+	case CHAR_OBJ:
+		var s string
+		if err = json.Unmarshal(stored.Value, &s); err == nil && len([]rune(s)) == 1 {
+			return &Char{Value: []rune(s)[0]}
+		}
+	case BOOLEAN_OBJ:
+		var b bool
+		if err = json.Unmarshal(stored.Value, &b); err == nil {
+			return &Boolean{Value: b}
+		}
+	case DATE_OBJ:
+		var d [3]int
+		if err = json.Unmarshal(stored.Value, &d); err == nil {
+			return &Date{Day: d[0], Month: d[1], Year: d[2]}
+		}
+	case NULL_OBJ:
+		return &Null{}
+	case RECORD_OBJ:
+		rec := &Record{TypeName: stored.Name, Fields: make(map[string]Object, len(stored.Fields))}
+		for name, field := range stored.Fields {
+			value := decodeValue(field)
+			if isError(value) {
+				return value
+			}
+			rec.Fields[name] = value
+		}
+		return rec
+	}
+	return &Error{Message: fmt.Sprintf("cannot read stored %s value", stored.Type)}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/andrinoff/cambridge-lang/pkg/token"
 )
@@ -16,7 +17,18 @@ type Lexer struct {
 	readPos int  // current reading position (after current char)
 	ch      byte // current char under examination
 	line    int  // current line number
-	column  int  // current column number
+	column  int  // current column number, counted in characters rather than bytes
+	errors  []*Error
+
+	comments []Comment // comments skipped so far
+}
+
+// Comment is a comment skipped by the lexer
+type Comment struct {
+	Text    string // the comment including its // or /* */ markers
+	Line    int    // line the comment starts on
+	Column  int    // column the comment starts at
+	EndLine int    // line the comment ends on, after Line for a block comment spanning lines
 }
 
 // New creates a new Lexer instance
@@ -35,7 +47,33 @@ func (l *Lexer) readChar() {
 	}
 	l.pos = l.readPos
 	l.readPos++
-	l.column++
+
+	// The continuation bytes of a multi-byte UTF-8 character do not start a
+	// new column
+	if l.ch&0xC0 != 0x80 {
+		l.column++
+	}
+}
+
+// Errors returns lexer errors encountered so far
+func (l *Lexer) Errors() []*Error {
+	return l.errors
+}
+
+// HasComments reports whether the lexer has skipped any comments so far.
+// Comments are not part of the token stream, so tools that print source
+// back from the AST use this to avoid silently dropping them.
+func (l *Lexer) HasComments() bool {
+	return len(l.comments) > 0
+}
+
+// Comments returns the comments skipped so far, in source order
+func (l *Lexer) Comments() []Comment {
+	return l.comments
+}
+
+func (l *Lexer) addError(line, column int, msg string) {
+	l.errors = append(l.errors, &Error{Line: line, Column: column, Message: msg})
 }
 
 // peekChar returns the next character without advancing
@@ -46,6 +84,15 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.readPos]
 }
 
+// peekCharAt returns the character offset positions after the next one
+// without consuming anything
+func (l *Lexer) peekCharAt(offset int) byte {
+	if l.readPos+offset >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPos+offset]
+}
+
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
@@ -64,12 +111,23 @@ func (l *Lexer) NextToken() token.Token {
 		tok = l.newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = l.newToken(token.RBRACKET, l.ch)
+	case '{':
+		tok = l.newToken(token.LBRACE, l.ch)
+	case '}':
+		tok = l.newToken(token.RBRACE, l.ch)
 	case ':':
 		tok = l.newToken(token.COLON, l.ch)
+	case ';':
+		tok = l.newToken(token.SEMICOLON, l.ch)
 	case ',':
 		tok = l.newToken(token.COMMA, l.ch)
 	case '.':
-		tok = l.newToken(token.DOT, l.ch)
+		if l.peekChar() == '.' {
+			l.readChar()
+			tok = token.Token{Type: token.DOTDOT, Literal: "..", Line: l.line, Column: l.column - 1}
+		} else {
+			tok = l.newToken(token.DOT, l.ch)
+		}
 	case '+':
 		tok = l.newToken(token.PLUS, l.ch)
 	case '-':
@@ -88,6 +146,10 @@ func (l *Lexer) NextToken() token.Token {
 			l.skipComment()
 			return l.NextToken()
 		}
+		if l.peekChar() == '*' {
+			l.skipBlockComment()
+			return l.NextToken()
+		}
 		tok = l.newToken(token.SLASH, l.ch)
 	case '<':
 		if l.peekChar() == '>' {
@@ -112,14 +174,10 @@ func (l *Lexer) NextToken() token.Token {
 	case '"':
 		tok.Type = token.STRING_LIT
 		tok.Literal = l.readString()
-		tok.Line = l.line
-		tok.Column = l.column
 		return tok
 	case '\'':
 		tok.Type = token.CHAR_LIT
 		tok.Literal = l.readCharLiteral()
-		tok.Line = l.line
-		tok.Column = l.column
 		return tok
 	case '\n':
 		tok = l.newToken(token.NEWLINE, l.ch)
@@ -135,11 +193,13 @@ func (l *Lexer) NextToken() token.Token {
 		// Check for Unicode arrow ←
 		if l.isArrow() {
 			tok = token.Token{Type: token.ASSIGN, Literal: "←", Line: l.line, Column: l.column}
-			l.readChar() // ← is multi-byte, need to skip remaining bytes
+			// ← is three bytes in UTF-8
+			l.readChar()
+			l.readChar()
 			l.readChar()
 			return tok
 		}
-		if isLetter(l.ch) {
+		if r, _ := l.currentRune(); isLetter(r) {
 			tok.Column = l.column
 			tok.Line = l.line
 			tok.Literal = l.readIdentifier()
@@ -157,7 +217,9 @@ func (l *Lexer) NextToken() token.Token {
 			}
 			return tok
 		} else {
-			tok = l.newToken(token.ILLEGAL, l.ch)
+			r, size := l.currentRune()
+			tok = token.Token{Type: token.ILLEGAL, Literal: string(r), Line: l.line, Column: l.column}
+			l.advance(size - 1)
 		}
 	}
 
@@ -165,6 +227,22 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
+// currentRune decodes the UTF-8 character starting at the current byte and
+// returns it with its length in bytes
+func (l *Lexer) currentRune() (rune, int) {
+	if l.pos >= len(l.input) {
+		return 0, 1
+	}
+	return utf8.DecodeRuneInString(l.input[l.pos:])
+}
+
+// advance reads n bytes, such as the rest of a multi-byte character
+func (l *Lexer) advance(n int) {
+	for ; n > 0; n-- {
+		l.readChar()
+	}
+}
+
 // isArrow checks if current position starts with Unicode arrow ←
 func (l *Lexer) isArrow() bool {
 	if l.pos+2 < len(l.input) {
@@ -179,30 +257,93 @@ func (l *Lexer) newToken(tokenType token.Type, ch byte) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch), Line: l.line, Column: l.column}
 }
 
-// skipWhitespace skips spaces and tabs (but not newlines)
+// skipWhitespace skips spaces and tabs (but not newlines). A line that ends
+// with _ continues on the next line, so the _ and the newline are skipped too.
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
-		l.readChar()
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '_' && l.isContinuation():
+			for l.ch != '\n' {
+				l.readChar()
+			}
+			l.readChar()
+			l.line++
+			l.column = 1
+		default:
+			return
+		}
+	}
+}
+
+// isContinuation reports whether the current _ is the last thing on its
+// line, apart from trailing spaces
+func (l *Lexer) isContinuation() bool {
+	for offset := 0; ; offset++ {
+		switch l.peekCharAt(offset) {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		default:
+			return false
+		}
 	}
 }
 
 // skipComment skips from // to end of line
 func (l *Lexer) skipComment() {
+	start, line, column := l.pos, l.line, l.column
 	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	text := strings.TrimRight(l.input[start:l.pos], " \t\r")
+	l.comments = append(l.comments, Comment{Text: text, Line: line, Column: column, EndLine: line})
+}
+
+// skipBlockComment skips from /* to the matching */, keeping line and
+// column counts accurate across embedded newlines
+func (l *Lexer) skipBlockComment() {
+	start, startLine, startColumn := l.pos, l.line, l.column
+	l.readChar() // skip '/'
+	l.readChar() // skip '*'
+
+	for {
+		switch {
+		case l.ch == 0:
+			l.addError(startLine, startColumn, "unterminated block comment")
+			return
+		case l.ch == '*' && l.peekChar() == '/':
+			l.readChar()
+			l.readChar()
+			l.comments = append(l.comments, Comment{Text: l.input[start:l.pos], Line: startLine, Column: startColumn, EndLine: l.line})
+			return
+		case l.ch == '\n':
+			l.line++
+			l.column = 0
+		}
+		l.readChar()
+	}
 }
 
-// readIdentifier reads an identifier
+// readIdentifier reads an identifier, which may contain letters from any
+// alphabet, such as Größe or Ñandú
 func (l *Lexer) readIdentifier() string {
 	start := l.pos
-	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
-		l.readChar()
+	for {
+		r, size := l.currentRune()
+		if !isLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		l.advance(size)
 	}
 	return l.input[start:l.pos]
 }
 
-// readNumber reads a number (integer or real)
+// readNumber reads a number (integer or real). A real may have a fraction,
+// an exponent such as e3 or E-5, or both. An e that is not followed by
+// digits is left for the next token.
 func (l *Lexer) readNumber() (string, bool) {
 	start := l.pos
 	isReal := false
@@ -220,6 +361,22 @@ func (l *Lexer) readNumber() (string, bool) {
 		}
 	}
 
+	// Check for exponent
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		signed := next == '+' || next == '-'
+		if isDigit(next) || (signed && isDigit(l.peekCharAt(1))) {
+			isReal = true
+			l.readChar() // consume 'e'
+			if signed {
+				l.readChar() // consume sign
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
+	}
+
 	return l.input[start:l.pos], isReal
 }
 
@@ -239,19 +396,52 @@ func (l *Lexer) readString() string {
 	return str
 }
 
-// readCharLiteral reads a character literal
+// charEscapes maps the letter after a backslash in a character literal to
+// the character it stands for
+var charEscapes = map[byte]rune{
+	'n':  '\n',
+	't':  '\t',
+	'\\': '\\',
+	'\'': '\'',
+}
+
+// readCharLiteral reads a character literal, which must hold exactly one
+// character or one of the escapes in charEscapes, and returns that character
 func (l *Lexer) readCharLiteral() string {
+	startLine, startColumn := l.line, l.column
 	l.readChar() // skip opening quote
-	ch := string(l.ch)
-	l.readChar() // move past the character
-	if l.ch == '\'' {
+
+	var chars []rune
+	for l.ch != '\'' && l.ch != '\n' && l.ch != 0 {
+		if l.ch == '\\' && l.peekChar() != '\n' && l.peekChar() != 0 {
+			l.readChar()
+			escaped, ok := charEscapes[l.ch]
+			if !ok {
+				l.addError(startLine, startColumn, fmt.Sprintf("unknown escape sequence \\%c in character literal", l.ch))
+			}
+			chars = append(chars, escaped)
+			l.readChar()
+			continue
+		}
+
+		r, size := l.currentRune()
+		chars = append(chars, r)
+		l.advance(size)
+	}
+
+	if l.ch != '\'' {
+		l.addError(startLine, startColumn, "unterminated character literal")
+	} else {
 		l.readChar() // skip closing quote
+		if len(chars) != 1 {
+			l.addError(startLine, startColumn, "character literal must be exactly one character")
+		}
 	}
-	return ch
+	return string(chars)
 }
 
-func isLetter(ch byte) bool {
-	return unicode.IsLetter(rune(ch))
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r)
 }
 
 func isDigit(ch byte) bool {
@@ -7,7 +7,7 @@ import (
 )
 
 func TestNextToken_SingleCharTokens(t *testing.T) {
-	input := `()[]:.+-*/^&=`
+	input := `()[]{}:.+-*/^&=`
 
 	tests := []struct {
 		expectedType    token.Type
@@ -17,6 +17,8 @@ func TestNextToken_SingleCharTokens(t *testing.T) {
 		{token.RPAREN, ")"},
 		{token.LBRACKET, "["},
 		{token.RBRACKET, "]"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
 		{token.COLON, ":"},
 		{token.DOT, "."},
 		{token.PLUS, "+"},
@@ -46,6 +48,40 @@ func TestNextToken_SingleCharTokens(t *testing.T) {
 	}
 }
 
+func TestNextToken_Semicolon(t *testing.T) {
+	input := `x <- 1; y <- 2`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"},
+		{token.ASSIGN, "<-"},
+		{token.INTEGER_LIT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "y"},
+		{token.ASSIGN, "<-"},
+		{token.INTEGER_LIT, "2"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestNextToken_MultiCharOperators(t *testing.T) {
 	input := `<> <= >= <- < >`
 
@@ -80,15 +116,14 @@ func TestNextToken_MultiCharOperators(t *testing.T) {
 }
 
 func TestNextToken_UnicodeArrow(t *testing.T) {
-	// Test with ASCII arrow instead since Unicode handling is complex
-	input := `x <- 5`
+	input := `x ← 5`
 
 	tests := []struct {
 		expectedType    token.Type
 		expectedLiteral string
 	}{
 		{token.IDENT, "x"},
-		{token.ASSIGN, "<-"},
+		{token.ASSIGN, "←"},
 		{token.INTEGER_LIT, "5"},
 		{token.EOF, ""},
 	}
@@ -110,6 +145,35 @@ func TestNextToken_UnicodeArrow(t *testing.T) {
 	}
 }
 
+func TestNextToken_UnicodeArrowColumn(t *testing.T) {
+	// Columns count characters, so ← is one column wide where <- is two
+	tests := []struct {
+		input          string
+		expectedColumn int
+	}{
+		{"Total <- Total + 1", 16},
+		{"Total ← Total + 1", 15},
+		{"Total←Total + 1", 13},
+		{`Name ← "é" & Rest`, 12},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		l.NextToken() // Total or Name
+		l.NextToken() // assignment
+		l.NextToken() // Total or string
+		tok := l.NextToken()
+		if tok.Type != token.PLUS && tok.Type != token.AMPERSAND {
+			t.Fatalf("%q: expected an operator as the fourth token, got %s %q", tt.input, tok.Type, tok.Literal)
+		}
+
+		if tok.Column != tt.expectedColumn {
+			t.Errorf("%q: expected %s at column %d, got column %d",
+				tt.input, tok.Literal, tt.expectedColumn, tok.Column)
+		}
+	}
+}
+
 func TestNextToken_IntegerLiterals(t *testing.T) {
 	input := `0 1 42 123456789`
 
@@ -172,6 +236,43 @@ func TestNextToken_RealLiterals(t *testing.T) {
 	}
 }
 
+func TestNextToken_ScientificNotation(t *testing.T) {
+	input := `1.5e3 6.02E23 1e-5 2E+2 3e x 4e-`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.REAL_LIT, "1.5e3"},
+		{token.REAL_LIT, "6.02E23"},
+		{token.REAL_LIT, "1e-5"},
+		{token.REAL_LIT, "2E+2"},
+		{token.INTEGER_LIT, "3"},
+		{token.IDENT, "e"},
+		{token.IDENT, "x"},
+		{token.INTEGER_LIT, "4"},
+		{token.IDENT, "e"},
+		{token.MINUS, "-"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestNextToken_StringLiterals(t *testing.T) {
 	input := `"hello" "world" "Hello, World!" ""`
 
@@ -233,6 +334,65 @@ func TestNextToken_CharLiterals(t *testing.T) {
 	}
 }
 
+func TestNextToken_CharLiteralEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`'a'`, "a"},
+		{`'\n'`, "\n"},
+		{`'\t'`, "\t"},
+		{`'\''`, "'"},
+		{`'\\'`, "\\"},
+		{`'é'`, "é"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.CHAR_LIT || tok.Literal != tt.expected {
+			t.Errorf("%s: expected CHAR_LIT %q, got %s %q", tt.input, tt.expected, tok.Type, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("%s: expected no lexer errors, got %v", tt.input, l.Errors())
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("%s: expected EOF after the literal, got %s %q", tt.input, next.Type, next.Literal)
+		}
+	}
+}
+
+func TestNextToken_CharLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x <- 'ab'`, "character literal must be exactly one character"},
+		{`x <- ''`, "character literal must be exactly one character"},
+		{`x <- 'a`, "unterminated character literal"},
+		{`x <- '\q'`, "unknown escape sequence \\q in character literal"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("%s: expected 1 lexer error, got %v", tt.input, errors)
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %q", tt.input, tt.expected, errors[0].Message)
+		}
+		if errors[0].Line != 1 || errors[0].Column != 6 {
+			t.Errorf("%s: expected error at line 1, column 6, got line %d, column %d",
+				tt.input, errors[0].Line, errors[0].Column)
+		}
+	}
+}
+
 func TestNextToken_Keywords(t *testing.T) {
 	input := `DECLARE CONSTANT TYPE ENDTYPE
 IF THEN ELSE ENDIF
@@ -379,6 +539,86 @@ func TestNextToken_Identifiers(t *testing.T) {
 	}
 }
 
+func TestNextToken_UnicodeIdentifiers(t *testing.T) {
+	input := `Größe <- "Café crème" & Ñandú`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.IDENT, "Größe", 1},
+		{token.ASSIGN, "<-", 7},
+		{token.STRING_LIT, "Café crème", 10},
+		{token.AMPERSAND, "&", 23},
+		{token.IDENT, "Ñandú", 25},
+		{token.EOF, "", 30},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - column wrong. expected=%d, got=%d",
+				i, tt.expectedColumn, tok.Column)
+		}
+	}
+}
+
+func TestNextToken_LineContinuation(t *testing.T) {
+	input := "OUTPUT \"Total: \", _  \n    Total\nx_1 <- _"
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.OUTPUT, "OUTPUT", 1, 1},
+		{token.STRING_LIT, "Total: ", 1, 8},
+		{token.COMMA, ",", 1, 17},
+		{token.IDENT, "Total", 2, 5},
+		{token.NEWLINE, "\n", 2, 10},
+		{token.IDENT, "x_1", 3, 1},
+		{token.ASSIGN, "<-", 3, 5},
+		{token.ILLEGAL, "_", 3, 8}, // not followed by a newline
+		{token.EOF, "", 3, 9},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
 func TestNextToken_Comments(t *testing.T) {
 	input := `x <- 5 // this is a comment
 y <- 10`
@@ -414,6 +654,109 @@ y <- 10`
 	}
 }
 
+func TestNextToken_BlockComments(t *testing.T) {
+	input := `x <- 5 /* a comment
+spanning
+lines */ y <- 10`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.IDENT, "x", 1},
+		{token.ASSIGN, "<-", 1},
+		{token.INTEGER_LIT, "5", 1},
+		{token.IDENT, "y", 3},
+		{token.ASSIGN, "<-", 3},
+		{token.INTEGER_LIT, "10", 3},
+		{token.EOF, "", 3},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line wrong. expected=%d, got=%d",
+				i, tt.expectedLine, tok.Line)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("expected no lexer errors, got %v", l.Errors())
+	}
+}
+
+func TestNextToken_BlockCommentColumn(t *testing.T) {
+	input := "/* one\ntwo */ x"
+
+	l := New(input)
+	tok := l.NextToken()
+
+	if tok.Type != token.IDENT || tok.Line != 2 || tok.Column != 8 {
+		t.Errorf("expected IDENT at line 2, column 8, got %s at line %d, column %d",
+			tok.Type, tok.Line, tok.Column)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "x <- 5 // note  \r\n/* one\ntwo */ y"
+
+	l := New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	expected := []Comment{
+		{Text: "// note", Line: 1, Column: 8, EndLine: 1},
+		{Text: "/* one\ntwo */", Line: 2, Column: 1, EndLine: 3},
+	}
+	comments := l.Comments()
+	if len(comments) != len(expected) {
+		t.Fatalf("expected %d comments, got %+v", len(expected), comments)
+	}
+	for i, c := range comments {
+		if c != expected[i] {
+			t.Errorf("comment %d: expected %+v, got %+v", i, expected[i], c)
+		}
+	}
+}
+
+func TestNextToken_UnterminatedBlockComment(t *testing.T) {
+	input := `x <- 5
+/* never closed
+y <- 10`
+
+	l := New(input)
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	errors := l.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 lexer error, got %d", len(errors))
+	}
+
+	if errors[0].Message != "unterminated block comment" {
+		t.Errorf("unexpected error message: %q", errors[0].Message)
+	}
+	if errors[0].Line != 2 || errors[0].Column != 1 {
+		t.Errorf("expected error at line 2, column 1, got line %d, column %d",
+			errors[0].Line, errors[0].Column)
+	}
+}
+
 func TestNextToken_CompleteProgram(t *testing.T) {
 	input := `DECLARE x : INTEGER
 x <- 10
@@ -611,6 +954,17 @@ func TestNextToken_IllegalCharacter(t *testing.T) {
 	if tok.Type != token.ILLEGAL {
 		t.Fatalf("expected ILLEGAL token, got %s", tok.Type)
 	}
+
+	// A character outside ASCII is one token, not one per byte
+	l = New("€ x")
+	tok = l.NextToken()
+	if tok.Type != token.ILLEGAL || tok.Literal != "€" {
+		t.Fatalf("expected ILLEGAL token €, got %s %q", tok.Type, tok.Literal)
+	}
+	tok = l.NextToken()
+	if tok.Type != token.IDENT || tok.Column != 3 {
+		t.Errorf("expected IDENT at column 3, got %s at column %d", tok.Type, tok.Column)
+	}
 }
 
 func TestNextToken_ClassDefinition(t *testing.T) {
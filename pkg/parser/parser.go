@@ -2,9 +2,11 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/andrinoff/cambridge-lang/pkg/ast"
 	"github.com/andrinoff/cambridge-lang/pkg/lexer"
@@ -23,6 +25,7 @@ const (
 	SUM         // + - &
 	PRODUCT     // * / DIV MOD
 	PREFIX      // -X NOT X
+	POWER       // ^
 	CALL        // function(x)
 	INDEX       // array[x]
 	MEMBER      // object.field
@@ -33,6 +36,7 @@ var precedences = map[token.Type]int{
 	token.AND:       AND_PREC,
 	token.EQ:        EQUALS,
 	token.NOT_EQ:    EQUALS,
+	token.IN:        EQUALS,
 	token.LT:        LESSGREATER,
 	token.GT:        LESSGREATER,
 	token.LT_EQ:     LESSGREATER,
@@ -44,6 +48,7 @@ var precedences = map[token.Type]int{
 	token.SLASH:     PRODUCT,
 	token.DIV:       PRODUCT,
 	token.MOD:       PRODUCT,
+	token.CARET:     POWER,
 	token.LPAREN:    CALL,
 	token.LBRACKET:  INDEX,
 	token.DOT:       MEMBER,
@@ -57,10 +62,11 @@ type (
 // Parser parses tokens into an AST
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors []ParseError
 
 	curToken  token.Token
 	peekToken token.Token
+	ahead     []token.Token // tokens after peekToken read by peekAt
 
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
@@ -70,7 +76,7 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []ParseError{},
 	}
 
 	p.prefixParseFns = make(map[token.Type]prefixParseFn)
@@ -81,11 +87,13 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.CHAR_LIT, p.parseCharLiteral)
 	p.registerPrefix(token.TRUE, p.parseBooleanLiteral)
 	p.registerPrefix(token.FALSE, p.parseBooleanLiteral)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.NEW, p.parseNewExpression)
 	p.registerPrefix(token.SUPER, p.parseSuperExpression)
+	p.registerPrefix(token.LBRACE, p.parseSetLiteral)
 
 	p.infixParseFns = make(map[token.Type]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -94,8 +102,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.DIV, p.parseInfixExpression)
 	p.registerInfix(token.MOD, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
@@ -124,16 +134,58 @@ func (p *Parser) registerInfix(tokenType token.Type, fn infixParseFn) {
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
-	p.peekToken = p.l.NextToken()
+	if len(p.ahead) > 0 {
+		p.peekToken = p.ahead[0]
+		p.ahead = p.ahead[1:]
+	} else {
+		p.peekToken = p.l.NextToken()
+	}
+}
+
+// peekAt returns the token n positions after peekToken without consuming it
+func (p *Parser) peekAt(n int) token.Token {
+	for len(p.ahead) < n {
+		p.ahead = append(p.ahead, p.l.NextToken())
+	}
+	return p.ahead[n-1]
+}
+
+// ParseError is a syntax error, or a lexer error found while parsing, at a
+// 1-based line and column. Length is the number of characters in the
+// offending token, zero when there is none.
+type ParseError struct {
+	Line    int
+	Column  int
+	Length  int
+	Message string
 }
 
-// Errors returns parser errors
+// Error formats the error as "line L, column C: message"
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Errors returns parser errors formatted as "line L, column C: message"
 func (p *Parser) Errors() []string {
+	errs := make([]string, 0, len(p.errors))
+	for _, err := range p.errors {
+		errs = append(errs, err.Error())
+	}
+	return errs
+}
+
+// StructuredErrors returns parser errors with their positions
+func (p *Parser) StructuredErrors() []ParseError {
 	return p.errors
 }
 
 func (p *Parser) addError(msg string) {
-	p.errors = append(p.errors, fmt.Sprintf("line %d, column %d: %s", p.curToken.Line, p.curToken.Column, msg))
+	p.errors = append(p.errors, ParseError{
+		Line:    p.curToken.Line,
+		Column:  p.curToken.Column,
+		Length:  utf8.RuneCountInString(p.curToken.Literal),
+		Message: msg,
+	})
 }
 
 func (p *Parser) peekError(t token.Type) {
@@ -172,9 +224,16 @@ func (p *Parser) curPrecedence() int {
 	return LOWEST
 }
 
-// skipNewlines advances past any newline tokens
+// peekIsTerminator reports whether the next token ends the current
+// statement. The end of the input ends a statement just as a newline does,
+// so the last line of a file parses the same with or without a newline.
+func (p *Parser) peekIsTerminator() bool {
+	return p.peekTokenIs(token.NEWLINE) || p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.EOF)
+}
+
+// skipNewlines advances past any statement terminators (newlines and semicolons)
 func (p *Parser) skipNewlines() {
-	for p.curTokenIs(token.NEWLINE) {
+	for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 }
@@ -196,6 +255,10 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.nextToken()
 	}
 
+	for _, err := range p.l.Errors() {
+		p.errors = append(p.errors, ParseError{Line: err.Line, Column: err.Column, Message: err.Message})
+	}
+
 	return program
 }
 
@@ -225,7 +288,7 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReturnStatement()
 	case token.INPUT:
 		return p.parseInputStatement()
-	case token.OUTPUT:
+	case token.OUTPUT, token.EPRINT:
 		return p.parseOutputStatement()
 	case token.OPENFILE:
 		return p.parseOpenFileStatement()
@@ -235,8 +298,16 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReadFileStatement()
 	case token.WRITEFILE:
 		return p.parseWriteFileStatement()
+	case token.SEEK:
+		return p.parseSeekStatement()
+	case token.GETRECORD:
+		return p.parseGetRecordStatement()
+	case token.PUTRECORD:
+		return p.parsePutRecordStatement()
 	case token.TYPE:
 		return p.parseTypeStatement()
+	case token.DEFINE:
+		return p.parseDefineStatement()
 	case token.CLASS:
 		return p.parseClassStatement()
 	case token.PUBLIC, token.PRIVATE:
@@ -249,18 +320,33 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseDeclareStatement() *ast.DeclareStatement {
 	stmt := &ast.DeclareStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Names = append(stmt.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	// DECLARE x <- value takes its type from the value
+	if !p.peekTokenIs(token.ASSIGN) {
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
 
-	if !p.expectPeek(token.COLON) {
-		return nil
+		p.nextToken()
+		stmt.DataType = p.parseDataType()
 	}
 
-	p.nextToken()
-	stmt.DataType = p.parseDataType()
+	if p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Value = p.parseExpression(LOWEST)
+	}
 
 	return stmt
 }
@@ -284,6 +370,13 @@ func (p *Parser) parseConstantStatement() *ast.ConstantStatement {
 	return stmt
 }
 
+// parseIfStatement parses an IF statement. An ELSE immediately followed by
+// IF on the same line (or the single keyword ELSEIF) continues the chain:
+//
+//	IF c1 THEN ... ELSE IF c2 THEN ... ELSE ... ENDIF
+//
+// Each link becomes a nested IfStatement in the Alternative of the previous
+// one, and the single ENDIF closes the whole chain.
 func (p *Parser) parseIfStatement() *ast.IfStatement {
 	stmt := &ast.IfStatement{Token: p.curToken}
 
@@ -297,12 +390,25 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 	p.nextToken()
 	p.skipNewlines()
 
-	stmt.Consequence = p.parseBlockStatements(token.ELSE, token.ENDIF)
+	stmt.Consequence = p.parseBlockStatements(stmt.Token, token.ELSE, token.ELSEIF, token.ENDIF)
+
+	if p.curTokenIs(token.ELSE) && p.peekTokenIs(token.IF) {
+		p.nextToken()
+	}
+
+	if p.curTokenIs(token.IF) || p.curTokenIs(token.ELSEIF) {
+		elseIf := p.parseIfStatement()
+		if elseIf == nil {
+			return nil
+		}
+		stmt.Alternative = []ast.Statement{elseIf}
+		return stmt
+	}
 
 	if p.curTokenIs(token.ELSE) {
 		p.nextToken()
 		p.skipNewlines()
-		stmt.Alternative = p.parseBlockStatements(token.ENDIF)
+		stmt.Alternative = p.parseBlockStatements(stmt.Token, token.ENDIF)
 	}
 
 	return stmt
@@ -333,7 +439,9 @@ func (p *Parser) parseCaseStatement() *ast.CaseStatement {
 		}
 		p.nextToken()
 		p.skipNewlines()
-		stmt.Otherwise = p.parseBlockStatements(token.ENDCASE)
+		stmt.Otherwise = p.parseBlockStatements(stmt.Token, token.ENDCASE)
+	} else if p.curTokenIs(token.EOF) {
+		p.unterminatedBlock(stmt.Token, token.ENDCASE)
 	}
 
 	return stmt
@@ -391,14 +499,40 @@ func (p *Parser) parseCaseClause() ast.CaseClause {
 	return clause
 }
 
+// isStartOfCaseValue reports whether the current line is a case label rather
+// than another statement of the clause body: it starts with a value and has
+// a colon outside brackets before any assignment arrow
 func (p *Parser) isStartOfCaseValue() bool {
-	// Check if current token could be a case value
 	switch p.curToken.Type {
-	case token.INTEGER_LIT, token.REAL_LIT, token.STRING_LIT, token.CHAR_LIT, token.IDENT:
-		// Look ahead to see if there's a colon or TO
-		return p.peekTokenIs(token.COLON) || p.peekTokenIs(token.TO) || p.peekTokenIs(token.COMMA)
+	case token.INTEGER_LIT, token.REAL_LIT, token.STRING_LIT, token.CHAR_LIT, token.IDENT,
+		token.TRUE, token.FALSE, token.MINUS, token.LPAREN:
+	default:
+		return false
+	}
+
+	depth := 0
+	for n := 0; ; n++ {
+		tok := p.curToken
+		switch {
+		case n == 1:
+			tok = p.peekToken
+		case n > 1:
+			tok = p.peekAt(n - 1)
+		}
+
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		case token.COLON:
+			if depth == 0 {
+				return true
+			}
+		case token.ASSIGN, token.NEWLINE, token.SEMICOLON, token.EOF:
+			return false
+		}
 	}
-	return false
 }
 
 func (p *Parser) parseForStatement() *ast.ForStatement {
@@ -433,7 +567,7 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 	p.nextToken()
 	p.skipNewlines()
 
-	stmt.Body = p.parseBlockStatements(token.NEXT)
+	stmt.Body = p.parseBlockStatements(stmt.Token, token.NEXT)
 
 	// Expect NEXT variable
 	if p.curTokenIs(token.NEXT) {
@@ -452,7 +586,7 @@ func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	p.nextToken()
 	p.skipNewlines()
 
-	stmt.Body = p.parseBlockStatements(token.ENDWHILE)
+	stmt.Body = p.parseBlockStatements(stmt.Token, token.ENDWHILE)
 
 	return stmt
 }
@@ -463,7 +597,10 @@ func (p *Parser) parseRepeatStatement() *ast.RepeatStatement {
 	p.nextToken()
 	p.skipNewlines()
 
-	stmt.Body = p.parseBlockStatements(token.UNTIL)
+	stmt.Body = p.parseBlockStatements(stmt.Token, token.UNTIL)
+	if !p.curTokenIs(token.UNTIL) {
+		return nil
+	}
 
 	p.nextToken()
 	stmt.Condition = p.parseExpression(LOWEST)
@@ -496,7 +633,7 @@ func (p *Parser) parseProcedureStatement() *ast.ProcedureStatement {
 	p.nextToken()
 	p.skipNewlines()
 
-	stmt.Body = p.parseBlockStatements(token.ENDPROCEDURE)
+	stmt.Body = p.parseBlockStatements(stmt.Token, token.ENDPROCEDURE)
 
 	return stmt
 }
@@ -533,7 +670,7 @@ func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
 	p.nextToken()
 	p.skipNewlines()
 
-	stmt.Body = p.parseBlockStatements(token.ENDFUNCTION)
+	stmt.Body = p.parseBlockStatements(stmt.Token, token.ENDFUNCTION)
 
 	return stmt
 }
@@ -563,6 +700,7 @@ func (p *Parser) parseParameters() []ast.Parameter {
 			return params
 		}
 
+		param.Token = p.curToken
 		param.Name = p.curToken.Literal
 
 		if !p.expectPeek(token.COLON) {
@@ -602,7 +740,7 @@ func (p *Parser) parseCallStatement() *ast.CallStatement {
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
-	if p.peekTokenIs(token.NEWLINE) || p.peekTokenIs(token.EOF) {
+	if p.peekIsTerminator() {
 		return stmt
 	}
 
@@ -616,13 +754,27 @@ func (p *Parser) parseInputStatement() *ast.InputStatement {
 	stmt := &ast.InputStatement{Token: p.curToken}
 
 	p.nextToken()
-	stmt.Variable = p.parseExpression(LOWEST)
+
+	for {
+		stmt.Variables = append(stmt.Variables, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume comma
+		p.nextToken()
+	}
 
 	return stmt
 }
 
 func (p *Parser) parseOutputStatement() *ast.OutputStatement {
-	stmt := &ast.OutputStatement{Token: p.curToken}
+	stmt := &ast.OutputStatement{Token: p.curToken, ToError: p.curTokenIs(token.EPRINT)}
+
+	// A bare OUTPUT prints a blank line
+	if p.peekIsTerminator() {
+		return stmt
+	}
 
 	p.nextToken()
 
@@ -658,8 +810,15 @@ func (p *Parser) parseOpenFileStatement() *ast.OpenFileStatement {
 		stmt.Mode = "WRITE"
 	case token.APPEND:
 		stmt.Mode = "APPEND"
+	case token.IDENT:
+		// RANDOM is not a keyword, so that the RANDOM() builtin still works
+		if strings.ToUpper(p.curToken.Literal) != "RANDOM" {
+			p.addError("expected READ, WRITE, APPEND, or RANDOM after FOR")
+			return nil
+		}
+		stmt.Mode = "RANDOM"
 	default:
-		p.addError("expected READ, WRITE, or APPEND after FOR")
+		p.addError("expected READ, WRITE, APPEND, or RANDOM after FOR")
 		return nil
 	}
 
@@ -702,7 +861,69 @@ func (p *Parser) parseWriteFileStatement() *ast.WriteFileStatement {
 	}
 
 	p.nextToken()
-	stmt.Data = p.parseExpression(LOWEST)
+
+	for {
+		stmt.Values = append(stmt.Values, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume comma
+
+		if p.peekIsTerminator() {
+			stmt.NoNewline = true
+			break
+		}
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseSeekStatement() *ast.SeekStatement {
+	stmt := &ast.SeekStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Filename = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COMMA) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Address = p.parseExpression(LOWEST)
+
+	return stmt
+}
+
+func (p *Parser) parseGetRecordStatement() *ast.GetRecordStatement {
+	stmt := &ast.GetRecordStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Filename = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COMMA) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Variable = p.parseExpression(LOWEST)
+
+	return stmt
+}
+
+func (p *Parser) parsePutRecordStatement() *ast.PutRecordStatement {
+	stmt := &ast.PutRecordStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Filename = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COMMA) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Variable = p.parseExpression(LOWEST)
 
 	return stmt
 }
@@ -728,17 +949,56 @@ func (p *Parser) parseTypeStatement() *ast.TypeStatement {
 		} else if p.curTokenIs(token.LPAREN) {
 			// Enum type
 			stmt.Definition = p.parseEnumType()
+		} else if p.curTokenIs(token.SET) {
+			// Set type
+			if !p.expectPeek(token.OF) {
+				return nil
+			}
+			p.nextToken()
+			stmt.Definition = &ast.SetType{ElementType: p.parseDataType()}
 		}
 	} else {
 		// Record type
 		p.nextToken()
 		p.skipNewlines()
 		stmt.Definition = p.parseRecordType()
+		if p.curTokenIs(token.EOF) {
+			p.unterminatedBlock(stmt.Token, token.ENDTYPE)
+		}
 	}
 
 	return stmt
 }
 
+// parseDefineStatement parses: DEFINE name (value1, value2, ...) : SetType
+func (p *Parser) parseDefineStatement() *ast.DefineStatement {
+	stmt := &ast.DefineStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	stmt.Values = p.parseExpressionList(token.RPAREN)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.TypeName = p.curToken.Literal
+
+	return stmt
+}
+
 func (p *Parser) parseRecordType() *ast.RecordType {
 	record := &ast.RecordType{}
 
@@ -820,12 +1080,15 @@ func (p *Parser) parseClassStatement() *ast.ClassStatement {
 		p.nextToken()
 		p.skipNewlines()
 	}
+	if p.curTokenIs(token.EOF) {
+		p.unterminatedBlock(stmt.Token, token.ENDCLASS)
+	}
 
 	return stmt
 }
 
 func (p *Parser) parseAccessModifiedStatement() ast.Statement {
-	access := p.curToken.Literal
+	access := strings.ToUpper(p.curToken.Literal)
 	p.nextToken()
 
 	switch p.curToken.Type {
@@ -864,7 +1127,7 @@ func (p *Parser) parseAccessModifiedStatement() ast.Statement {
 // parsePropertyDeclaration parses: Name : TYPE (used in class definitions)
 func (p *Parser) parsePropertyDeclaration() *ast.DeclareStatement {
 	stmt := &ast.DeclareStatement{Token: p.curToken}
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Names = []*ast.Identifier{{Token: p.curToken, Value: p.curToken.Literal}}
 
 	if !p.expectPeek(token.COLON) {
 		return nil
@@ -877,24 +1140,37 @@ func (p *Parser) parsePropertyDeclaration() *ast.DeclareStatement {
 }
 
 func (p *Parser) parseAssignmentOrExpressionStatement() ast.Statement {
+	// Both kinds of statement keep their first token, which is where errors
+	// in them are reported
+	first := p.curToken
 	expr := p.parseExpression(LOWEST)
 
 	if p.peekTokenIs(token.ASSIGN) {
 		// This is an assignment
 		p.nextToken()
-		stmt := &ast.AssignmentStatement{Token: p.curToken, Name: expr}
+		stmt := &ast.AssignmentStatement{Token: first, Name: expr}
 		p.nextToken()
 		stmt.Value = p.parseExpression(LOWEST)
 		return stmt
 	}
 
-	return &ast.ExpressionStatement{Token: p.curToken, Expression: expr}
+	return &ast.ExpressionStatement{Token: first, Expression: expr}
 }
 
-func (p *Parser) parseBlockStatements(endTokens ...token.Type) []ast.Statement {
+// parseBlockStatements parses the body of the block opened by opener up to
+// one of endTokens, the last of which is the keyword that closes the block.
+// A keyword that closes a different kind of block, such as ENDWHILE in a FOR
+// loop, is reported and ends the block in its place.
+func (p *Parser) parseBlockStatements(opener token.Token, endTokens ...token.Type) []ast.Statement {
 	statements := []ast.Statement{}
 
 	for !p.isEndToken(endTokens...) && !p.curTokenIs(token.EOF) {
+		if p.isBlockCloser() {
+			p.addError(fmt.Sprintf("expected %s to close %s started at line %d, got %s",
+				endTokens[len(endTokens)-1], opener.Type, opener.Line, p.curToken.Literal))
+			break
+		}
+
 		stmt := p.parseStatement()
 		if stmt != nil {
 			statements = append(statements, stmt)
@@ -903,9 +1179,50 @@ func (p *Parser) parseBlockStatements(endTokens ...token.Type) []ast.Statement {
 		p.skipNewlines()
 	}
 
+	if p.curTokenIs(token.EOF) {
+		p.unterminatedBlock(opener, endTokens[len(endTokens)-1])
+	}
+
 	return statements
 }
 
+// blockNames describes each kind of block in unterminated block errors
+var blockNames = map[token.Type]string{
+	token.IF:        "IF statement",
+	token.ELSEIF:    "IF statement",
+	token.CASE:      "CASE statement",
+	token.FOR:       "FOR loop",
+	token.WHILE:     "WHILE loop",
+	token.REPEAT:    "REPEAT loop",
+	token.PROCEDURE: "PROCEDURE",
+	token.FUNCTION:  "FUNCTION",
+	token.CLASS:     "CLASS",
+	token.TYPE:      "TYPE",
+}
+
+// unterminatedBlock reports reaching the end of the input inside the block
+// opened by opener, which should have been closed by closer
+func (p *Parser) unterminatedBlock(opener token.Token, closer token.Type) {
+	name, ok := blockNames[opener.Type]
+	if !ok {
+		name = string(opener.Type)
+	}
+	p.addError(fmt.Sprintf("unterminated %s started at line %d — missing %s", name, opener.Line, closer))
+}
+
+// isBlockCloser reports whether the current token is a keyword that closes
+// a block, or ENDFOR, which students often write for NEXT
+func (p *Parser) isBlockCloser() bool {
+	switch p.curToken.Type {
+	case token.ENDIF, token.ENDCASE, token.NEXT, token.ENDWHILE, token.UNTIL,
+		token.ENDPROCEDURE, token.ENDFUNCTION, token.ENDCLASS, token.ENDTYPE:
+		return true
+	case token.IDENT:
+		return p.curToken.Literal == "ENDFOR"
+	}
+	return false
+}
+
 func (p *Parser) isEndToken(tokens ...token.Type) bool {
 	for _, t := range tokens {
 		if p.curTokenIs(t) {
@@ -918,9 +1235,11 @@ func (p *Parser) isEndToken(tokens ...token.Type) bool {
 func (p *Parser) parseDataType() ast.DataType {
 	switch p.curToken.Type {
 	case token.INTEGER, token.REAL, token.STRING, token.CHAR, token.BOOLEAN, token.DATE:
-		return &ast.PrimitiveType{Name: p.curToken.Literal}
+		return &ast.PrimitiveType{Name: strings.ToUpper(p.curToken.Literal)}
 	case token.ARRAY:
 		return p.parseArrayType()
+	case token.DICTIONARY:
+		return p.parseDictionaryType()
 	case token.CARET:
 		p.nextToken()
 		return &ast.PointerType{TargetType: p.parseDataType()}
@@ -941,25 +1260,19 @@ func (p *Parser) parseArrayType() *ast.ArrayType {
 
 	// Parse dimensions
 	for {
+		var dim ast.ArrayDimension
+
 		p.nextToken()
-		lower, err := strconv.Atoi(p.curToken.Literal)
-		if err != nil {
-			p.addError("expected integer for array lower bound")
-			return arrType
-		}
+		dim.Lower, dim.LowerExpr = p.parseArrayBound()
 
 		if !p.expectPeek(token.COLON) {
 			return arrType
 		}
 
 		p.nextToken()
-		upper, err := strconv.Atoi(p.curToken.Literal)
-		if err != nil {
-			p.addError("expected integer for array upper bound")
-			return arrType
-		}
+		dim.Upper, dim.UpperExpr = p.parseArrayBound()
 
-		arrType.Dimensions = append(arrType.Dimensions, ast.ArrayDimension{Lower: lower, Upper: upper})
+		arrType.Dimensions = append(arrType.Dimensions, dim)
 
 		if p.peekTokenIs(token.COMMA) {
 			p.nextToken()
@@ -982,6 +1295,34 @@ func (p *Parser) parseArrayType() *ast.ArrayType {
 	return arrType
 }
 
+func (p *Parser) parseDictionaryType() *ast.DictionaryType {
+	dictType := &ast.DictionaryType{}
+
+	if !p.expectPeek(token.OF) {
+		return dictType
+	}
+	p.nextToken()
+	dictType.KeyType = p.parseDataType()
+
+	if !p.expectPeek(token.TO) {
+		return dictType
+	}
+	p.nextToken()
+	dictType.ValueType = p.parseDataType()
+
+	return dictType
+}
+
+// parseArrayBound parses one array bound. Integer literals are stored as
+// numbers; anything else, such as a constant, is kept as an expression.
+func (p *Parser) parseArrayBound() (int, ast.Expression) {
+	expr := p.parseExpression(LOWEST)
+	if lit, ok := expr.(*ast.IntegerLiteral); ok {
+		return int(lit.Value), nil
+	}
+	return 0, expr
+}
+
 // ============ EXPRESSION PARSING ============
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -992,7 +1333,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	}
 	leftExp := prefix()
 
-	for !p.peekTokenIs(token.NEWLINE) && precedence < p.peekPrecedence() {
+	for !p.peekIsTerminator() && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -1012,6 +1353,11 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		// Keep the node so the rest of the statement still parses
+		p.addError("integer literal too large for 64-bit")
+		return lit
+	}
 	if err != nil {
 		p.addError(fmt.Sprintf("could not parse %q as integer", p.curToken.Literal))
 		return nil
@@ -1049,10 +1395,14 @@ func (p *Parser) parseBooleanLiteral() ast.Expression {
 	}
 }
 
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: strings.ToUpper(p.curToken.Literal),
 	}
 
 	p.nextToken()
@@ -1064,17 +1414,24 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: strings.ToUpper(p.curToken.Literal),
 		Left:     left,
 	}
 
 	precedence := p.curPrecedence()
+	if p.curTokenIs(token.CARET) {
+		precedence-- // right-associative: 2 ^ 3 ^ 2 is 2 ^ (3 ^ 2)
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+func (p *Parser) parseSetLiteral() ast.Expression {
+	return &ast.SetLiteral{Token: p.curToken, Elements: p.parseExpressionList(token.RBRACE)}
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 
@@ -1118,8 +1475,34 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 }
 
 func (p *Parser) parseArrayAccess(array ast.Expression) ast.Expression {
-	exp := &ast.ArrayAccess{Token: p.curToken, Array: array}
-	exp.Indices = p.parseExpressionList(token.RBRACKET)
+	tok := p.curToken
+	if p.peekTokenIs(token.RBRACKET) {
+		return &ast.ArrayAccess{Token: tok, Array: array, Indices: p.parseExpressionList(token.RBRACKET)}
+	}
+
+	p.nextToken()
+	first := p.parseExpression(LOWEST)
+
+	// Slice: arr[start..end]
+	if p.peekTokenIs(token.DOTDOT) {
+		p.nextToken()
+		p.nextToken()
+		slice := &ast.SliceExpression{Token: tok, Array: array, Start: first, End: p.parseExpression(LOWEST)}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return slice
+	}
+
+	exp := &ast.ArrayAccess{Token: tok, Array: array, Indices: []ast.Expression{first}}
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		exp.Indices = append(exp.Indices, p.parseExpression(LOWEST))
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
 	return exp
 }
 
@@ -37,8 +37,8 @@ func TestParseDeclareStatement(t *testing.T) {
 				program.Statements[0])
 		}
 
-		if stmt.Name.Value != tt.expectedName {
-			t.Errorf("stmt.Name.Value not '%s'. got=%s", tt.expectedName, stmt.Name.Value)
+		if stmt.Names[0].Value != tt.expectedName {
+			t.Errorf("stmt.Names[0].Value not '%s'. got=%s", tt.expectedName, stmt.Names[0].Value)
 		}
 
 		primitiveType, ok := stmt.DataType.(*ast.PrimitiveType)
@@ -52,6 +52,86 @@ func TestParseDeclareStatement(t *testing.T) {
 	}
 }
 
+func TestParseDeclareWithValue(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedType  string // empty when the type is inferred
+		expectedValue string // empty when there is no initial value
+	}{
+		{"DECLARE Count : INTEGER <- 5", "INTEGER", "5"},
+		{"DECLARE Total : REAL ← Price * 2", "REAL", "Price * 2"},
+		{`DECLARE Name <- "Ada"`, "", `"Ada"`},
+		{"DECLARE Flag : BOOLEAN", "BOOLEAN", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.DeclareStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.DeclareStatement. got=%T",
+				program.Statements[0])
+		}
+
+		typ := ""
+		if stmt.DataType != nil {
+			typ = stmt.DataType.String()
+		}
+		if typ != tt.expectedType {
+			t.Errorf("%q: expected type %q, got %q", tt.input, tt.expectedType, typ)
+		}
+
+		value := ""
+		if stmt.Value != nil {
+			value = stmt.Value.String()
+		}
+		if value != tt.expectedValue {
+			t.Errorf("%q: expected value %q, got %q", tt.input, tt.expectedValue, value)
+		}
+	}
+}
+
+func TestParseDeclareMultipleNames(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedNames []string
+	}{
+		{"DECLARE a, b, c : INTEGER", []string{"a", "b", "c"}},
+		{"DECLARE Row, Col : ARRAY[1:3] OF REAL", []string{"Row", "Col"}},
+		{"DECLARE Low, High : INTEGER <- 0", []string{"Low", "High"}},
+		{"DECLARE x : INTEGER", []string{"x"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.DeclareStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.DeclareStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if len(stmt.Names) != len(tt.expectedNames) {
+			t.Fatalf("%q: expected %d names, got %d", tt.input, len(tt.expectedNames), len(stmt.Names))
+		}
+		for idx, name := range tt.expectedNames {
+			if stmt.Names[idx].Value != name {
+				t.Errorf("%q: name %d not '%s'. got=%s", tt.input, idx, name, stmt.Names[idx].Value)
+			}
+		}
+
+		if stmt.String() != tt.input {
+			t.Errorf("expected String() %q, got %q", tt.input, stmt.String())
+		}
+	}
+}
+
 func TestParseArrayDeclaration(t *testing.T) {
 	input := `DECLARE arr : ARRAY[1:10] OF INTEGER`
 
@@ -71,8 +151,8 @@ func TestParseArrayDeclaration(t *testing.T) {
 			program.Statements[0])
 	}
 
-	if stmt.Name.Value != "arr" {
-		t.Errorf("stmt.Name.Value not 'arr'. got=%s", stmt.Name.Value)
+	if stmt.Names[0].Value != "arr" {
+		t.Errorf("stmt.Names[0].Value not 'arr'. got=%s", stmt.Names[0].Value)
 	}
 
 	arrType, ok := stmt.DataType.(*ast.ArrayType)
@@ -116,6 +196,54 @@ func TestParse2DArrayDeclaration(t *testing.T) {
 	}
 }
 
+func TestParseArrayDeclarationWithConstantBound(t *testing.T) {
+	input := `DECLARE arr : ARRAY[1:MaxSize] OF INTEGER`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.DeclareStatement)
+	arrType := stmt.DataType.(*ast.ArrayType)
+
+	dim := arrType.Dimensions[0]
+	if dim.LowerExpr != nil || dim.Lower != 1 {
+		t.Errorf("lower bound wrong. expected literal 1, got %d (%v)", dim.Lower, dim.LowerExpr)
+	}
+
+	ident, ok := dim.UpperExpr.(*ast.Identifier)
+	if !ok || ident.Value != "MaxSize" {
+		t.Fatalf("upper bound is not identifier MaxSize. got=%T (%v)", dim.UpperExpr, dim.UpperExpr)
+	}
+
+	if stmt.String() != input {
+		t.Errorf("stmt.String() wrong. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
+func TestParseDictionaryDeclaration(t *testing.T) {
+	input := `DECLARE Stock : DICTIONARY OF STRING TO INTEGER`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.DeclareStatement)
+	dictType, ok := stmt.DataType.(*ast.DictionaryType)
+	if !ok {
+		t.Fatalf("DataType is not *ast.DictionaryType. got=%T", stmt.DataType)
+	}
+	if dictType.KeyType.String() != "STRING" || dictType.ValueType.String() != "INTEGER" {
+		t.Errorf("wrong types. expected STRING TO INTEGER, got %s TO %s", dictType.KeyType, dictType.ValueType)
+	}
+
+	if stmt.String() != input {
+		t.Errorf("stmt.String() wrong. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
 func TestParseConstantStatement(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -258,6 +386,21 @@ func TestParseBooleanLiteral(t *testing.T) {
 	}
 }
 
+func TestParseNullLiteral(t *testing.T) {
+	l := lexer.New("x <- null")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.AssignmentStatement)
+	if _, ok := stmt.Value.(*ast.NullLiteral); !ok {
+		t.Fatalf("stmt.Value is not *ast.NullLiteral. got=%T", stmt.Value)
+	}
+	if stmt.String() != "x <- NULL" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
 func TestParsePrefixExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -386,6 +529,10 @@ func TestOperatorPrecedence(t *testing.T) {
 		{"x <- -5 + 3", "x <- ((- 5) + 3)"},
 		{"x <- NOT TRUE AND FALSE", "x <- ((NOT TRUE) AND FALSE)"},
 		{"x <- TRUE OR FALSE AND TRUE", "x <- (TRUE OR (FALSE AND TRUE))"},
+		{"x <- 2 * 3 ^ 2", "x <- (2 * (3 ^ 2))"},
+		{"x <- 2 ^ 3 ^ 2", "x <- (2 ^ (3 ^ 2))"},
+		{"x <- -2 ^ 2", "x <- (- (2 ^ 2))"},
+		{"x <- 2 ^ -1", "x <- (2 ^ (- 1))"},
 	}
 
 	for _, tt := range tests {
@@ -394,10 +541,60 @@ func TestOperatorPrecedence(t *testing.T) {
 		program := p.ParseProgram()
 		checkParserErrors(t, p)
 
-		actual := program.Statements[0].String()
+		stmt := program.Statements[0].(*ast.AssignmentStatement)
+		actual := "x <- " + parenthesize(stmt.Value)
 		if actual != tt.expected {
 			t.Errorf("expected=%q, got=%q", tt.expected, actual)
 		}
+
+		// String() adds only the parentheses the grouping needs, so each
+		// input prints back unchanged
+		if printed := stmt.String(); printed != tt.input {
+			t.Errorf("expected %q to print unchanged, got %q", tt.input, printed)
+		}
+	}
+}
+
+// parenthesize prints expr with every operation in parentheses, showing how
+// the parser grouped it
+func parenthesize(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.PrefixExpression:
+		return "(" + e.Operator + " " + parenthesize(e.Right) + ")"
+	case *ast.InfixExpression:
+		return "(" + parenthesize(e.Left) + " " + e.Operator + " " + parenthesize(e.Right) + ")"
+	}
+	return expr.String()
+}
+
+func TestExpressionStringRoundTrips(t *testing.T) {
+	tests := []string{
+		"1 + 2 * 3",
+		"(1 + 2) * 3",
+		"1 - (2 - 3)",
+		"1 - 2 - 3",
+		"(2 ^ 3) ^ 2",
+		"(-2) ^ 2",
+		"-(a + b) * c",
+		"NOT (a AND b) OR c",
+		"a * -b + c",
+		"(a OR b) AND c",
+		"x = (y = z)",
+		"(a & b)[2]",
+		"Items[i + 1].Name & \" \" & Names[i]",
+		"Square(n - 1) DIV 2 MOD 3",
+	}
+
+	for _, input := range tests {
+		l := lexer.New("x <- " + input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.AssignmentStatement)
+		if printed := stmt.Value.String(); printed != input {
+			t.Errorf("expected %q to print unchanged, got %q", input, printed)
+		}
 	}
 }
 
@@ -462,6 +659,80 @@ ENDIF`
 	}
 }
 
+func TestParseElseIfChain(t *testing.T) {
+	inputs := []string{`IF x >= 90 THEN
+    grade <- "A"
+ELSE IF x >= 80 THEN
+    grade <- "B"
+ELSE IF x >= 70 THEN
+    grade <- "C"
+ELSE
+    grade <- "F"
+ENDIF
+OUTPUT grade`, `IF x >= 90 THEN
+    grade <- "A"
+ELSEIF x >= 80 THEN
+    grade <- "B"
+ELSEIF x >= 70 THEN
+    grade <- "C"
+ELSE
+    grade <- "F"
+ENDIF
+OUTPUT grade`}
+
+	for _, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 2 {
+			t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+				len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.IfStatement)
+		depth := 0
+		for {
+			if len(stmt.Alternative) != 1 {
+				t.Fatalf("link %d: Alternative should have 1 statement. got=%d", depth, len(stmt.Alternative))
+			}
+			next, ok := stmt.Alternative[0].(*ast.IfStatement)
+			if !ok {
+				break
+			}
+			stmt = next
+			depth++
+		}
+
+		if depth != 2 {
+			t.Errorf("expected 2 chained ELSE IF links, got %d", depth)
+		}
+	}
+}
+
+func TestParseElseFollowedByNestedIf(t *testing.T) {
+	input := `IF x > 5 THEN
+    OUTPUT "big"
+ELSE
+    IF x > 2 THEN
+        OUTPUT "medium"
+    ENDIF
+    OUTPUT "not big"
+ENDIF`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.IfStatement)
+
+	if len(stmt.Alternative) != 2 {
+		t.Errorf("Alternative should have 2 statements. got=%d", len(stmt.Alternative))
+	}
+}
+
 func TestParseCaseStatement(t *testing.T) {
 	input := `CASE OF grade
     'A' : OUTPUT "Excellent"
@@ -489,6 +760,45 @@ ENDCASE`
 	}
 }
 
+func TestParseCaseMultiStatementBodies(t *testing.T) {
+	input := `CASE OF n
+    -1 : OUTPUT "negative"
+         Count <- Count + 1
+    (2 * 3) :
+        Log("six")
+        Total <- Total + n
+    Limit, 10 : Log("limit")
+         Show
+    OTHERWISE : Log("other")
+         Count <- 0
+ENDCASE`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.CaseStatement)
+
+	if len(stmt.Cases) != 3 {
+		t.Fatalf("expected 3 cases, got %d", len(stmt.Cases))
+	}
+
+	expected := []string{"-1", "2 * 3", "Limit"}
+	for idx, c := range stmt.Cases {
+		if c.Values[0].String() != expected[idx] {
+			t.Errorf("case %d: expected label %s, got %s", idx, expected[idx], c.Values[0].String())
+		}
+		if len(c.Body) != 2 {
+			t.Errorf("case %d: expected 2 statements, got %d", idx, len(c.Body))
+		}
+	}
+
+	if len(stmt.Otherwise) != 2 {
+		t.Errorf("expected 2 OTHERWISE statements, got %d", len(stmt.Otherwise))
+	}
+}
+
 func TestParseCaseWithRange(t *testing.T) {
 	input := `CASE OF score
     0 TO 49 : OUTPUT "Fail"
@@ -770,9 +1080,13 @@ func TestParseInputStatement(t *testing.T) {
 			program.Statements[0])
 	}
 
-	ident, ok := stmt.Variable.(*ast.Identifier)
+	if len(stmt.Variables) != 1 {
+		t.Fatalf("expected 1 variable, got %d", len(stmt.Variables))
+	}
+
+	ident, ok := stmt.Variables[0].(*ast.Identifier)
 	if !ok {
-		t.Fatalf("stmt.Variable is not *ast.Identifier. got=%T", stmt.Variable)
+		t.Fatalf("stmt.Variables[0] is not *ast.Identifier. got=%T", stmt.Variables[0])
 	}
 
 	if ident.Value != "name" {
@@ -780,6 +1094,24 @@ func TestParseInputStatement(t *testing.T) {
 	}
 }
 
+func TestParseInputMultipleVariables(t *testing.T) {
+	input := `INPUT x, Scores[i], p.Name`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.InputStatement)
+	if len(stmt.Variables) != 3 {
+		t.Fatalf("expected 3 variables, got %d", len(stmt.Variables))
+	}
+
+	if stmt.String() != input {
+		t.Errorf("stmt.String() wrong. expected=%q, got=%q", input, stmt.String())
+	}
+}
+
 func TestParseOutputStatement(t *testing.T) {
 	input := `OUTPUT "Hello", name, 42`
 
@@ -799,6 +1131,55 @@ func TestParseOutputStatement(t *testing.T) {
 	}
 }
 
+func TestParseEprintStatement(t *testing.T) {
+	input := `EPRINT "Error: ", code`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.OutputStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.OutputStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if !stmt.ToError {
+		t.Error("expected EPRINT to write to the error output")
+	}
+	if len(stmt.Values) != 2 {
+		t.Errorf("expected 2 values, got %d", len(stmt.Values))
+	}
+	if stmt.String() != input {
+		t.Errorf("expected String() %q, got %q", input, stmt.String())
+	}
+}
+
+func TestParseBareOutputStatement(t *testing.T) {
+	input := `OUTPUT
+OUTPUT`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(program.Statements))
+	}
+
+	for _, s := range program.Statements {
+		stmt, ok := s.(*ast.OutputStatement)
+		if !ok {
+			t.Fatalf("statement is not *ast.OutputStatement. got=%T", s)
+		}
+		if len(stmt.Values) != 0 {
+			t.Errorf("expected no values, got %d", len(stmt.Values))
+		}
+	}
+}
+
 func TestParseOpenFileStatement(t *testing.T) {
 	tests := []struct {
 		input        string
@@ -807,6 +1188,7 @@ func TestParseOpenFileStatement(t *testing.T) {
 		{`OPENFILE "data.txt" FOR READ`, "READ"},
 		{`OPENFILE "data.txt" FOR WRITE`, "WRITE"},
 		{`OPENFILE "data.txt" FOR APPEND`, "APPEND"},
+		{`OPENFILE "data.dat" FOR RANDOM`, "RANDOM"},
 	}
 
 	for _, tt := range tests {
@@ -883,8 +1265,80 @@ func TestParseWriteFileStatement(t *testing.T) {
 		t.Fatal("stmt.Filename should not be nil")
 	}
 
-	if stmt.Data == nil {
-		t.Fatal("stmt.Data should not be nil")
+	if len(stmt.Values) != 1 || stmt.NoNewline {
+		t.Fatalf("expected 1 value ending the line, got %d values (NoNewline=%v)", len(stmt.Values), stmt.NoNewline)
+	}
+}
+
+func TestParseWriteFileList(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedValues    int
+		expectedNoNewline bool
+	}{
+		{`WRITEFILE "data.txt", Name, ",", Age`, 3, false},
+		{`WRITEFILE "data.txt", Name, ","`, 2, false},
+		{`WRITEFILE "data.txt", Name, ",",`, 2, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.WriteFileStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.WriteFileStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if len(stmt.Values) != tt.expectedValues {
+			t.Errorf("%q: expected %d values, got %d", tt.input, tt.expectedValues, len(stmt.Values))
+		}
+		if stmt.NoNewline != tt.expectedNoNewline {
+			t.Errorf("%q: expected NoNewline=%v, got %v", tt.input, tt.expectedNoNewline, stmt.NoNewline)
+		}
+		if stmt.String() != tt.input {
+			t.Errorf("stmt.String() wrong. expected=%q, got=%q", tt.input, stmt.String())
+		}
+	}
+}
+
+func TestParseRandomFileStatements(t *testing.T) {
+	input := `SEEK "stock.dat", Position + 1
+GETRECORD "stock.dat", Item
+PUTRECORD "stock.dat", Items[3]`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d",
+			len(program.Statements))
+	}
+
+	if _, ok := program.Statements[0].(*ast.SeekStatement); !ok {
+		t.Errorf("program.Statements[0] is not *ast.SeekStatement. got=%T", program.Statements[0])
+	}
+	if _, ok := program.Statements[1].(*ast.GetRecordStatement); !ok {
+		t.Errorf("program.Statements[1] is not *ast.GetRecordStatement. got=%T", program.Statements[1])
+	}
+	if _, ok := program.Statements[2].(*ast.PutRecordStatement); !ok {
+		t.Errorf("program.Statements[2] is not *ast.PutRecordStatement. got=%T", program.Statements[2])
+	}
+
+	expected := []string{
+		`SEEK "stock.dat", Position + 1`,
+		`GETRECORD "stock.dat", Item`,
+		`PUTRECORD "stock.dat", Items[3]`,
+	}
+	for idx, want := range expected {
+		if got := program.Statements[idx].String(); got != want {
+			t.Errorf("statement %d wrong. expected=%q, got=%q", idx, want, got)
+		}
 	}
 }
 
@@ -919,6 +1373,68 @@ ENDTYPE`
 	}
 }
 
+func TestParsePointerTypes(t *testing.T) {
+	input := `TYPE IntPointer = ^INTEGER
+DECLARE p : ^INTEGER`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if got := program.String(); got != "TYPE IntPointer = ^INTEGER\nDECLARE p : ^INTEGER\n" {
+		t.Errorf("wrong pointer types. got=%q", got)
+	}
+}
+
+func TestParseDefineStatement(t *testing.T) {
+	input := `TYPE LetterSet = SET OF CHAR
+DEFINE Vowels ('A', 'E', 'I', 'O', 'U') : LetterSet`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	typeStmt, ok := program.Statements[0].(*ast.TypeStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.TypeStatement. got=%T",
+			program.Statements[0])
+	}
+
+	setType, ok := typeStmt.Definition.(*ast.SetType)
+	if !ok {
+		t.Fatalf("typeStmt.Definition is not *ast.SetType. got=%T", typeStmt.Definition)
+	}
+
+	if setType.String() != "SET OF CHAR" {
+		t.Errorf("setType.String() wrong. got=%q", setType.String())
+	}
+
+	stmt, ok := program.Statements[1].(*ast.DefineStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not *ast.DefineStatement. got=%T",
+			program.Statements[1])
+	}
+
+	if stmt.Name.Value != "Vowels" {
+		t.Errorf("stmt.Name.Value not 'Vowels'. got=%s", stmt.Name.Value)
+	}
+
+	if len(stmt.Values) != 5 {
+		t.Fatalf("stmt.Values does not contain 5 values. got=%d", len(stmt.Values))
+	}
+
+	if stmt.TypeName != "LetterSet" {
+		t.Errorf("stmt.TypeName not 'LetterSet'. got=%s", stmt.TypeName)
+	}
+}
+
 func TestParseClassStatement(t *testing.T) {
 	input := `CLASS Animal
     PRIVATE DECLARE name : STRING
@@ -989,6 +1505,25 @@ func TestParseArrayAccess(t *testing.T) {
 	}
 }
 
+func TestParseArraySlice(t *testing.T) {
+	input := `CALL Sort(arr[lo + 1..hi])`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.CallStatement)
+	slice, ok := stmt.Arguments[0].(*ast.SliceExpression)
+	if !ok {
+		t.Fatalf("argument is not *ast.SliceExpression. got=%T", stmt.Arguments[0])
+	}
+
+	if slice.String() != "arr[lo + 1..hi]" {
+		t.Errorf("wrong slice. got=%s", slice.String())
+	}
+}
+
 func TestParse2DArrayAccess(t *testing.T) {
 	input := `x <- matrix[1, 2]`
 
@@ -1147,6 +1682,56 @@ func TestParseMemberAssignment(t *testing.T) {
 	}
 }
 
+func TestParseSemicolonSeparatedStatements(t *testing.T) {
+	input := `x <- 1; y <- 2`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	for idx, name := range []string{"x", "y"} {
+		stmt, ok := program.Statements[idx].(*ast.AssignmentStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not *ast.AssignmentStatement. got=%T",
+				idx, program.Statements[idx])
+		}
+		if stmt.Name.String() != name {
+			t.Errorf("program.Statements[%d] assigns %s, want %s", idx, stmt.Name.String(), name)
+		}
+		testIntegerLiteral(t, stmt.Value, int64(idx+1))
+	}
+}
+
+func TestParseSemicolonsInBlock(t *testing.T) {
+	input := `IF x > 0 THEN a <- 1; b <- 2; ENDIF
+OUTPUT a + b`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.IfStatement. got=%T", program.Statements[0])
+	}
+
+	if len(stmt.Consequence) != 2 {
+		t.Errorf("consequence does not contain 2 statements. got=%d", len(stmt.Consequence))
+	}
+}
+
 func TestParserErrors(t *testing.T) {
 	input := `DECLARE x`
 
@@ -1160,6 +1745,147 @@ func TestParserErrors(t *testing.T) {
 	}
 }
 
+func TestParseMismatchedBlockTerminator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"WHILE x < 3\n    x <- x + 1\nENDFOR", "line 3, column 1: expected ENDWHILE to close WHILE started at line 1, got ENDFOR"},
+		{"FOR i <- 1 TO 3\n    OUTPUT i\nENDWHILE", "line 3, column 1: expected NEXT to close FOR started at line 1, got ENDWHILE"},
+		{"x <- 0\nREPEAT\n    x <- x + 1\nENDWHILE", "line 4, column 1: expected UNTIL to close REPEAT started at line 2, got ENDWHILE"},
+		{"IF x > 1 THEN\n    OUTPUT x\nELSE\n    OUTPUT 0\nENDWHILE", "line 5, column 1: expected ENDIF to close IF started at line 1, got ENDWHILE"},
+		{"PROCEDURE Show()\n    OUTPUT 1\nENDFUNCTION", "line 3, column 1: expected ENDPROCEDURE to close PROCEDURE started at line 1, got ENDFUNCTION"},
+		// The innermost open block reports the mismatch
+		{"FOR i <- 1 TO 3\n    WHILE i < 2\n        i <- i + 1\n    NEXT i\nNEXT i", "line 4, column 5: expected ENDWHILE to close WHILE started at line 2, got NEXT"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected an error, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParseUnterminatedBlock(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"IF x > 1 THEN\n    OUTPUT x\n", "unterminated IF statement started at line 1 — missing ENDIF"},
+		{"IF x > 1 THEN\n    OUTPUT x\nELSE\n    OUTPUT 0", "unterminated IF statement started at line 1 — missing ENDIF"},
+		{"x <- 0\nWHILE x < 3\n    x <- x + 1\n", "unterminated WHILE loop started at line 2 — missing ENDWHILE"},
+		{"FOR i <- 1 TO 3\n    OUTPUT i", "unterminated FOR loop started at line 1 — missing NEXT"},
+		{"REPEAT\n    OUTPUT 1\n", "unterminated REPEAT loop started at line 1 — missing UNTIL"},
+		{"PROCEDURE Show()\n    OUTPUT 1\n", "unterminated PROCEDURE started at line 1 — missing ENDPROCEDURE"},
+		{"FUNCTION One() RETURNS INTEGER\n    RETURN 1\n", "unterminated FUNCTION started at line 1 — missing ENDFUNCTION"},
+		{"CASE OF x\n    1 : OUTPUT 1\n", "unterminated CASE statement started at line 1 — missing ENDCASE"},
+		{"CLASS Pet\n    PUBLIC Name : STRING\n", "unterminated CLASS started at line 1 — missing ENDCLASS"},
+		{"TYPE Point\n    DECLARE X : INTEGER\n", "unterminated TYPE started at line 1 — missing ENDTYPE"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.StructuredErrors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 error, got %d: %v", tt.input, len(errors), p.Errors())
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestParseIntegerLiteralOverflow(t *testing.T) {
+	input := `x <- 99999999999999999999 + 1
+DECLARE y : INTEGER
+y <- 2`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error, got %d: %v", len(errors), errors)
+	}
+
+	expected := "line 1, column 6: integer literal too large for 64-bit"
+	if errors[0] != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0])
+	}
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+
+	// The tree must stay printable despite the error
+	if got := program.Statements[0].String(); got != "x <- 99999999999999999999 + 1" {
+		t.Errorf("unexpected statement %q", got)
+	}
+	if _, ok := program.Statements[2].(*ast.AssignmentStatement); !ok {
+		t.Errorf("expected statement after the error to parse, got %T", program.Statements[2])
+	}
+}
+
+func TestParserReportsLexerErrors(t *testing.T) {
+	input := `x <- 1
+/* unterminated`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errors), errors)
+	}
+
+	expected := "line 2, column 1: unterminated block comment"
+	if errors[0] != expected {
+		t.Errorf("expected %q, got %q", expected, errors[0])
+	}
+}
+
+func TestStructuredErrors(t *testing.T) {
+	input := `x <- 99999999999999999999
+/* unterminated`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	expected := []ParseError{
+		{Line: 1, Column: 6, Length: 20, Message: "integer literal too large for 64-bit"},
+		{Line: 2, Column: 1, Message: "unterminated block comment"},
+	}
+
+	errors := p.StructuredErrors()
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errors), errors)
+	}
+	for idx, want := range expected {
+		if errors[idx] != want {
+			t.Errorf("error %d: expected %+v, got %+v", idx, want, errors[idx])
+		}
+		if p.Errors()[idx] != want.Error() {
+			t.Errorf("error %d: expected string %q, got %q", idx, want.Error(), p.Errors()[idx])
+		}
+	}
+}
+
 // Helper functions
 
 func checkParserErrors(t *testing.T, p *Parser) {
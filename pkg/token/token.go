@@ -18,20 +18,22 @@ const (
 	CHAR_LIT    Type = "CHAR_LIT"
 	TRUE        Type = "TRUE"
 	FALSE       Type = "FALSE"
+	NULL        Type = "NULL"
 
 	// Identifier
 	IDENT Type = "IDENT"
 
 	// Data Type Keywords
-	INTEGER Type = "INTEGER"
-	REAL    Type = "REAL"
-	STRING  Type = "STRING"
-	CHAR    Type = "CHAR"
-	BOOLEAN Type = "BOOLEAN"
-	DATE    Type = "DATE"
-	ARRAY   Type = "ARRAY"
-	OF      Type = "OF"
-	SET     Type = "SET"
+	INTEGER    Type = "INTEGER"
+	REAL       Type = "REAL"
+	STRING     Type = "STRING"
+	CHAR       Type = "CHAR"
+	BOOLEAN    Type = "BOOLEAN"
+	DATE       Type = "DATE"
+	ARRAY      Type = "ARRAY"
+	OF         Type = "OF"
+	SET        Type = "SET"
+	DICTIONARY Type = "DICTIONARY"
 
 	// Declaration Keywords
 	DECLARE  Type = "DECLARE"
@@ -64,6 +66,9 @@ const (
 	OR  Type = "OR"
 	NOT Type = "NOT"
 
+	// Membership
+	IN Type = "IN"
+
 	// String Concatenation
 	AMPERSAND Type = "AMPERSAND" // &
 
@@ -71,6 +76,7 @@ const (
 	IF        Type = "IF"
 	THEN      Type = "THEN"
 	ELSE      Type = "ELSE"
+	ELSEIF    Type = "ELSEIF"
 	ENDIF     Type = "ENDIF"
 	CASE      Type = "CASE"
 	OTHERWISE Type = "OTHERWISE"
@@ -100,12 +106,16 @@ const (
 	// Input/Output
 	INPUT  Type = "INPUT"
 	OUTPUT Type = "OUTPUT"
+	EPRINT Type = "EPRINT"
 
 	// File Handling
 	OPENFILE  Type = "OPENFILE"
 	CLOSEFILE Type = "CLOSEFILE"
 	READFILE  Type = "READFILE"
 	WRITEFILE Type = "WRITEFILE"
+	SEEK      Type = "SEEK"
+	GETRECORD Type = "GETRECORD"
+	PUTRECORD Type = "PUTRECORD"
 	READ      Type = "READ"
 	WRITE     Type = "WRITE"
 	APPEND    Type = "APPEND"
@@ -120,14 +130,18 @@ const (
 	SUPER    Type = "SUPER"
 
 	// Punctuation
-	COLON    Type = "COLON"
-	COMMA    Type = "COMMA"
-	DOT      Type = "DOT"
-	LPAREN   Type = "LPAREN"
-	RPAREN   Type = "RPAREN"
-	LBRACKET Type = "LBRACKET"
-	RBRACKET Type = "RBRACKET"
-	CARET    Type = "CARET" // ^ for pointers
+	COLON     Type = "COLON"
+	SEMICOLON Type = "SEMICOLON" // statement separator
+	COMMA     Type = "COMMA"
+	DOT       Type = "DOT"
+	DOTDOT    Type = "DOTDOT" // .. in array slices
+	LPAREN    Type = "LPAREN"
+	RPAREN    Type = "RPAREN"
+	LBRACKET  Type = "LBRACKET"
+	RBRACKET  Type = "RBRACKET"
+	LBRACE    Type = "LBRACE"
+	RBRACE    Type = "RBRACE"
+	CARET     Type = "CARET" // ^ for pointers
 )
 
 // Token represents a lexical token
@@ -141,19 +155,21 @@ type Token struct {
 // keywords maps keyword strings to their token types
 var Keywords = map[string]Type{
 	// Data types
-	"INTEGER": INTEGER,
-	"REAL":    REAL,
-	"STRING":  STRING,
-	"CHAR":    CHAR,
-	"BOOLEAN": BOOLEAN,
-	"DATE":    DATE,
-	"ARRAY":   ARRAY,
-	"OF":      OF,
-	"SET":     SET,
+	"INTEGER":    INTEGER,
+	"REAL":       REAL,
+	"STRING":     STRING,
+	"CHAR":       CHAR,
+	"BOOLEAN":    BOOLEAN,
+	"DATE":       DATE,
+	"ARRAY":      ARRAY,
+	"OF":         OF,
+	"SET":        SET,
+	"DICTIONARY": DICTIONARY,
 
 	// Boolean literals
 	"TRUE":  TRUE,
 	"FALSE": FALSE,
+	"NULL":  NULL,
 
 	// Declaration
 	"DECLARE":  DECLARE,
@@ -171,10 +187,14 @@ var Keywords = map[string]Type{
 	"OR":  OR,
 	"NOT": NOT,
 
+	// Membership
+	"IN": IN,
+
 	// Selection
 	"IF":        IF,
 	"THEN":      THEN,
 	"ELSE":      ELSE,
+	"ELSEIF":    ELSEIF,
 	"ENDIF":     ENDIF,
 	"CASE":      CASE,
 	"OTHERWISE": OTHERWISE,
@@ -204,12 +224,16 @@ var Keywords = map[string]Type{
 	// I/O
 	"INPUT":  INPUT,
 	"OUTPUT": OUTPUT,
+	"EPRINT": EPRINT,
 
 	// File handling
 	"OPENFILE":  OPENFILE,
 	"CLOSEFILE": CLOSEFILE,
 	"READFILE":  READFILE,
 	"WRITEFILE": WRITEFILE,
+	"SEEK":      SEEK,
+	"GETRECORD": GETRECORD,
+	"PUTRECORD": PUTRECORD,
 	"READ":      READ,
 	"WRITE":     WRITE,
 	"APPEND":    APPEND,
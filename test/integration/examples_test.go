@@ -209,7 +209,8 @@ func findExamplesDir(t *testing.T) string {
 	return ""
 }
 
-// TestExamplesFileIO tests file I/O example separately with cleanup
+// TestExamplesFileIO runs the file I/O example in a temporary directory so
+// that the files it writes do not end up in the tree
 func TestExamplesFileIO(t *testing.T) {
 	examplesDir := findExamplesDir(t)
 	filePath := filepath.Join(examplesDir, "fileio.pseudo")
@@ -219,9 +220,14 @@ func TestExamplesFileIO(t *testing.T) {
 		t.Fatalf("failed to read example file: %v", err)
 	}
 
-	// Clean up any existing test file before running
-	os.Remove("test_output.txt")
-	defer os.Remove("test_output.txt")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("could not change to temporary directory: %v", err)
+	}
+	defer os.Chdir(wd)
 
 	output, err := runProgram(string(content))
 	if err != nil {
@@ -2,6 +2,9 @@ package integration
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -132,6 +135,36 @@ OUTPUT "Score: ", Score, " Grade: ", Grade`
 	}
 }
 
+func TestIntegration_Selection_ElseIf(t *testing.T) {
+	code := `DECLARE Score : INTEGER
+DECLARE Grade : STRING
+
+FOR Score <- 55 TO 95 STEP 10
+    IF Score >= 90 THEN
+        Grade <- "A"
+    ELSE IF Score >= 80 THEN
+        Grade <- "B"
+    ELSE IF Score >= 70 THEN
+        Grade <- "C"
+    ELSE IF Score >= 60 THEN
+        Grade <- "D"
+    ELSE
+        Grade <- "F"
+    ENDIF
+    OUTPUT Score, " ", Grade
+NEXT Score`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "55 F\n65 D\n75 C\n85 B\n95 A\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
 func TestIntegration_Selection_Case(t *testing.T) {
 	code := `DECLARE DayNumber : INTEGER
 DayNumber <- 3
@@ -490,6 +523,27 @@ OUTPUT "Full Name: ", FullName`
 	}
 }
 
+func TestIntegration_NonASCIIString(t *testing.T) {
+	code := `DECLARE Word : STRING
+DECLARE I : INTEGER
+
+Word <- "naïve"
+FOR I <- 1 TO LENGTH(Word)
+    OUTPUT Word[I]
+NEXT I
+OUTPUT MID(Word, 3, 1), LEFT(Word, 3), RIGHT(Word, 3)`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "n\na\nï\nv\ne\nïnaïïve\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
 func TestIntegration_BuildAlphabet(t *testing.T) {
 	code := `DECLARE Result : STRING
 DECLARE I : INTEGER
@@ -781,3 +835,525 @@ OUTPUT "Searching for 35: position ", BinarySearch(numbers, 35, 1, 10)`
 		t.Errorf("expected 35 not found (-1), got %q", output)
 	}
 }
+
+func TestIntegration_WriteFileScalars(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "scalars.txt")
+
+	code := fmt.Sprintf(`DECLARE Letter : CHAR
+Letter <- 'Z'
+OPENFILE %q FOR WRITE
+WRITEFILE %q, 42
+WRITEFILE %q, 3.5
+WRITEFILE %q, "Hello, World"
+WRITEFILE %q, Letter
+WRITEFILE %q, TRUE
+CLOSEFILE %q`, filename, filename, filename, filename, filename, filename, filename)
+
+	if _, err := runProgram(code); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+
+	expected := "42\n3.5\nHello, World\nZ\nTRUE\n"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, string(content))
+	}
+}
+
+func TestIntegration_MutualRecursion(t *testing.T) {
+	code := `FUNCTION IsEven(N : INTEGER) RETURNS BOOLEAN
+    IF N = 0 THEN
+        RETURN TRUE
+    ENDIF
+    RETURN IsOdd(N - 1)
+ENDFUNCTION
+
+FUNCTION IsOdd(N : INTEGER) RETURNS BOOLEAN
+    IF N = 0 THEN
+        RETURN FALSE
+    ENDIF
+    RETURN IsEven(N - 1)
+ENDFUNCTION
+
+OUTPUT IsEven(10), " ", IsOdd(7)
+
+CLASS Parity
+    PUBLIC FUNCTION Even(N : INTEGER) RETURNS BOOLEAN
+        IF N = 0 THEN
+            RETURN TRUE
+        ENDIF
+        RETURN Odd(N - 1)
+    ENDFUNCTION
+
+    PUBLIC FUNCTION Odd(N : INTEGER) RETURNS BOOLEAN
+        IF N = 0 THEN
+            RETURN FALSE
+        ENDIF
+        RETURN Even(N - 1)
+    ENDFUNCTION
+ENDCLASS
+
+DECLARE P : Parity
+P <- NEW Parity()
+OUTPUT P.Even(4), " ", P.Odd(4)`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "TRUE TRUE\nTRUE FALSE\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ArrayOfRecords(t *testing.T) {
+	code := `TYPE Person
+    DECLARE Name : STRING
+    DECLARE Age : INTEGER
+ENDTYPE
+
+DECLARE People : ARRAY[1:3] OF Person
+People[1].Name <- "Ada"
+People[1].Age <- 36
+People[2].Name <- "Alan"
+People[2].Age <- 41
+
+FOR i <- 1 TO 2
+    OUTPUT People[i].Name, " ", People[i].Age
+NEXT i
+
+DECLARE Seats : ARRAY[1:2, 1:2] OF Person
+Seats[2, 1].Name <- "Grace"
+OUTPUT Seats[2, 1].Name`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Ada 36\nAlan 41\nGrace\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ArrayOfRecordsOutOfBounds(t *testing.T) {
+	code := `TYPE R
+    DECLARE N : INTEGER
+ENDTYPE
+
+DECLARE a : ARRAY[1:3] OF R
+a[99].N <- 5`
+
+	_, err := runProgram(code)
+	if err == nil || err.Error() != "array index [99] out of bounds" {
+		t.Fatalf("expected an out of bounds error, got %v", err)
+	}
+}
+
+func TestIntegration_ArraySliceArguments(t *testing.T) {
+	code := `PROCEDURE DoubleAll(Items : ARRAY[1:3] OF INTEGER)
+    FOR i <- 1 TO 3
+        Items[i] <- Items[i] * 2
+    NEXT i
+    OUTPUT Items[1], " ", Items[3]
+ENDPROCEDURE
+
+PROCEDURE DoubleAllRef(BYREF Items : ARRAY[1:3] OF INTEGER)
+    FOR i <- 1 TO 3
+        Items[i] <- Items[i] * 2
+    NEXT i
+ENDPROCEDURE
+
+DECLARE Data : ARRAY[1:6] OF INTEGER
+FOR i <- 1 TO 6
+    Data[i] <- i
+NEXT i
+
+CALL DoubleAll(Data[2..4])
+OUTPUT Data[2], " ", Data[3], " ", Data[4]
+
+CALL DoubleAllRef(Data[2..4])
+OUTPUT Data[1], " ", Data[2], " ", Data[3], " ", Data[4], " ", Data[5]`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "4 8\n2 3 4\n1 4 6 8 5\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ArraySliceOutOfBounds(t *testing.T) {
+	code := `DECLARE Data : ARRAY[1:3] OF INTEGER
+OUTPUT Data[2..4][1]`
+
+	_, err := runProgram(code)
+	if err == nil || !strings.Contains(err.Error(), "array slice 2..4 out of bounds 1:3") {
+		t.Errorf("expected out of bounds error, got %v", err)
+	}
+}
+
+func TestIntegration_CaseMultiStatementBodies(t *testing.T) {
+	code := `DECLARE Count : INTEGER
+Count <- 0
+FOR n <- -1 TO 2
+    CASE OF n
+        -1 : OUTPUT "negative"
+             Count <- Count + 1
+        0 :
+            OUTPUT "zero"
+            Count <- Count + 10
+        OTHERWISE : OUTPUT "positive"
+             Count <- Count + 100
+    ENDCASE
+NEXT n
+OUTPUT Count`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "negative\nzero\npositive\npositive\n211\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ArrayToString(t *testing.T) {
+	code := `DECLARE Word : ARRAY[1:5] OF CHAR
+Word[1] <- 'H'
+Word[2] <- 'E'
+Word[3] <- 'L'
+Word[4] <- 'L'
+Word[5] <- 'O'
+OUTPUT ARRAY_TO_STRING(Word)`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output != "HELLO\n" {
+		t.Errorf("expected %q, got %q", "HELLO\n", output)
+	}
+}
+
+func TestIntegration_StringToArray(t *testing.T) {
+	code := `DECLARE Letters : ARRAY[1:3] OF CHAR
+Letters <- STRING_TO_ARRAY("abc")
+FOR i <- 3 TO 1 STEP -1
+    OUTPUT Letters[i]
+NEXT i`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "c\nb\na\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_Dictionary(t *testing.T) {
+	code := `DECLARE Counts : DICTIONARY OF CHAR TO INTEGER
+DECLARE Word : STRING
+Word <- "banana"
+Counts['a'] <- 0
+Counts['b'] <- 0
+Counts['n'] <- 0
+FOR i <- 1 TO LENGTH(Word)
+    Counts[Word[i]] <- Counts[Word[i]] + 1
+NEXT i
+OUTPUT Counts['a'], " ", Counts['b'], " ", Counts['n']`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "3 1 2\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ContainsKey(t *testing.T) {
+	code := `DECLARE Words : ARRAY[1:5] OF STRING
+DECLARE Counts : DICTIONARY OF STRING TO INTEGER
+Words[1] <- "to"
+Words[2] <- "be"
+Words[3] <- "or"
+Words[4] <- "not"
+Words[5] <- "to"
+FOR i <- 1 TO 5
+    IF CONTAINSKEY(Counts, Words[i]) THEN
+        Counts[Words[i]] <- Counts[Words[i]] + 1
+    ELSE
+        Counts[Words[i]] <- 1
+    ENDIF
+NEXT i
+OUTPUT Counts["to"], " ", Counts["be"]
+OUTPUT CONTAINS(Words, "not"), " ", CONTAINS(Words, "maybe")`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "2 1\nTRUE FALSE\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_Assert(t *testing.T) {
+	code := `FUNCTION Square(n : INTEGER) RETURNS INTEGER
+    RETURN n * n
+ENDFUNCTION
+ASSERT(Square(3) = 9, "Square(3) should be 9")
+OUTPUT "first check passed"
+ASSERT(Square(-2) = -4, "Square(-2) should be -4")
+OUTPUT "not reached"`
+
+	output, err := runProgram(code)
+	if err == nil || err.Error() != "assertion failed: Square(-2) should be -4" {
+		t.Fatalf("expected the second assertion to fail, got %v", err)
+	}
+	if output != "first check passed\n" {
+		t.Errorf("expected the program to stop at the failed assertion, got %q", output)
+	}
+}
+
+func TestIntegration_AssertWithoutParentheses(t *testing.T) {
+	code := `ASSERT 1 = 2
+OUTPUT "not reached"`
+
+	output, err := runProgram(code)
+	expected := "ASSERT is a built-in function and must be called with parentheses, as in ASSERT(...)"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+	if output != "" {
+		t.Errorf("expected no output, got %q", output)
+	}
+}
+
+func TestIntegration_BooleanOutput(t *testing.T) {
+	code := `DECLARE Flag : BOOLEAN <- FALSE
+OUTPUT "Result: " & (5 > 3)
+OUTPUT 5 > 3, " ", Flag
+OUTPUT "Flag: " & Flag & ", " & (1 = 1)`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Result: TRUE\nTRUE FALSE\nFlag: FALSE, TRUE\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ChainedMemberAssignment(t *testing.T) {
+	code := `TYPE Item
+    DECLARE Name : STRING
+    DECLARE Tags : ARRAY[1:2] OF STRING
+ENDTYPE
+
+CLASS Box
+    PUBLIC Items : ARRAY[1:3] OF Item
+    PUBLIC PROCEDURE NEW()
+    ENDPROCEDURE
+ENDCLASS
+
+DECLARE Crate : Box
+Crate <- NEW Box()
+Crate.Items[3].Name <- "lamp"
+Crate.Items[3].Tags[2] <- "fragile"
+OUTPUT Crate.Items[3].Name, " ", Crate.Items[3].Tags[2]
+
+DECLARE Shelf : ARRAY[1:2] OF Item
+Shelf[1].Tags[1] <- "new"
+Shelf[1].Name <- Shelf[1].Tags[1] & " lamp"
+OUTPUT Shelf[1].Name`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "lamp fragile\nnew lamp\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_UnicodeIdentifiers(t *testing.T) {
+	code := `DECLARE Größe : INTEGER
+DECLARE Café : STRING
+Größe <- 3
+Café ← "crème brûlée"
+OUTPUT Café, " x", Größe, " ", Café[2]`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "crème brûlée x3 r\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_NoTrailingNewline(t *testing.T) {
+	programs := []string{
+		`OUTPUT 1 + 2 * 3`,
+		`OUTPUT "a", "b"`,
+		`OUTPUT`,
+		`OUTPUT NOT FALSE`,
+		"DECLARE x : INTEGER\nx <- 2 ^ 3\nOUTPUT x",
+		"IF 1 < 2 THEN\n    OUTPUT \"yes\"\nENDIF",
+		"FOR i <- 1 TO 2\n    OUTPUT i\nNEXT i",
+		"FUNCTION Twice(n : INTEGER) RETURNS INTEGER\n    RETURN n * 2\nENDFUNCTION\nOUTPUT Twice(4)",
+	}
+
+	for _, code := range programs {
+		withoutNewline, err := runProgram(code)
+		if err != nil {
+			t.Errorf("%q without trailing newline: unexpected error: %v", code, err)
+			continue
+		}
+
+		withNewline, err := runProgram(code + "\n")
+		if err != nil {
+			t.Errorf("%q with trailing newline: unexpected error: %v", code, err)
+			continue
+		}
+
+		if withoutNewline != withNewline {
+			t.Errorf("%q: output differs without trailing newline: %q vs %q", code, withoutNewline, withNewline)
+		}
+	}
+}
+
+func TestIntegration_SuperConstructors(t *testing.T) {
+	code := `CLASS Shape
+    PRIVATE DECLARE Name : STRING
+    PUBLIC PROCEDURE NEW(GivenName : STRING)
+        Name <- GivenName
+    ENDPROCEDURE
+    PUBLIC FUNCTION Describe() RETURNS STRING
+        RETURN Name
+    ENDFUNCTION
+ENDCLASS
+
+CLASS Rectangle INHERITS Shape
+    PRIVATE DECLARE Width : INTEGER
+    PRIVATE DECLARE Height : INTEGER
+    PUBLIC PROCEDURE NEW(GivenName : STRING, W : INTEGER, H : INTEGER)
+        SUPER.NEW(GivenName)
+        Width <- W
+        Height <- H
+    ENDPROCEDURE
+    PUBLIC FUNCTION Area() RETURNS INTEGER
+        RETURN Width * Height
+    ENDFUNCTION
+ENDCLASS
+
+CLASS Square INHERITS Rectangle
+    PUBLIC PROCEDURE NEW(Side : INTEGER)
+        SUPER.NEW("square", Side, Side)
+    ENDPROCEDURE
+ENDCLASS
+
+CLASS Box INHERITS Rectangle
+ENDCLASS
+
+DECLARE S : Square
+DECLARE B : Box
+S <- NEW Square(3)
+B <- NEW Box("box", 2, 5)
+OUTPUT S.Describe(), " ", S.Area()
+OUTPUT B.Describe(), " ", B.Area()`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "square 9\nbox 10\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestIntegration_ConstructorErrorsPropagate(t *testing.T) {
+	code := `CLASS Account
+    PUBLIC PROCEDURE NEW(Amount : INTEGER)
+        OUTPUT 10 DIV Amount
+    ENDPROCEDURE
+ENDCLASS
+
+DECLARE A : Account
+A <- NEW Account(0)
+OUTPUT "unreachable"`
+
+	output, err := runProgram(code)
+	if err == nil {
+		t.Fatalf("expected error, got output %q", output)
+	}
+	if strings.Contains(output, "unreachable") {
+		t.Errorf("program continued after a failed constructor: %q", output)
+	}
+}
+
+func TestIntegration_Polymorphism(t *testing.T) {
+	code := `CLASS Animal
+    PUBLIC PROCEDURE Speak()
+        OUTPUT "..."
+    ENDPROCEDURE
+    PUBLIC PROCEDURE Greet()
+        CALL Speak()
+    ENDPROCEDURE
+ENDCLASS
+
+CLASS Dog INHERITS Animal
+    PUBLIC PROCEDURE Speak()
+        OUTPUT "Woof"
+    ENDPROCEDURE
+ENDCLASS
+
+DECLARE Pet : Animal
+Pet <- NEW Dog()
+CALL Pet.Speak()
+CALL Pet.Greet()
+OUTPUT ISINSTANCE(Pet, "Dog"), " ", ISINSTANCE(Pet, "Animal")
+Pet <- NEW Animal()
+CALL Pet.Speak()
+OUTPUT ISINSTANCE(Pet, "Dog")`
+
+	output, err := runProgram(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Woof\nWoof\nTRUE TRUE\n...\nFALSE\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
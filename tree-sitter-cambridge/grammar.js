@@ -4,6 +4,7 @@
 const KEYWORDS = [
   "DECLARE",
   "CONSTANT",
+  "DEFINE",
   "TYPE",
   "ENDTYPE",
   "INTEGER",
@@ -13,9 +14,11 @@ const KEYWORDS = [
   "BOOLEAN",
   "DATE",
   "ARRAY",
+  "DICTIONARY",
   "OF",
   "TRUE",
   "FALSE",
+  "NULL",
   "AND",
   "OR",
   "NOT",
@@ -24,6 +27,7 @@ const KEYWORDS = [
   "IF",
   "THEN",
   "ELSE",
+  "ELSEIF",
   "ENDIF",
   "CASE",
   "OTHERWISE",
@@ -47,6 +51,7 @@ const KEYWORDS = [
   "BYREF",
   "INPUT",
   "OUTPUT",
+  "EPRINT",
   "OPENFILE",
   "CLOSEFILE",
   "READFILE",
@@ -54,6 +59,10 @@ const KEYWORDS = [
   "READ",
   "WRITE",
   "APPEND",
+  "RANDOM",
+  "SEEK",
+  "GETRECORD",
+  "PUTRECORD",
   "CLASS",
   "ENDCLASS",
   "INHERITS",
@@ -77,6 +86,7 @@ module.exports = grammar({
       choice(
         $.declaration,
         $.constant_declaration,
+        $.define_statement,
         $.assignment,
         $.output_statement,
         $.input_statement,
@@ -95,7 +105,10 @@ module.exports = grammar({
       ),
 
     // Comments
-    comment: ($) => token(seq("//", /.*/)),
+    comment: ($) =>
+      token(
+        choice(seq("//", /.*/), seq("/*", /[^*]*\*+([^/*][^*]*\*+)*/, "/")),
+      ),
 
     // Declarations
     declaration: ($) =>
@@ -122,10 +135,22 @@ module.exports = grammar({
         kw("ENDTYPE"),
       ),
 
+    define_statement: ($) =>
+      seq(
+        kw("DEFINE"),
+        field("name", $.identifier),
+        "(",
+        optional(seq($._expression, repeat(seq(",", $._expression)))),
+        ")",
+        ":",
+        field("type", $.identifier),
+      ),
+
     type_field: ($) => seq(kw("DECLARE"), $.identifier, ":", $.type),
 
     // Types
-    type: ($) => choice($.primitive_type, $.array_type, $.identifier),
+    type: ($) =>
+      choice($.primitive_type, $.array_type, $.dictionary_type, $.identifier),
 
     primitive_type: ($) =>
       choice(
@@ -148,6 +173,15 @@ module.exports = grammar({
         $.type,
       ),
 
+    dictionary_type: ($) =>
+      seq(
+        kw("DICTIONARY"),
+        kw("OF"),
+        field("key", $.type),
+        kw("TO"),
+        field("value", $.type),
+      ),
+
     array_bounds: ($) => seq($._expression, ":", $._expression),
 
     // Assignment
@@ -167,6 +201,7 @@ module.exports = grammar({
         $.string,
         $.char,
         $.boolean,
+        $.null,
         $.identifier,
         $.binary_expression,
         $.unary_expression,
@@ -210,6 +245,8 @@ module.exports = grammar({
 
     boolean: ($) => choice(kw("TRUE"), kw("FALSE")),
 
+    null: ($) => kw("NULL"),
+
     identifier: ($) => token(prec(-1, new RegExp(`[a-zA-Z_][a-zA-Z0-9_]*`))),
 
     // Array access
@@ -258,11 +295,11 @@ module.exports = grammar({
         ")",
       ),
 
-    // Output statement
+    // Output statement; EPRINT writes to standard error
     output_statement: ($) =>
       prec.right(
         seq(
-          kw("OUTPUT"),
+          choice(kw("OUTPUT"), kw("EPRINT")),
           optional(seq($._expression, repeat(seq(",", $._expression)))),
         ),
       ),
@@ -277,10 +314,23 @@ module.exports = grammar({
         field("condition", $._expression),
         kw("THEN"),
         repeat($._statement),
+        repeat($.elseif_clause),
         optional($.else_clause),
         kw("ENDIF"),
       ),
 
+    // ELSEIF, or ELSE IF on one line, continues the chain under one ENDIF
+    elseif_clause: ($) =>
+      seq(
+        alias(
+          token(prec(1, /[eE][lL][sS][eE][ \t]*[iI][fF]/)),
+          "ELSEIF",
+        ),
+        field("condition", $._expression),
+        kw("THEN"),
+        repeat($._statement),
+      ),
+
     else_clause: ($) => seq(kw("ELSE"), repeat($._statement)),
 
     // CASE statement
@@ -414,14 +464,22 @@ module.exports = grammar({
 
     // File operations
     file_operation: ($) =>
-      choice($.openfile, $.closefile, $.readfile, $.writefile),
+      choice(
+        $.openfile,
+        $.closefile,
+        $.readfile,
+        $.writefile,
+        $.seek,
+        $.getrecord,
+        $.putrecord,
+      ),
 
     openfile: ($) =>
       seq(
         kw("OPENFILE"),
         $._expression,
         kw("FOR"),
-        choice(kw("READ"), kw("WRITE"), kw("APPEND")),
+        choice(kw("READ"), kw("WRITE"), kw("APPEND"), kw("RANDOM")),
       ),
 
     closefile: ($) => seq(kw("CLOSEFILE"), $._expression),
@@ -429,6 +487,12 @@ module.exports = grammar({
     readfile: ($) => seq(kw("READFILE"), $._expression, ",", $.identifier),
 
     writefile: ($) => seq(kw("WRITEFILE"), $._expression, ",", $._expression),
+
+    seek: ($) => seq(kw("SEEK"), $._expression, ",", $._expression),
+
+    getrecord: ($) => seq(kw("GETRECORD"), $._expression, ",", $._expression),
+
+    putrecord: ($) => seq(kw("PUTRECORD"), $._expression, ",", $._expression),
   },
 });
 
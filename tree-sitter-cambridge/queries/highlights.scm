@@ -2,11 +2,13 @@
 [
   "DECLARE"
   "CONSTANT"
+  "DEFINE"
   "TYPE"
   "ENDTYPE"
   "IF"
   "THEN"
   "ELSE"
+  "ELSEIF"
   "ENDIF"
   "CASE"
   "OF"
@@ -31,6 +33,7 @@
   "BYREF"
   "INPUT"
   "OUTPUT"
+  "EPRINT"
   "OPENFILE"
   "CLOSEFILE"
   "READFILE"
@@ -38,12 +41,17 @@
   "READ"
   "WRITE"
   "APPEND"
+  "RANDOM"
+  "SEEK"
+  "GETRECORD"
+  "PUTRECORD"
   "CLASS"
   "ENDCLASS"
   "INHERITS"
   "NEW"
   "SUPER"
   "ARRAY"
+  "DICTIONARY"
 ] @keyword
 
 ; Visibility modifiers
@@ -101,6 +109,8 @@
 
 (boolean) @boolean
 
+(null) @constant.builtin
+
 ; Comments
 (comment) @comment
 
@@ -142,6 +152,9 @@
 (constant_declaration
   name: (identifier) @constant)
 
+(define_statement
+  name: (identifier) @constant)
+
 ; Field access
 (member_access
   (identifier) @property)